@@ -11,6 +11,8 @@ const (
 	ActionRequestReceived  LogAction = "RequestReceived"
 	ActionRequestCompleted LogAction = "RequestCompleted"
 	ActionRequestError     LogAction = "RequestError"
+	ActionRequestPanic     LogAction = "RequestPanic"
+	ActionRequestTimeout   LogAction = "RequestTimeout"
 
 	// Authentication Actions
 	ActionAuthAttempt LogAction = "AuthAttempt"
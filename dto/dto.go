@@ -1,14 +1,39 @@
 package dto
 
+// EncryptedRequest is a payload sealed with pkg/payloadcrypto, used for
+// encrypted request bodies and response data
 type EncryptedRequest struct {
-	Data     string `json:"data"`
+	// Data is the hex encoded AES-GCM ciphertext
+	Data string `json:"data"`
+	// CrcValue is the hex encoded HMAC-SHA256 of the envelope
 	CrcValue string `json:"crc_value"`
 }
 
+// DeviceInfo describes the device a request came from, as collected by
+// DeviceInfoMiddleware
 type DeviceInfo struct {
 	UserID    string
 	OS        string
 	OSVersion string
 	DeviceIP  string
-	LatLong   string
+	// LatLong is the raw X-Lat-Long header; Location holds it parsed
+	LatLong  string
+	Location *Coordinates
+
+	DeviceID       string
+	DeviceType     string
+	Browser        string
+	BrowserVersion string
+	UserAgent      string
+
+	// Fingerprint identifies the device across requests of the same user.
+	// It ignores versions, IP and location, which change over time
+	Fingerprint string
+	// NewDevice is set on the first request seen from Fingerprint
+	NewDevice bool
+}
+
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
 }
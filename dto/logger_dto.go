@@ -9,10 +9,11 @@ type LogEntry struct {
 	StartTime time.Time `json:"start_time"`
 	EndTime   time.Time `json:"end_time"`
 
-	RequestID    string `json:"request_id"`
-	TraceID      string `json:"trace_id"`
-	SpanID       string `json:"span_id"`
-	ParentSpanID string `json:"parent_span_id"`
+	RequestID     string `json:"request_id"`
+	CorrelationID string `json:"correlation_id"`
+	TraceID       string `json:"trace_id"`
+	SpanID        string `json:"span_id"`
+	ParentSpanID  string `json:"parent_span_id"`
 
 	UserID string `json:"user_id"`
 
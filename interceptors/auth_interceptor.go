@@ -0,0 +1,162 @@
+package interceptors
+
+import (
+	"common/constants"
+	"common/pkg/auth"
+	"common/pkg/jwt"
+	"common/pkg/logger"
+	"common/pkg/session"
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultPublicMethods are reachable without a token so that probes and
+// tooling work against an authenticated server
+var DefaultPublicMethods = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1.ServerReflection/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+}
+
+// AuthConfig configures the JWT auth interceptors
+type AuthConfig struct {
+	// PublicMethods are full method names, or "/package.Service/" prefixes,
+	// that skip authentication in addition to DefaultPublicMethods
+	PublicMethods []string
+	// Denylist rejects access tokens whose jti was revoked, such as by
+	// session.Manager, with Unauthenticated; denylist errors give
+	// Unavailable. Tokens without a jti cannot be revoked and pass
+	Denylist session.Denylist
+}
+
+// UnaryAuthInterceptor validates the bearer token in the authorization
+// metadata with pkg/jwt, like AuthMiddleware, and stores the caller in the
+// context as an *auth.Principal
+func UnaryAuthInterceptor(logger logger.Logger, jwt jwt.JWT, config ...AuthConfig) grpc.UnaryServerInterceptor {
+	public, denylist := publicMethods(config), authDenylist(config)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if isPublic(public, info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, logger, jwt, denylist, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor is the stream counterpart of UnaryAuthInterceptor
+func StreamAuthInterceptor(logger logger.Logger, jwt jwt.JWT, config ...AuthConfig) grpc.StreamServerInterceptor {
+	public, denylist := publicMethods(config), authDenylist(config)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isPublic(public, info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx, err := authenticate(ss.Context(), logger, jwt, denylist, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, wrapStream(ss, ctx))
+	}
+}
+
+func authenticate(ctx context.Context, logger logger.Logger, j jwt.JWT, denylist session.Denylist, fullMethod string) (context.Context, error) {
+	fail := func(msg string, err error) (context.Context, error) {
+		fields := []interface{}{"method", fullMethod, "request_id", RequestIDFromContext(ctx), "action", constants.ActionAuthFailed}
+		if err != nil {
+			fields = append(fields, "error", err)
+		}
+		logger.Warn(msg, fields...)
+		return nil, status.Error(codes.Unauthenticated, msg)
+	}
+
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return fail("missing or malformed authorization metadata", nil)
+	}
+	parsed, err := j.ValidateToken(token)
+	if err != nil {
+		return fail("invalid token", err)
+	}
+	claims, err := j.GetClaims(parsed)
+	if err != nil {
+		return fail("invalid token", err)
+	}
+	principal, err := auth.FromClaims(claims)
+	if err != nil {
+		return fail("invalid token", err)
+	}
+
+	if denylist != nil {
+		if jti, _ := claims["jti"].(string); jti != "" {
+			denied, err := denylist.Denied(ctx, jti)
+			if err != nil {
+				logger.Error("token revocation check failed",
+					"error", err,
+					"method", fullMethod,
+					"request_id", RequestIDFromContext(ctx),
+					"action", constants.ActionAuthFailed,
+				)
+				return nil, status.Error(codes.Unavailable, "token revocation check unavailable")
+			}
+			if denied {
+				logger.Warn("revoked token rejected",
+					"jti", jti,
+					"user_id", principal.Subject,
+					"session_id", principal.SessionID,
+					"method", fullMethod,
+					"request_id", RequestIDFromContext(ctx),
+					"action", constants.ActionAuthFailed,
+				)
+				return nil, status.Error(codes.Unauthenticated, "token has been revoked")
+			}
+		}
+	}
+	return auth.NewContext(ctx, principal), nil
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", false
+	}
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+func publicMethods(config []AuthConfig) []string {
+	public := append([]string(nil), DefaultPublicMethods...)
+	if len(config) > 0 {
+		public = append(public, config[0].PublicMethods...)
+	}
+	return public
+}
+
+func authDenylist(config []AuthConfig) session.Denylist {
+	if len(config) > 0 {
+		return config[0].Denylist
+	}
+	return nil
+}
+
+func isPublic(public []string, fullMethod string) bool {
+	for _, m := range public {
+		if m == fullMethod || (strings.HasSuffix(m, "/") && strings.HasPrefix(fullMethod, m)) {
+			return true
+		}
+	}
+	return false
+}
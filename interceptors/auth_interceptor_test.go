@@ -0,0 +1,142 @@
+package interceptors
+
+import (
+	"common/pkg/jwt"
+	"common/pkg/logger/loggertest"
+	"common/pkg/session"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryAuthInterceptor(t *testing.T) {
+	log, _ := loggertest.New()
+	tokens := jwt.New(jwt.JWTConfig{SecretKey: []byte("secret"), TokenDuration: time.Minute})
+	valid, err := tokens.GenerateToken(map[string]interface{}{"user_id": "u1", "role": "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	noUser, err := tokens.GenerateToken(map[string]interface{}{"role": "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := jwt.New(jwt.JWTConfig{SecretKey: []byte("other"), TokenDuration: time.Minute}).
+		GenerateToken(map[string]interface{}{"user_id": "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interceptor := UnaryAuthInterceptor(log, tokens, AuthConfig{PublicMethods: []string{"/users.v1.Users/Ping"}})
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		wantCode      codes.Code
+		wantUserID    string
+	}{
+		{"valid token", "/users.v1.Users/Get", "Bearer " + valid, codes.OK, "u1"},
+		{"lower-case scheme", "/users.v1.Users/Get", "bearer " + valid, codes.OK, "u1"},
+		{"missing metadata", "/users.v1.Users/Get", "", codes.Unauthenticated, ""},
+		{"wrong scheme", "/users.v1.Users/Get", "Basic " + valid, codes.Unauthenticated, ""},
+		{"wrong key", "/users.v1.Users/Get", "Bearer " + forged, codes.Unauthenticated, ""},
+		{"no user id", "/users.v1.Users/Get", "Bearer " + noUser, codes.Unauthenticated, ""},
+		{"public method", "/users.v1.Users/Ping", "", codes.OK, ""},
+		{"health is public", "/grpc.health.v1.Health/Check", "", codes.OK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization))
+			}
+
+			var gotUserID string
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					gotUserID = UserIDFromContext(ctx)
+					return nil, nil
+				})
+
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("code = %v, want %v", code, tt.wantCode)
+			}
+			if gotUserID != tt.wantUserID {
+				t.Errorf("user id = %q, want %q", gotUserID, tt.wantUserID)
+			}
+		})
+	}
+}
+
+type failingDenylist struct{}
+
+func (failingDenylist) Deny(ctx context.Context, jti string, until time.Time) error {
+	return errors.New("redis unavailable")
+}
+
+func (failingDenylist) Denied(ctx context.Context, jti string) (bool, error) {
+	return false, errors.New("redis unavailable")
+}
+
+func TestAuthInterceptorsDenylist(t *testing.T) {
+	log, recorder := loggertest.New()
+	tokens := jwt.New(jwt.JWTConfig{SecretKey: []byte("secret"), TokenDuration: time.Minute})
+	token := func(claims map[string]interface{}) string {
+		t.Helper()
+		signed, err := tokens.GenerateToken(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	revoked := token(map[string]interface{}{"user_id": "u1", "sid": "s1", "jti": "revoked"})
+	active := token(map[string]interface{}{"user_id": "u1", "sid": "s2", "jti": "active"})
+	noJTI := token(map[string]interface{}{"user_id": "u1"})
+
+	denylist := session.NewMemoryDenylist()
+	if err := denylist.Deny(context.Background(), "revoked", time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		denylist session.Denylist
+		token    string
+		wantCode codes.Code
+	}{
+		{"revoked token", denylist, revoked, codes.Unauthenticated},
+		{"active token", denylist, active, codes.OK},
+		{"token without jti", denylist, noJTI, codes.OK},
+		{"denylist unavailable", failingDenylist{}, active, codes.Unavailable},
+		{"no denylist", nil, revoked, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := AuthConfig{Denylist: tt.denylist}
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+tt.token))
+
+			_, err := UnaryAuthInterceptor(log, tokens, config)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/users.v1.Users/Get"},
+				func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("unary code = %v, want %v", code, tt.wantCode)
+			}
+
+			err = StreamAuthInterceptor(log, tokens, config)(nil, &testStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/users.v1.Users/Watch"},
+				func(srv interface{}, ss grpc.ServerStream) error { return nil })
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("stream code = %v, want %v", code, tt.wantCode)
+			}
+		})
+	}
+
+	recorder.AssertLogged(t, loggertest.WarnLevel, "revoked token rejected", map[string]interface{}{"jti": "revoked", "session_id": "s1"})
+	recorder.AssertLogged(t, loggertest.ErrorLevel, "token revocation check failed", nil)
+}
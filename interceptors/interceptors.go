@@ -0,0 +1,72 @@
+// Package interceptors provides gRPC server interceptors that mirror the gin
+// middlewares: request IDs, tracing, metrics, logging, panic recovery and JWT
+// authentication. Each comes as a unary and a stream interceptor
+package interceptors
+
+import (
+	"common/pkg/auth"
+	"common/pkg/correlation"
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDFromContext returns the ID set by the request ID interceptor; use
+// correlation.FromContext for the correlation ID too
+func RequestIDFromContext(ctx context.Context) string {
+	return correlation.RequestIDFromContext(ctx)
+}
+
+// UserIDFromContext returns the subject of the principal set by the auth
+// interceptor; use auth.FromContext for the whole principal
+func UserIDFromContext(ctx context.Context) string {
+	subject, _ := auth.SubjectFromContext(ctx)
+	return subject
+}
+
+// serverStream replaces the context of a stream so stream interceptors can
+// pass values down the chain like unary ones do
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func wrapStream(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &serverStream{ServerStream: ss, ctx: ctx}
+}
+
+// splitMethod splits "/package.Service/Method" into service and method
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", fullMethod
+	}
+	return service, method
+}
+
+// toStatus converts a handler error to the status the client receives,
+// mapping context errors to Canceled and DeadlineExceeded
+func toStatus(err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	return status.FromContextError(err)
+}
+
+// isServerError reports codes that indicate a fault on the server rather
+// than in the request, the gRPC counterpart of a 5xx status
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal,
+		codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
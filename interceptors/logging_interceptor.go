@@ -0,0 +1,63 @@
+package interceptors
+
+import (
+	"common/constants"
+	"common/pkg/logger"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+)
+
+// UnaryLoggingInterceptor logs each completed call with its status code and
+// latency. Server errors are logged at error level, other failures as
+// warnings, like LoggerMiddleware does for 5xx and 4xx responses
+func UnaryLoggingInterceptor(logger logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(logger, ctx, info.FullMethod, "unary", start, err)
+		return resp, err
+	}
+}
+
+// StreamLoggingInterceptor is the stream counterpart of UnaryLoggingInterceptor
+func StreamLoggingInterceptor(logger logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(logger, ss.Context(), info.FullMethod, "stream", start, err)
+		return err
+	}
+}
+
+func logCall(logger logger.Logger, ctx context.Context, fullMethod, kind string, start time.Time, err error) {
+	st := toStatus(err)
+	service, method := splitMethod(fullMethod)
+	fields := []interface{}{
+		"service", service,
+		"method", method,
+		"kind", kind,
+		"code", st.Code().String(),
+		"duration", time.Since(start),
+		"request_id", RequestIDFromContext(ctx),
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		fields = append(fields, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, "peer", p.Addr.String())
+	}
+
+	switch {
+	case st.Code() == codes.OK:
+		logger.Info("gRPC call completed", append(fields, "action", constants.ActionRequestCompleted)...)
+	case isServerError(st.Code()):
+		logger.Error("gRPC call failed", append(fields, "error", st.Message(), "action", constants.ActionRequestError)...)
+	default:
+		logger.Warn("gRPC call rejected", append(fields, "error", st.Message(), "action", constants.ActionRequestError)...)
+	}
+}
@@ -0,0 +1,44 @@
+package interceptors
+
+import (
+	"common/pkg/correlation"
+	"common/pkg/logger/loggertest"
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryLoggingInterceptor(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantLevel loggertest.Level
+		wantMsg   string
+	}{
+		{"ok", nil, loggertest.InfoLevel, "gRPC call completed"},
+		{"client error", status.Error(codes.InvalidArgument, "bad id"), loggertest.WarnLevel, "gRPC call rejected"},
+		{"server error", status.Error(codes.Unavailable, "db down"), loggertest.ErrorLevel, "gRPC call failed"},
+		{"canceled", context.Canceled, loggertest.WarnLevel, "gRPC call rejected"},
+		{"plain error", errors.New("boom"), loggertest.ErrorLevel, "gRPC call failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, recorder := loggertest.New()
+			ctx := correlation.NewContext(context.Background(), correlation.IDs{RequestID: "req-1", CorrelationID: "req-1"})
+
+			UnaryLoggingInterceptor(log)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/users.v1.Users/Get"},
+				func(ctx context.Context, req interface{}) (interface{}, error) { return nil, tt.err })
+
+			recorder.AssertLogged(t, tt.wantLevel, tt.wantMsg, map[string]interface{}{
+				"service":    "users.v1.Users",
+				"method":     "Get",
+				"request_id": "req-1",
+			})
+		})
+	}
+}
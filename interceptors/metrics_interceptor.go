@@ -0,0 +1,131 @@
+package interceptors
+
+import (
+	"common/middlewares"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Metrics records rate, errors and duration (RED) metrics for gRPC calls,
+// labelled by service, method and status code. The unary and stream
+// interceptors share the same collectors
+type Metrics struct {
+	excludes []string
+	handled  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+// NewMetrics registers the gRPC collectors. It uses the same configuration
+// as middlewares.MetricsMiddleware; ExcludePaths are matched against full
+// method names such as "/grpc.health.v1.Health/"
+func NewMetrics(config middlewares.MetricsConfig) *Metrics {
+	if config.DurationBuckets == nil {
+		config.DurationBuckets = middlewares.DefaultDurationBuckets
+	}
+	if config.Registerer == nil {
+		config.Registerer = prometheus.DefaultRegisterer
+	}
+	ns, sub := config.Namespace, config.Subsystem
+
+	m := &Metrics{
+		excludes: config.ExcludePaths,
+		handled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: sub,
+			Name: "grpc_server_handled_total",
+			Help: "Total number of gRPC calls completed on the server.",
+		}, []string{"service", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Subsystem: sub,
+			Name:    "grpc_server_handling_seconds",
+			Help:    "gRPC call latency in seconds.",
+			Buckets: config.DurationBuckets,
+		}, []string{"service", "method", "code"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: ns, Subsystem: sub,
+			Name: "grpc_server_in_flight",
+			Help: "Number of gRPC calls currently being served.",
+		}, []string{"service", "method"}),
+	}
+
+	m.handled = registerCollector(config.Registerer, m.handled)
+	m.duration = registerCollector(config.Registerer, m.duration)
+	m.inFlight = registerCollector(config.Registerer, m.inFlight)
+	return m
+}
+
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if m.excluded(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		done := m.start(info.FullMethod)
+		resp, err := handler(ctx, req)
+		done(ctx, err)
+		return resp, err
+	}
+}
+
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if m.excluded(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		done := m.start(info.FullMethod)
+		err := handler(srv, ss)
+		done(ss.Context(), err)
+		return err
+	}
+}
+
+func (m *Metrics) excluded(fullMethod string) bool {
+	for _, prefix := range m.excludes {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// start marks a call in flight and returns the function that records it
+func (m *Metrics) start(fullMethod string) func(ctx context.Context, err error) {
+	service, method := splitMethod(fullMethod)
+	inFlight := m.inFlight.WithLabelValues(service, method)
+	inFlight.Inc()
+	start := time.Now()
+
+	return func(ctx context.Context, err error) {
+		inFlight.Dec()
+		code := toStatus(err).Code().String()
+		m.handled.WithLabelValues(service, method, code).Inc()
+
+		duration := m.duration.WithLabelValues(service, method, code)
+		elapsed := time.Since(start).Seconds()
+		if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+			duration.(prometheus.ExemplarObserver).ObserveWithExemplar(elapsed, prometheus.Labels{"trace_id": sc.TraceID().String()})
+		} else {
+			duration.Observe(elapsed)
+		}
+	}
+}
+
+// registerCollector registers c, returning the existing collector when an
+// identical one was registered before (for example by a second server)
+func registerCollector[T prometheus.Collector](registerer prometheus.Registerer, c T) T {
+	if err := registerer.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
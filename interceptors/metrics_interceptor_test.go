@@ -0,0 +1,50 @@
+package interceptors
+
+import (
+	"common/middlewares"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetricsInterceptors(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := NewMetrics(middlewares.MetricsConfig{
+		Namespace:    "users",
+		Registerer:   registry,
+		ExcludePaths: []string{"/grpc.health.v1.Health/"},
+	})
+	unary := metrics.UnaryServerInterceptor()
+
+	call := func(method string, err error) {
+		unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(ctx context.Context, req interface{}) (interface{}, error) { return nil, err })
+	}
+	call("/users.v1.Users/Get", nil)
+	call("/users.v1.Users/Get", nil)
+	call("/users.v1.Users/Get", status.Error(codes.NotFound, "no such user"))
+	call("/grpc.health.v1.Health/Check", nil)
+
+	metrics.StreamServerInterceptor()(nil, &testStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/users.v1.Users/Watch"},
+		func(srv interface{}, ss grpc.ServerStream) error { return nil })
+
+	want := `
+# HELP users_grpc_server_handled_total Total number of gRPC calls completed on the server.
+# TYPE users_grpc_server_handled_total counter
+users_grpc_server_handled_total{code="NotFound",method="Get",service="users.v1.Users"} 1
+users_grpc_server_handled_total{code="OK",method="Get",service="users.v1.Users"} 2
+users_grpc_server_handled_total{code="OK",method="Watch",service="users.v1.Users"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "users_grpc_server_handled_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(registry, "users_grpc_server_handling_seconds"); n != 3 {
+		t.Errorf("duration series = %d, want 3", n)
+	}
+}
@@ -0,0 +1,53 @@
+package interceptors
+
+import (
+	"common/constants"
+	"common/pkg/logger"
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryRecoveryInterceptor turns panics into an Internal status and logs
+// them with the stack and request ID, like RecoveryMiddleware
+func UnaryRecoveryInterceptor(logger logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = recoverPanic(logger, ctx, info.FullMethod, recovered)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecoveryInterceptor is the stream counterpart of UnaryRecoveryInterceptor
+func StreamRecoveryInterceptor(logger logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = recoverPanic(logger, ss.Context(), info.FullMethod, recovered)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recoverPanic(logger logger.Logger, ctx context.Context, fullMethod string, recovered interface{}) error {
+	err, ok := recovered.(error)
+	if !ok {
+		err = fmt.Errorf("%v", recovered)
+	}
+	logger.Error("panic recovered",
+		"error", err,
+		"stack", string(debug.Stack()),
+		"request_id", RequestIDFromContext(ctx),
+		"method", fullMethod,
+		"action", constants.ActionRequestPanic,
+	)
+	return status.Error(codes.Internal, "internal server error")
+}
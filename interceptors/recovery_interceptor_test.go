@@ -0,0 +1,49 @@
+package interceptors
+
+import (
+	"common/pkg/correlation"
+	"common/pkg/logger/loggertest"
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context { return s.ctx }
+
+func TestRecoveryInterceptors(t *testing.T) {
+	log, recorder := loggertest.New()
+	ctx := correlation.NewContext(context.Background(), correlation.IDs{RequestID: "req-1", CorrelationID: "req-1"})
+
+	_, err := UnaryRecoveryInterceptor(log)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/users.v1.Users/Get"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { panic("boom") })
+	if status.Code(err) != codes.Internal {
+		t.Errorf("unary code = %v, want Internal", status.Code(err))
+	}
+
+	err = StreamRecoveryInterceptor(log)(nil, &testStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/users.v1.Users/Watch"},
+		func(srv interface{}, ss grpc.ServerStream) error { panic("boom") })
+	if status.Code(err) != codes.Internal {
+		t.Errorf("stream code = %v, want Internal", status.Code(err))
+	}
+
+	entries := recorder.FilterMessage("panic recovered").All()
+	if len(entries) != 2 {
+		t.Fatalf("logged %d panic entries, want 2", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["request_id"] != "req-1" || fields["method"] != "/users.v1.Users/Get" {
+		t.Errorf("fields = %v", fields)
+	}
+	if stack, _ := fields["stack"].(string); !strings.Contains(stack, "recovery_interceptor_test.go") {
+		t.Errorf("stack does not include the panicking handler:\n%s", stack)
+	}
+}
@@ -0,0 +1,65 @@
+package interceptors
+
+import (
+	"common/pkg/correlation"
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDMetadataKey and CorrelationIDMetadataKey carry the IDs in both
+// directions, like the X-Request-ID and X-Correlation-ID headers do for HTTP
+const (
+	RequestIDMetadataKey     = "x-request-id"
+	CorrelationIDMetadataKey = "x-correlation-id"
+)
+
+// UnaryRequestIDInterceptor reuses valid IDs from incoming metadata or
+// generates a request ID, stores them with correlation.NewContext and echoes
+// them in the response header
+func UnaryRequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+// StreamRequestIDInterceptor is the stream counterpart of UnaryRequestIDInterceptor
+func StreamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, wrapStream(ss, withRequestID(ss.Context())))
+	}
+}
+
+// UnaryClientCorrelationInterceptor sends the IDs in the context as
+// outgoing metadata, so downstream services continue the correlation
+func UnaryClientCorrelationInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingCorrelation(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientCorrelationInterceptor is the stream counterpart of
+// UnaryClientCorrelationInterceptor
+func StreamClientCorrelationInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingCorrelation(ctx), desc, cc, method, opts...)
+	}
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ids := correlation.Extract(metadataCarrier(md), nil)
+
+	// fails only outside a server call, where there is no header to set
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadataKey, ids.RequestID, CorrelationIDMetadataKey, ids.CorrelationID))
+	return correlation.NewContext(ctx, ids)
+}
+
+func outgoingCorrelation(ctx context.Context) context.Context {
+	ids, ok := correlation.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, RequestIDMetadataKey, ids.RequestID, CorrelationIDMetadataKey, ids.CorrelationID)
+}
@@ -0,0 +1,88 @@
+package interceptors
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryTracingInterceptor continues the trace propagated in the incoming
+// metadata and records the call as a server span
+func UnaryTracingInterceptor(tracer trace.Tracer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startSpan(ctx, tracer, info.FullMethod)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		endSpan(span, err)
+		return resp, err
+	}
+}
+
+// StreamTracingInterceptor is the stream counterpart of UnaryTracingInterceptor
+func StreamTracingInterceptor(tracer trace.Tracer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startSpan(ss.Context(), tracer, info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, wrapStream(ss, ctx))
+		endSpan(span, err)
+		return err
+	}
+}
+
+func startSpan(ctx context.Context, tracer trace.Tracer, fullMethod string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	service, method := splitMethod(fullMethod)
+	return tracer.Start(ctx, fullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+			attribute.String("request_id", RequestIDFromContext(ctx)),
+		),
+	)
+}
+
+func endSpan(span trace.Span, err error) {
+	st := toStatus(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(st.Code())))
+	if err != nil && isServerError(st.Code()) {
+		span.SetStatus(otelcodes.Error, st.Message())
+		span.RecordError(err)
+	}
+}
+
+// metadataCarrier adapts incoming metadata to the propagation and
+// correlation APIs. metadata.MD lower-cases the header names
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+var _ propagation.TextMapCarrier = metadataCarrier(nil)
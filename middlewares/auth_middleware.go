@@ -1,9 +1,13 @@
 package middlewares
 
 import (
+	"common/constants"
+	"common/pkg/auth"
 	"common/pkg/jwt"
 	"common/pkg/logger"
+	"common/pkg/session"
 	"common/pkg/utils/response"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -13,7 +17,28 @@ import (
 
 const BearerTokenPrefix = "Bearer"
 
+const (
+	// ClaimsKey is the gin context key holding the validated token claims
+	ClaimsKey = "claims"
+	// PrincipalKey is the gin context key holding the *auth.Principal
+	PrincipalKey = "principal"
+)
+
+type AuthConfig struct {
+	// Denylist rejects access tokens whose jti was revoked, such as by
+	// session.Manager. Tokens without a jti cannot be revoked and pass
+	Denylist session.Denylist
+}
+
+// AuthMiddleware validates bearer tokens without a revocation check
 func AuthMiddleware(logger logger.Logger, jwt jwt.JWT) gin.HandlerFunc {
+	return NewAuthMiddleware(logger, jwt, AuthConfig{})
+}
+
+// NewAuthMiddleware validates the bearer token and stores its claims and
+// principal. With a Denylist, revoked tokens get 401 and denylist errors 503,
+// since accepting the token could let a revoked session through
+func NewAuthMiddleware(logger logger.Logger, jwt jwt.JWT, config AuthConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token, err := extractBearerToken(c)
 		if err != nil {
@@ -42,20 +67,82 @@ func AuthMiddleware(logger logger.Logger, jwt jwt.JWT) gin.HandlerFunc {
 			return
 		}
 
-		userId, ok := tokenData["user_id"].(string)
-		if !ok {
-			resp := response.Error(http.StatusBadRequest, "user id does not available in token", nil)
+		principal, err := auth.FromClaims(tokenData)
+		if errors.Is(err, auth.ErrServiceToken) {
+			logger.Warn("service token rejected",
+				"request_id", requestID(c),
+				"action", constants.ActionAuthFailed,
+			)
+			resp := response.Unauthorized("Service tokens cannot authenticate users")
+			c.AbortWithStatusJSON(resp.Status, resp)
+			return
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("Invalid token: %v | IP: %s", err, c.ClientIP()))
+			resp := response.Error(http.StatusBadRequest, "Invalid token", err.Error())
 			c.JSON(resp.Status, resp)
 			c.Abort()
 			return
 		}
 
-		c.Set("user_id", userId)
+		if config.Denylist != nil {
+			if jti, _ := tokenData["jti"].(string); jti != "" {
+				denied, err := config.Denylist.Denied(c.Request.Context(), jti)
+				if err != nil {
+					logger.Error("token revocation check failed",
+						"error", err,
+						"request_id", requestID(c),
+						"action", constants.ActionAuthFailed,
+					)
+					resp := response.ServiceUnavailable("Token revocation check unavailable")
+					c.AbortWithStatusJSON(resp.Status, resp)
+					return
+				}
+				if denied {
+					logger.Warn("revoked token rejected",
+						"jti", jti,
+						"user_id", principal.Subject,
+						"session_id", principal.SessionID,
+						"request_id", requestID(c),
+						"action", constants.ActionAuthFailed,
+					)
+					resp := response.Unauthorized("Token has been revoked")
+					c.AbortWithStatusJSON(resp.Status, resp)
+					return
+				}
+			}
+		}
+
+		c.Set("user_id", principal.Subject)
+		c.Set(ClaimsKey, tokenData)
+		c.Set(PrincipalKey, principal)
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), principal))
 
 		c.Next()
 	}
 }
 
+// GetClaims returns the token claims stored by AuthMiddleware
+func GetClaims(c *gin.Context) (map[string]interface{}, bool) {
+	claims, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	m, ok := claims.(map[string]interface{})
+	return m, ok
+}
+
+// GetPrincipal returns the caller stored by AuthMiddleware, from the gin
+// context or the request context, or auth.ErrNoPrincipal
+func GetPrincipal(c *gin.Context) (*auth.Principal, error) {
+	if value, ok := c.Get(PrincipalKey); ok {
+		if principal, ok := value.(*auth.Principal); ok {
+			return principal, nil
+		}
+	}
+	return auth.FromContext(c.Request.Context())
+}
+
 func extractBearerToken(c *gin.Context) (string, error) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
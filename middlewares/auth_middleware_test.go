@@ -0,0 +1,135 @@
+package middlewares
+
+import (
+	"common/pkg/auth"
+	"common/pkg/jwt"
+	"common/pkg/logger/loggertest"
+	"common/pkg/session"
+	"common/pkg/utils"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddlewarePrincipal(t *testing.T) {
+	log, _ := loggertest.New()
+	tokens := jwt.New(jwt.JWTConfig{SecretKey: []byte("secret"), TokenDuration: time.Minute})
+
+	router := gin.New()
+	router.GET("/me", AuthMiddleware(log, tokens), func(c *gin.Context) {
+		fromGin, err := GetPrincipal(c)
+		if err != nil {
+			t.Fatalf("GetPrincipal: %v", err)
+		}
+		// service layers only see the request context
+		fromCtx, err := auth.FromContext(c.Request.Context())
+		if err != nil || fromCtx != fromGin {
+			t.Fatalf("auth.FromContext = %v, %v", fromCtx, err)
+		}
+		if userID, err := utils.GetUserIdFromContext(c); err != nil || userID != "u1" {
+			t.Errorf("GetUserIdFromContext = %q, %v", userID, err)
+		}
+		c.JSON(http.StatusOK, gin.H{"tenant": fromCtx.TenantID, "session": fromCtx.SessionID})
+	})
+
+	tests := []struct {
+		name       string
+		claims     map[string]interface{}
+		wantStatus int
+		wantBody   string
+	}{
+		{"principal", map[string]interface{}{"user_id": "u1", "tenant_id": "t1", "sid": "s1"}, http.StatusOK, `{"session":"s1","tenant":"t1"}`},
+		{"missing subject", map[string]interface{}{"tenant_id": "t1"}, http.StatusBadRequest, ""},
+		{"service token", map[string]interface{}{"sub": "billing", "token_type": auth.ServiceTokenType}, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tokens.GenerateToken(tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestGetUserIdFromContextWithoutAuth(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if _, err := utils.GetUserIdFromContext(c); err == nil {
+		t.Error("expected an error, not a user ID")
+	}
+	if _, err := GetPrincipal(c); err != auth.ErrNoPrincipal {
+		t.Errorf("GetPrincipal err = %v, want ErrNoPrincipal", err)
+	}
+}
+
+type failingDenylist struct{}
+
+func (failingDenylist) Deny(ctx context.Context, jti string, until time.Time) error { return nil }
+
+func (failingDenylist) Denied(ctx context.Context, jti string) (bool, error) {
+	return false, errors.New("redis unavailable")
+}
+
+func TestAuthMiddlewareDenylist(t *testing.T) {
+	log, recorder := loggertest.New()
+	tokens := jwt.New(jwt.JWTConfig{SecretKey: []byte("secret"), TokenDuration: time.Minute})
+	denylist := session.NewMemoryDenylist()
+	if err := denylist.Deny(context.Background(), "revoked", time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router := gin.New()
+	router.GET("/me", NewAuthMiddleware(log, tokens, AuthConfig{Denylist: denylist}), ok)
+	router.GET("/unchecked", AuthMiddleware(log, tokens), ok)
+	router.GET("/failing", NewAuthMiddleware(log, tokens, AuthConfig{Denylist: failingDenylist{}}), ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		claims     map[string]interface{}
+		wantStatus int
+	}{
+		{"active token", "/me", map[string]interface{}{"sub": "u1", "jti": "active"}, http.StatusOK},
+		{"revoked token", "/me", map[string]interface{}{"sub": "u1", "jti": "revoked"}, http.StatusUnauthorized},
+		{"token without jti", "/me", map[string]interface{}{"sub": "u1"}, http.StatusOK},
+		{"no denylist", "/unchecked", map[string]interface{}{"sub": "u1", "jti": "revoked"}, http.StatusOK},
+		{"denylist error", "/failing", map[string]interface{}{"sub": "u1", "jti": "active"}, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tokens.GenerateToken(tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+
+	recorder.AssertLogged(t, loggertest.WarnLevel, "revoked token rejected", map[string]interface{}{"jti": "revoked"})
+}
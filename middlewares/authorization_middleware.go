@@ -0,0 +1,116 @@
+package middlewares
+
+import (
+	"common/constants"
+	"common/pkg/authz"
+	"common/pkg/logger"
+	"common/pkg/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubjectKey is the gin context key holding the authorized authz.Subject
+const SubjectKey = "subject"
+
+// Authorizer protects routes using the principal stored by AuthMiddleware,
+// which must run first. Requests without claims get 401, denied requests
+// 403 through response.Forbidden
+type Authorizer struct {
+	logger logger.Logger
+	model  authz.Model
+}
+
+// NewAuthorizer builds subjects with model, so roles inherit through its
+// hierarchy and grant its role permissions. logger may be nil
+func NewAuthorizer(logger logger.Logger, model authz.Model) *Authorizer {
+	return &Authorizer{logger: logger, model: model}
+}
+
+var defaultAuthorizer = NewAuthorizer(nil, authz.Model{})
+
+// RequireRoles allows subjects holding at least one of roles
+func (a *Authorizer) RequireRoles(roles ...string) gin.HandlerFunc {
+	return a.Authorize(authz.AnyRole(roles...))
+}
+
+// RequireScopes allows subjects granted every one of scopes, directly or
+// through a wildcard such as "orders:*"
+func (a *Authorizer) RequireScopes(scopes ...string) gin.HandlerFunc {
+	return a.Authorize(authz.AllPermissions(scopes...))
+}
+
+// Authorize allows requests the policy allows. A policy error answers 500
+func (a *Authorizer) Authorize(policy authz.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := GetPrincipal(c)
+		if err != nil {
+			resp := response.Unauthorized("Authentication required")
+			c.AbortWithStatusJSON(resp.Status, resp)
+			return
+		}
+
+		subject := a.model.Subject(principal)
+		decision, err := policy.Evaluate(c.Request.Context(), authz.Request{
+			Subject: subject,
+			Method:  c.Request.Method,
+			Route:   c.FullPath(),
+			Path:    c.Request.URL.Path,
+		})
+		if err != nil {
+			a.log(c, subject, "authorization failed", "error", err)
+			c.Error(err)
+			resp := response.InternalServerError("Authorization failed")
+			c.AbortWithStatusJSON(resp.Status, resp)
+			return
+		}
+		if !decision.Allowed {
+			a.log(c, subject, "access denied", "reason", decision.Reason)
+			resp := response.Forbidden("You do not have permission to perform this action")
+			c.AbortWithStatusJSON(resp.Status, resp)
+			return
+		}
+
+		c.Set(SubjectKey, subject)
+		c.Next()
+	}
+}
+
+func (a *Authorizer) log(c *gin.Context, subject authz.Subject, msg string, fields ...interface{}) {
+	if a.logger == nil {
+		return
+	}
+	a.logger.Warn(msg, append(fields,
+		"user_id", subject.UserID,
+		"method", c.Request.Method,
+		"route", c.FullPath(),
+		"request_id", requestID(c),
+		"action", constants.ActionAuthFailed,
+	)...)
+}
+
+// GetSubject returns the subject stored by an authorization middleware
+func GetSubject(c *gin.Context) (authz.Subject, bool) {
+	subject, ok := c.Get(SubjectKey)
+	if !ok {
+		return authz.Subject{}, false
+	}
+	s, ok := subject.(authz.Subject)
+	return s, ok
+}
+
+// RequireRoles allows subjects holding at least one of roles, without a
+// role hierarchy. Use NewAuthorizer for inherited roles and role permissions
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return defaultAuthorizer.RequireRoles(roles...)
+}
+
+// RequireScopes allows subjects whose token grants every one of scopes
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return defaultAuthorizer.RequireScopes(scopes...)
+}
+
+// Authorize allows requests the policy allows, building subjects without a
+// role hierarchy
+func Authorize(policy authz.Policy) gin.HandlerFunc {
+	return defaultAuthorizer.Authorize(policy)
+}
@@ -0,0 +1,85 @@
+package middlewares
+
+import (
+	"common/pkg/auth"
+	"common/pkg/authz"
+	"common/pkg/logger/loggertest"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthorizationMiddleware(t *testing.T) {
+	log, recorder := loggertest.New()
+	authorizer := NewAuthorizer(log, authz.Model{
+		Hierarchy:       authz.RoleHierarchy{"admin": {"manager"}, "manager": {"user"}},
+		RolePermissions: map[string][]string{"manager": {"orders:*"}},
+	})
+	failing := authz.PolicyFunc(func(ctx context.Context, req authz.Request) (authz.Decision, error) {
+		return authz.Decision{}, errors.New("engine unavailable")
+	})
+
+	router := gin.New()
+	users := map[string]map[string]interface{}{
+		"admin":   {"user_id": "a1", "roles": []interface{}{"admin"}},
+		"user":    {"user_id": "u1", "role": "user", "scope": "reports:read"},
+		"service": {"sub": "billing", "scopes": []interface{}{"reports:read"}},
+	}
+	router.Use(func(c *gin.Context) {
+		if claims, ok := users[c.GetHeader("X-Test-User")]; ok {
+			principal, err := auth.FromClaims(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Set(PrincipalKey, principal)
+		}
+	})
+	router.GET("/admin", authorizer.RequireRoles("admin"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/team", authorizer.RequireRoles("manager"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.DELETE("/orders/:id", authorizer.RequireScopes("orders:delete"), func(c *gin.Context) {
+		if subject, ok := GetSubject(c); !ok || subject.UserID == "" {
+			t.Error("subject not stored")
+		}
+		c.Status(http.StatusOK)
+	})
+	router.GET("/reports", RequireScopes("reports:read"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/broken", Authorize(failing), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		user       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"admin holds admin", "admin", http.MethodGet, "/admin", http.StatusOK},
+		{"admin inherits manager", "admin", http.MethodGet, "/team", http.StatusOK},
+		{"inherited wildcard permission", "admin", http.MethodDelete, "/orders/1", http.StatusOK},
+		{"user lacks role", "user", http.MethodGet, "/team", http.StatusForbidden},
+		{"user lacks permission", "user", http.MethodDelete, "/orders/1", http.StatusForbidden},
+		{"token scope", "user", http.MethodGet, "/reports", http.StatusOK},
+		{"scopes claim", "service", http.MethodGet, "/reports", http.StatusOK},
+		{"default authorizer has no hierarchy", "admin", http.MethodGet, "/reports", http.StatusForbidden},
+		{"unauthenticated", "", http.MethodGet, "/admin", http.StatusUnauthorized},
+		{"policy error", "user", http.MethodGet, "/broken", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-Test-User", tt.user)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	if entries := recorder.FilterMessage("access denied").FilterField("user_id", "u1").All(); len(entries) != 2 {
+		t.Errorf("logged %d denials for u1, want 2", len(entries))
+	}
+}
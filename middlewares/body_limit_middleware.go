@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"common/pkg/utils/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimitMiddleware rejects request bodies larger than maxBytes with 413.
+// Bodies without a declared length are cut off at maxBytes, and reading past
+// the limit fails with *http.MaxBytesError
+func BodyLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			resp := response.RequestEntityTooLarge("Request body too large")
+			c.AbortWithStatusJSON(resp.Status, resp)
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(BodyLimitMiddleware(8))
+	router.POST("/echo", func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		want          int
+	}{
+		{"within limit", `{"a":1}`, 7, http.StatusOK},
+		{"declared too large", `{"a":"long value"}`, 18, http.StatusRequestEntityTooLarge},
+		{"undeclared too large", `{"a":"long value"}`, -1, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(tt.body))
+			req.ContentLength = tt.contentLength
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
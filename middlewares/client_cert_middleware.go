@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"crypto/x509"
+
+	"github.com/gin-gonic/gin"
+)
+
+const clientIdentityKey = "client_identity"
+
+// ClientIdentity is the subject of a verified TLS client certificate
+type ClientIdentity struct {
+	CommonName   string   `json:"common_name"`
+	Organization []string `json:"organization,omitempty"`
+	DNSNames     []string `json:"dns_names,omitempty"`
+	// URIs carries URI SANs such as SPIFFE IDs (spiffe://cluster/ns/svc)
+	URIs         []string `json:"uris,omitempty"`
+	SerialNumber string   `json:"serial_number"`
+}
+
+// ClientCertMiddleware stores the identity of a verified client certificate
+// in the context as client_identity. Requests without a verified chain are
+// left untouched, so certificates that were only requested are never trusted
+func ClientCertMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := c.Request.TLS
+		if state != nil && len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+			c.Set(clientIdentityKey, newClientIdentity(state.VerifiedChains[0][0]))
+		}
+		c.Next()
+	}
+}
+
+// GetClientIdentity returns the identity set by ClientCertMiddleware
+func GetClientIdentity(c *gin.Context) (ClientIdentity, bool) {
+	value, ok := c.Get(clientIdentityKey)
+	if !ok {
+		return ClientIdentity{}, false
+	}
+	identity, ok := value.(ClientIdentity)
+	return identity, ok
+}
+
+func newClientIdentity(cert *x509.Certificate) ClientIdentity {
+	identity := ClientIdentity{
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+		DNSNames:     cert.DNSNames,
+		SerialNumber: cert.SerialNumber.String(),
+	}
+	for _, uri := range cert.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+	return identity
+}
@@ -0,0 +1,244 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+var (
+	// DefaultEncodings lists the supported encodings in order of preference
+	DefaultEncodings = []string{EncodingBrotli, EncodingGzip}
+
+	// DefaultCompressionMinLength skips bodies too small to benefit
+	DefaultCompressionMinLength = 1024
+)
+
+type CompressionConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Encodings in order of preference; DefaultEncodings when empty
+	Encodings []string `json:"encodings" yaml:"encodings"`
+	// GzipLevel and BrotliLevel use each library's default when zero
+	GzipLevel   int `json:"gzip_level" yaml:"gzip_level"`
+	BrotliLevel int `json:"brotli_level" yaml:"brotli_level"`
+	// MinLength is the smallest body in bytes that is compressed
+	MinLength int `json:"min_length" yaml:"min_length"`
+	// ExcludePaths are URL path prefixes that are never compressed
+	ExcludePaths []string `json:"exclude_paths" yaml:"exclude_paths"`
+}
+
+// CompressionMiddleware compresses responses with brotli or gzip as
+// negotiated through Accept-Encoding. Responses that already carry a
+// Content-Encoding, are shorter than MinLength or are of an already
+// compressed media type are sent as they are
+func CompressionMiddleware(config CompressionConfig) gin.HandlerFunc {
+	if len(config.Encodings) == 0 {
+		config.Encodings = DefaultEncodings
+	}
+	if config.GzipLevel == 0 {
+		config.GzipLevel = gzip.DefaultCompression
+	}
+	if config.BrotliLevel == 0 {
+		config.BrotliLevel = brotli.DefaultCompression
+	}
+	if config.MinLength <= 0 {
+		config.MinLength = DefaultCompressionMinLength
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		for _, prefix := range config.ExcludePaths {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), config.Encodings)
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, config: &config}
+		c.Writer = cw
+		defer func() {
+			cw.finish()
+			c.Writer = cw.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// negotiateEncoding picks the acceptable encoding with the highest q-value,
+// breaking ties by the server's preference order
+func negotiateEncoding(header string, supported []string) string {
+	if header == "" {
+		return ""
+	}
+
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range supported {
+		q, ok := accepted[encoding]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of the body until MinLength is reached,
+// then switches to streaming through the encoder
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	config   *CompressionConfig
+
+	buf         []byte
+	encoder     io.WriteCloser
+	passThrough bool
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.passThrough {
+		return w.ResponseWriter.Write(data)
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+
+	if w.buf == nil && !w.compressible() {
+		w.passThrough = true
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.config.MinLength {
+		if err := w.startEncoder(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports buffered bodies as written, so later middleware does not
+// write a second response on top
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// Flush sends what is buffered. A body flushed before reaching MinLength is
+// streamed uncompressed, which keeps server-sent events working
+func (w *compressWriter) Flush() {
+	if w.encoder != nil {
+		if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+			flusher.Flush()
+		}
+	} else if !w.passThrough {
+		w.passThrough = true
+		w.writeBuffered()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) compressible() bool {
+	if w.ResponseWriter.Written() || w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	switch status := w.Status(); {
+	case status < 200, status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return !isCompressedMediaType(w.Header().Get("Content-Type"))
+}
+
+func (w *compressWriter) startEncoder() error {
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+
+	switch w.encoding {
+	case EncodingBrotli:
+		w.encoder = brotli.NewWriterLevel(w.ResponseWriter, w.config.BrotliLevel)
+	default:
+		encoder, err := gzip.NewWriterLevel(w.ResponseWriter, w.config.GzipLevel)
+		if err != nil {
+			return err
+		}
+		w.encoder = encoder
+	}
+
+	buf := w.buf
+	w.buf = nil
+	_, err := w.encoder.Write(buf)
+	return err
+}
+
+func (w *compressWriter) writeBuffered() {
+	if len(w.buf) > 0 {
+		buf := w.buf
+		w.buf = nil
+		w.ResponseWriter.Write(buf)
+	}
+}
+
+// finish closes the encoder, or sends a body that stayed under MinLength as is
+func (w *compressWriter) finish() {
+	if w.encoder != nil {
+		w.encoder.Close()
+		return
+	}
+	w.writeBuffered()
+}
+
+func isCompressedMediaType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+
+	switch {
+	case strings.HasPrefix(mediaType, "image/") && mediaType != "image/svg+xml",
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "audio/"):
+		return true
+	}
+	switch mediaType {
+	case "application/zip", "application/gzip", "application/x-gzip",
+		"application/x-brotli", "application/zstd", "application/octet-stream":
+		return true
+	}
+	return false
+}
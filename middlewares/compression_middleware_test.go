@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", EncodingGzip},
+		{"gzip, br", EncodingBrotli},
+		{"br;q=0.5, gzip", EncodingGzip},
+		{"br;q=0, gzip;q=0", ""},
+		{"*", EncodingBrotli},
+		{"identity", ""},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header, DefaultEncodings); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	large := strings.Repeat("compress me ", 500)
+
+	router := gin.New()
+	router.Use(CompressionMiddleware(CompressionConfig{Enabled: true}))
+	router.GET("/large", func(c *gin.Context) { c.String(http.StatusOK, large) })
+	router.GET("/small", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.GET("/image", func(c *gin.Context) { c.Data(http.StatusOK, "image/png", []byte(large)) })
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		EncodingGzip:   func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		EncodingBrotli: func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		"":             func(r io.Reader) (io.Reader, error) { return r, nil },
+	}
+
+	tests := []struct {
+		name, path, accept, wantEncoding, wantBody string
+	}{
+		{"gzip", "/large", "gzip", EncodingGzip, large},
+		{"brotli", "/large", "gzip, br", EncodingBrotli, large},
+		{"not accepted", "/large", "", "", large},
+		{"below min length", "/small", "gzip", "", "ok"},
+		{"compressed media type", "/image", "gzip", "", large},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			reader, err := decoders[tt.wantEncoding](bytes.NewReader(w.Body.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("decoded body has %d bytes, want %d", len(body), len(tt.wantBody))
+			}
+			if tt.wantEncoding != "" && w.Body.Len() >= len(large) {
+				t.Errorf("compressed body is %d bytes, not smaller than %d", w.Body.Len(), len(large))
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	DefaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	DefaultCORSHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	DefaultCORSMaxAge  = 12 * time.Hour
+)
+
+// corsSubdomainPattern is the only accepted wildcard form: "*" as the whole
+// leading label of a host with at least two further labels, so
+// "https://*.example.com:8443" is allowed but "https://*" or "https://*.com"
+// are not. Wildcards are never allowed with credentials
+var corsSubdomainPattern = regexp.MustCompile(`^https?://\*\.([a-zA-Z0-9-]+\.)+[a-zA-Z0-9-]+(:[0-9]+)?$`)
+
+// CORSPolicy describes which cross-origin requests are allowed. Origins may
+// be exact ("https://app.example.com"), a subdomain pattern
+// ("https://*.example.com") or "*" for any origin. Patterns and "*" cannot be
+// combined with AllowCredentials
+type CORSPolicy struct {
+	AllowOrigins     []string      `json:"allow_origins" yaml:"allow_origins"`
+	AllowMethods     []string      `json:"allow_methods" yaml:"allow_methods"`
+	AllowHeaders     []string      `json:"allow_headers" yaml:"allow_headers"`
+	ExposeHeaders    []string      `json:"expose_headers" yaml:"expose_headers"`
+	AllowCredentials bool          `json:"allow_credentials" yaml:"allow_credentials"`
+	MaxAge           time.Duration `json:"max_age" yaml:"max_age"`
+}
+
+type CORSConfig struct {
+	CORSPolicy `yaml:",inline"`
+
+	// Groups replaces the policy for requests whose path is under the given
+	// prefix ("/public"). The longest matching prefix wins
+	Groups map[string]CORSPolicy `json:"groups" yaml:"groups"`
+}
+
+// Validate rejects policies browsers would refuse or that are unsafe, such
+// as allowing any origin together with credentials
+func (c CORSConfig) Validate() error {
+	if err := c.CORSPolicy.Validate(); err != nil {
+		return err
+	}
+	for prefix, policy := range c.Groups {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("cors group %q: prefix must start with /", prefix)
+		}
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("cors group %q: %w", prefix, err)
+		}
+	}
+	return nil
+}
+
+func (p CORSPolicy) Validate() error {
+	if len(p.AllowOrigins) == 0 {
+		return errors.New("cors: at least one allowed origin is required")
+	}
+	for _, origin := range p.AllowOrigins {
+		if origin == "*" {
+			if len(p.AllowOrigins) > 1 {
+				return errors.New("cors: \"*\" cannot be combined with other origins")
+			}
+			if p.AllowCredentials {
+				return errors.New("cors: wildcard origin \"*\" cannot be used with credentials")
+			}
+			continue
+		}
+		if strings.Contains(origin, "*") {
+			if !corsSubdomainPattern.MatchString(origin) {
+				return fmt.Errorf("cors: origin %q: a wildcard is only allowed as the first label of a domain, as in \"https://*.example.com\"", origin)
+			}
+			// any subdomain, including a compromised or user-controlled
+			// one, could make credentialed requests
+			if p.AllowCredentials {
+				return fmt.Errorf("cors: wildcard origin %q cannot be used with credentials", origin)
+			}
+		}
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("cors: origin %q must start with http:// or https://", origin)
+		}
+	}
+	if p.MaxAge < 0 {
+		return errors.New("cors: max age must not be negative")
+	}
+	return nil
+}
+
+// CORSMiddleware applies the root policy, or the policy of the longest
+// matching group. The config must be valid; see CORSConfig.Validate
+func CORSMiddleware(config CORSConfig) gin.HandlerFunc {
+	root := newCORSHandler(config.CORSPolicy)
+	if len(config.Groups) == 0 {
+		return root
+	}
+
+	type group struct {
+		prefix  string
+		handler gin.HandlerFunc
+	}
+	groups := make([]group, 0, len(config.Groups))
+	for prefix, policy := range config.Groups {
+		groups = append(groups, group{strings.TrimSuffix(prefix, "/"), newCORSHandler(policy)})
+	}
+	sort.Slice(groups, func(i, j int) bool { return len(groups[i].prefix) > len(groups[j].prefix) })
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		for _, g := range groups {
+			if path == g.prefix || strings.HasPrefix(path, g.prefix+"/") || g.prefix == "" {
+				g.handler(c)
+				return
+			}
+		}
+		root(c)
+	}
+}
+
+func newCORSHandler(p CORSPolicy) gin.HandlerFunc {
+	config := cors.Config{
+		AllowMethods:     p.AllowMethods,
+		AllowHeaders:     p.AllowHeaders,
+		ExposeHeaders:    p.ExposeHeaders,
+		AllowCredentials: p.AllowCredentials,
+		MaxAge:           p.MaxAge,
+	}
+	if len(config.AllowMethods) == 0 {
+		config.AllowMethods = DefaultCORSMethods
+	}
+	if len(config.AllowHeaders) == 0 {
+		config.AllowHeaders = DefaultCORSHeaders
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = DefaultCORSMaxAge
+	}
+
+	if len(p.AllowOrigins) == 1 && p.AllowOrigins[0] == "*" {
+		config.AllowAllOrigins = true
+	} else {
+		config.AllowOrigins = p.AllowOrigins
+		for _, origin := range p.AllowOrigins {
+			if strings.Contains(origin, "*") {
+				config.AllowWildcard = true
+			}
+		}
+	}
+
+	return cors.New(config)
+}
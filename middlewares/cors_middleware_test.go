@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  CORSConfig
+		wantErr bool
+	}{
+		{"exact origin", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: true}}, false},
+		{"subdomain pattern", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://*.example.com"}}}, false},
+		{"subdomain pattern with credentials", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://*.example.com"}, AllowCredentials: true}}, true},
+		{"subdomain pattern with port and credentials", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://app.example.com", "https://*.example.com:8443"}, AllowCredentials: true}}, true},
+		{"group subdomain pattern with credentials", CORSConfig{
+			CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://app.example.com"}},
+			Groups:     map[string]CORSPolicy{"/partners": {AllowOrigins: []string{"https://*.partner.com"}, AllowCredentials: true}},
+		}, true},
+		{"subdomain pattern with port", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://*.example.com:8443"}}}, false},
+		{"wildcard host with credentials", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://*"}, AllowCredentials: true}}, true},
+		{"wildcard host", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://*"}}}, true},
+		{"wildcard on top-level domain", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://*.com"}, AllowCredentials: true}}, true},
+		{"trailing wildcard", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://example.com*"}}}, true},
+		{"wildcard inside label", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://app-*.example.com"}}}, true},
+		{"wildcard port", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://app.example.com:*"}}}, true},
+		{"any origin", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"*"}}}, false},
+		{"any origin with credentials", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"*"}, AllowCredentials: true}}, true},
+		{"no origins", CORSConfig{}, true},
+		{"missing scheme", CORSConfig{CORSPolicy: CORSPolicy{AllowOrigins: []string{"example.com"}}}, true},
+		{"invalid group", CORSConfig{
+			CORSPolicy: CORSPolicy{AllowOrigins: []string{"https://app.example.com"}},
+			Groups:     map[string]CORSPolicy{"/public": {AllowOrigins: []string{"*"}, AllowCredentials: true}},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(CORSMiddleware(CORSConfig{
+		CORSPolicy: CORSPolicy{
+			AllowOrigins: []string{"https://*.example.com"},
+		},
+		Groups: map[string]CORSPolicy{
+			"/public": {AllowOrigins: []string{"*"}},
+		},
+	}))
+	router.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/public/docs", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		path       string
+		origin     string
+		wantStatus int
+		wantAllow  string
+	}{
+		{"matching subdomain", "/users", "https://app.example.com", http.StatusOK, "https://app.example.com"},
+		{"foreign origin", "/users", "https://evil.com", http.StatusForbidden, ""},
+		{"public group", "/public/docs", "https://evil.com", http.StatusOK, "*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllow)
+			}
+		})
+	}
+}
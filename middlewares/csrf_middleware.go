@@ -0,0 +1,251 @@
+package middlewares
+
+import (
+	"common/constants"
+	"common/pkg/logger"
+	"common/pkg/utils/response"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CSRFHeader = "X-CSRF-Token"
+	// CSRFTokenKey is the gin context key holding the token to embed in
+	// forms or send back in the X-CSRF-Token header
+	CSRFTokenKey = "csrf_token"
+
+	// CSRFModeDoubleSubmit keeps the token in a cookie that the client
+	// echoes in a header or form field
+	CSRFModeDoubleSubmit = "double_submit"
+	// CSRFModeSynchronizer binds the token to the session and sets no
+	// cookie; the page or client keeps it from CSRFTokenKey or the header
+	CSRFModeSynchronizer = "synchronizer"
+
+	DefaultCSRFCookieName = "csrf_token"
+	DefaultCSRFFormField  = "csrf_token"
+	DefaultCSRFTTL        = 12 * time.Hour
+
+	csrfMinSecretSize = 32
+	csrfNonceSize     = 16
+)
+
+var errInvalidCSRFToken = errors.New("invalid csrf token")
+
+type CSRFConfig struct {
+	// Mode is CSRFModeDoubleSubmit (default) or CSRFModeSynchronizer
+	Mode string
+	// Secret signs tokens and must be at least 32 bytes, shared by all
+	// instances serving the frontend
+	Secret []byte
+	// TTL is how long a token is accepted; DefaultCSRFTTL when zero
+	TTL time.Duration
+	// SessionFunc returns the session a token is bound to, such as the
+	// session cookie value. Required in synchronizer mode; in double submit
+	// mode it stops tokens planted by a sibling subdomain from being accepted
+	SessionFunc func(c *gin.Context) string
+
+	// HeaderName and FormField are where unsafe requests send the token;
+	// CSRFHeader and DefaultCSRFFormField when empty
+	HeaderName string
+	FormField  string
+
+	// Cookie settings for double submit mode. The cookie is readable by
+	// scripts, which must copy it into the header. It is Secure unless
+	// InsecureCookie is set for local development, and SameSite=Lax by default
+	CookieName     string
+	CookiePath     string
+	CookieDomain   string
+	InsecureCookie bool
+	SameSite       http.SameSite
+
+	// ExemptPaths are not checked; a path also covers its sub-paths. Use it
+	// for APIs authenticated by bearer tokens rather than cookies
+	ExemptPaths []string
+	// ExemptBearer skips requests that carry an Authorization: Bearer
+	// header, which browsers never attach to cross-site requests
+	ExemptBearer bool
+}
+
+func (c CSRFConfig) Validate() error {
+	switch c.Mode {
+	case "", CSRFModeDoubleSubmit:
+	case CSRFModeSynchronizer:
+		if c.SessionFunc == nil {
+			return errors.New("csrf: synchronizer mode requires a session func")
+		}
+	default:
+		return fmt.Errorf("csrf: unknown mode %q", c.Mode)
+	}
+	if len(c.Secret) < csrfMinSecretSize {
+		return fmt.Errorf("csrf: secret must be at least %d bytes", csrfMinSecretSize)
+	}
+	if c.TTL < 0 {
+		return errors.New("csrf: ttl must not be negative")
+	}
+	return nil
+}
+
+// CSRFMiddleware protects cookie-authenticated frontends from cross-site
+// request forgery. Every request gets a signed token under CSRFTokenKey and
+// in the X-CSRF-Token response header, and unsafe methods must send it back
+// in the header or form field or are rejected with 403. It panics when the
+// config is invalid, since a missing secret would make tokens forgeable
+func CSRFMiddleware(logger logger.Logger, config CSRFConfig) gin.HandlerFunc {
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+	if config.Mode == "" {
+		config.Mode = CSRFModeDoubleSubmit
+	}
+	if config.TTL == 0 {
+		config.TTL = DefaultCSRFTTL
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = CSRFHeader
+	}
+	if config.FormField == "" {
+		config.FormField = DefaultCSRFFormField
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultCSRFCookieName
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = "/"
+	}
+	if config.SameSite == 0 {
+		config.SameSite = http.SameSiteLaxMode
+	}
+	tokens := csrfTokens{secret: config.Secret, ttl: config.TTL, now: time.Now}
+
+	return func(c *gin.Context) {
+		if skipPath(config.ExemptPaths, c.Request.URL.Path) || config.ExemptBearer && hasBearerToken(c) {
+			c.Next()
+			return
+		}
+
+		var session string
+		if config.SessionFunc != nil {
+			session = config.SessionFunc(c)
+		}
+		submitted := c.GetHeader(config.HeaderName)
+
+		// the token the client holds, reissued when missing or expired
+		var current string
+		if config.Mode == CSRFModeDoubleSubmit {
+			current, _ = c.Cookie(config.CookieName)
+		} else {
+			current = submitted
+		}
+		token := current
+		if tokens.verify(current, session) != nil {
+			var err error
+			if token, err = tokens.issue(session); err != nil {
+				if logger != nil {
+					logger.Error("failed to issue csrf token", "error", err, "request_id", requestID(c), "action", constants.ActionMiddlewareError)
+				}
+				response.HandleServerErrorWithAbort(c, "Failed to issue CSRF token")
+				return
+			}
+			if config.Mode == CSRFModeDoubleSubmit {
+				c.SetSameSite(config.SameSite)
+				c.SetCookie(config.CookieName, token, int(config.TTL/time.Second), config.CookiePath, config.CookieDomain, !config.InsecureCookie, false)
+			}
+		}
+		c.Set(CSRFTokenKey, token)
+		c.Header(config.HeaderName, token)
+
+		if isSafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if submitted == "" {
+			submitted = c.PostForm(config.FormField)
+		}
+		err := tokens.verify(submitted, session)
+		if err == nil && config.Mode == CSRFModeDoubleSubmit && subtle.ConstantTimeCompare([]byte(submitted), []byte(current)) != 1 {
+			err = errInvalidCSRFToken
+		}
+		if err != nil {
+			if logger != nil {
+				logger.Warn("csrf token rejected",
+					"method", c.Request.Method,
+					"path", c.Request.URL.Path,
+					"missing", submitted == "",
+					"request_id", requestID(c),
+					"action", constants.ActionAuthFailed,
+				)
+			}
+			resp := response.Forbidden("Invalid or missing CSRF token")
+			c.AbortWithStatusJSON(resp.Status, resp)
+			return
+		}
+		c.Next()
+	}
+}
+
+// csrfTokens issues and verifies tokens of the form payload.signature, where
+// payload is a random nonce and the issue time and the signature is an HMAC
+// of the payload and the session
+type csrfTokens struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func (t csrfTokens) issue(session string) (string, error) {
+	var payload [csrfNonceSize + 8]byte
+	if _, err := rand.Read(payload[:csrfNonceSize]); err != nil {
+		return "", err
+	}
+	binary.BigEndian.PutUint64(payload[csrfNonceSize:], uint64(t.now().Unix()))
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload[:])
+	return encoded + "." + t.sign(encoded, session), nil
+}
+
+func (t csrfTokens) verify(token, session string) error {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(t.sign(encoded, session))) {
+		return errInvalidCSRFToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(payload) != csrfNonceSize+8 {
+		return errInvalidCSRFToken
+	}
+	issued := time.Unix(int64(binary.BigEndian.Uint64(payload[csrfNonceSize:])), 0)
+	if t.now().Sub(issued) > t.ttl {
+		return errInvalidCSRFToken
+	}
+	return nil
+}
+
+func (t csrfTokens) sign(payload, session string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(payload + "|" + session))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func hasBearerToken(c *gin.Context) bool {
+	token, err := extractBearerToken(c)
+	return err == nil && token != ""
+}
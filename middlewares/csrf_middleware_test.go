@@ -0,0 +1,191 @@
+package middlewares
+
+import (
+	"common/pkg/logger/loggertest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var testCSRFSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestCSRFConfigValidate(t *testing.T) {
+	session := func(c *gin.Context) string { return "" }
+	tests := []struct {
+		name    string
+		config  CSRFConfig
+		wantErr bool
+	}{
+		{"double submit", CSRFConfig{Secret: testCSRFSecret}, false},
+		{"synchronizer", CSRFConfig{Mode: CSRFModeSynchronizer, Secret: testCSRFSecret, SessionFunc: session}, false},
+		{"synchronizer without session", CSRFConfig{Mode: CSRFModeSynchronizer, Secret: testCSRFSecret}, true},
+		{"short secret", CSRFConfig{Secret: []byte("secret")}, true},
+		{"unknown mode", CSRFConfig{Mode: "origin", Secret: testCSRFSecret}, true},
+		{"negative ttl", CSRFConfig{Secret: testCSRFSecret, TTL: -time.Second}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func newCSRFRouter(t *testing.T, config CSRFConfig) (*gin.Engine, *loggertest.Recorder) {
+	t.Helper()
+	log, recorder := loggertest.New()
+	router := gin.New()
+	router.Use(CSRFMiddleware(log, config))
+	router.GET("/form", func(c *gin.Context) { c.String(http.StatusOK, c.GetString(CSRFTokenKey)) })
+	router.POST("/form", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/api/v1/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router, recorder
+}
+
+func TestCSRFMiddlewareDoubleSubmit(t *testing.T) {
+	router, recorder := newCSRFRouter(t, CSRFConfig{
+		Secret:       testCSRFSecret,
+		ExemptPaths:  []string{"/api"},
+		ExemptBearer: true,
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/form", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != DefaultCSRFCookieName {
+		t.Fatalf("cookies = %v, want one %s cookie", cookies, DefaultCSRFCookieName)
+	}
+	cookie := cookies[0]
+	if !cookie.Secure || cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("cookie = %+v, want Secure, script-readable and SameSite=Lax", cookie)
+	}
+	token := cookie.Value
+	if w.Body.String() != token || w.Header().Get(CSRFHeader) != token {
+		t.Errorf("token in context %q and header %q, want the cookie value", w.Body.String(), w.Header().Get(CSRFHeader))
+	}
+	other, _ := (csrfTokens{secret: testCSRFSecret, ttl: DefaultCSRFTTL, now: time.Now}).issue("")
+
+	tests := []struct {
+		name       string
+		path       string
+		cookie     string
+		header     string
+		form       string
+		bearer     bool
+		wantStatus int
+	}{
+		{"header matches cookie", "/form", token, token, "", false, http.StatusOK},
+		{"form field matches cookie", "/form", token, "", token, false, http.StatusOK},
+		{"missing token", "/form", token, "", "", false, http.StatusForbidden},
+		{"missing cookie", "/form", "", token, "", false, http.StatusForbidden},
+		{"valid token from another cookie", "/form", token, other, "", false, http.StatusForbidden},
+		{"forged token", "/form", "forged.value", "forged.value", "", false, http.StatusForbidden},
+		{"exempt path", "/api/v1/users", "", "", "", false, http.StatusOK},
+		{"bearer token", "/form", "", "", "", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			if tt.form != "" {
+				req = httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(url.Values{DefaultCSRFFormField: {tt.form}}.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				req = httptest.NewRequest(http.MethodPost, tt.path, nil)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: DefaultCSRFCookieName, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+			if tt.bearer {
+				req.Header.Set("Authorization", "Bearer abc")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	if len(recorder.FilterMessage("csrf token rejected").All()) != 4 {
+		t.Errorf("rejections logged = %d, want 4", len(recorder.FilterMessage("csrf token rejected").All()))
+	}
+}
+
+func TestCSRFMiddlewareSynchronizer(t *testing.T) {
+	router, _ := newCSRFRouter(t, CSRFConfig{
+		Mode:        CSRFModeSynchronizer,
+		Secret:      testCSRFSecret,
+		SessionFunc: func(c *gin.Context) string { return c.GetHeader("X-Session") },
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/form", nil)
+	req.Header.Set("X-Session", "session-a")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	token := w.Body.String()
+	if token == "" || len(w.Result().Cookies()) != 0 {
+		t.Fatalf("token = %q, cookies = %v; want a token and no cookie", token, w.Result().Cookies())
+	}
+
+	tests := []struct {
+		name       string
+		session    string
+		token      string
+		wantStatus int
+	}{
+		{"same session", "session-a", token, http.StatusOK},
+		{"other session", "session-b", token, http.StatusForbidden},
+		{"missing token", "session-a", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/form", nil)
+			req.Header.Set("X-Session", tt.session)
+			if tt.token != "" {
+				req.Header.Set(CSRFHeader, tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCSRFTokenExpiry(t *testing.T) {
+	now := time.Now()
+	tokens := csrfTokens{secret: testCSRFSecret, ttl: time.Hour, now: func() time.Time { return now }}
+	token, err := tokens.issue("session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.verify(token, "session"); err != nil {
+		t.Errorf("fresh token rejected: %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if err := tokens.verify(token, "session"); err == nil {
+		t.Error("expired token accepted")
+	}
+}
+
+func TestCSRFMiddlewarePanicsOnInvalidConfig(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("CSRFMiddleware accepted a config without a secret")
+		}
+	}()
+	CSRFMiddleware(nil, CSRFConfig{})
+}
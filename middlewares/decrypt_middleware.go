@@ -1,42 +1,131 @@
 package middlewares
 
 import (
+	"bytes"
+	"common/constants"
 	"common/dto"
 	"common/pkg/logger"
-	"common/pkg/utils/encryption"
+	"common/pkg/payloadcrypto"
+	"common/pkg/utils"
 	"common/pkg/utils/response"
+	"errors"
+	"io"
 	"net/http"
-	"strings"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
-func DecryptMiddleware(logger logger.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userID := c.MustGet("user_id").(string)
+const (
+	EncryptionKeyIDHeader     = "X-Encryption-Key-Id"
+	EncryptionNonceHeader     = "X-Encryption-Nonce"
+	EncryptionTimestampHeader = "X-Encryption-Timestamp"
+
+	// EncryptionKeyKey is the gin context key holding the *payloadcrypto.Key
+	// of the current request
+	EncryptionKeyKey = "encryption_key"
+)
 
-		tokenDataParts := strings.Split(userID, "|")
-		if len(tokenDataParts) < 2 {
+// DecryptMiddleware opens a dto.EncryptedRequest body sealed with the
+// caller's key. The key ID, nonce and timestamp come from the
+// X-Encryption-* headers and crc_value holds the envelope HMAC. The key must
+// belong to the authenticated user or session, so it runs after
+// AuthMiddleware. The plaintext is stored under "decrypted" and replaces the
+// request body, so handlers can bind it as usual
+func DecryptMiddleware(logger logger.Logger, codec *payloadcrypto.Codec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := encryptionKey(c, logger, codec)
+		if !ok {
 			return
 		}
 
-		signingKey := tokenDataParts[1]
-
 		req := new(dto.EncryptedRequest)
-
 		if err := c.ShouldBindJSON(req); err != nil {
 			response.HandleErrorWithAbort(c, http.StatusBadRequest, "Invalid request payload", err)
 			return
 		}
-
-		decrypted, err := encryption.Decrypt(req.Data, []byte(signingKey))
+		timestamp, err := strconv.ParseInt(c.GetHeader(EncryptionTimestampHeader), 10, 64)
 		if err != nil {
-			response.HandleErrorWithAbort(c, http.StatusBadRequest, "Invalid request payload data", err)
+			response.HandleErrorWithAbort(c, http.StatusBadRequest, "Invalid encryption timestamp")
 			return
 		}
 
-		c.Set("decrypted", decrypted)
+		plaintext, err := codec.Open(c.Request.Context(), key, &payloadcrypto.Envelope{
+			KeyID:     key.ID,
+			Nonce:     c.GetHeader(EncryptionNonceHeader),
+			Timestamp: timestamp,
+			Data:      req.Data,
+			MAC:       req.CrcValue,
+		})
+		if err != nil {
+			logger.Warn("encrypted payload rejected",
+				"error", err,
+				"key_id", key.ID,
+				"ip", c.ClientIP(),
+				"request_id", requestID(c),
+				"action", constants.ActionRequestError,
+			)
+			if errors.Is(err, payloadcrypto.ErrReplay) || errors.Is(err, payloadcrypto.ErrStale) ||
+				errors.Is(err, payloadcrypto.ErrIntegrity) || errors.Is(err, payloadcrypto.ErrMalformed) {
+				response.HandleErrorWithAbort(c, http.StatusBadRequest, "Invalid request payload data")
+			} else {
+				resp := response.InternalServerError("Payload decryption unavailable")
+				c.AbortWithStatusJSON(resp.Status, resp)
+			}
+			return
+		}
 
+		c.Set("decrypted", string(plaintext))
+		c.Request.Body = io.NopCloser(bytes.NewReader(plaintext))
+		c.Request.ContentLength = int64(len(plaintext))
 		c.Next()
 	}
 }
+
+// encryptionKey resolves the key named by X-Encryption-Key-Id for the
+// authenticated caller once per request and stores it under
+// EncryptionKeyKey. It aborts the request and returns false on failure
+func encryptionKey(c *gin.Context, logger logger.Logger, codec *payloadcrypto.Codec) (*payloadcrypto.Key, bool) {
+	if value, ok := c.Get(EncryptionKeyKey); ok {
+		if key, ok := value.(*payloadcrypto.Key); ok {
+			return key, true
+		}
+	}
+
+	userID, err := utils.GetUserIdFromContext(c)
+	if err != nil {
+		response.HandleUnAuthorizedErrorWithAbort(c, "Authentication required")
+		return nil, false
+	}
+	owners := []string{userID}
+	if principal, err := GetPrincipal(c); err == nil {
+		owners = append(owners, principal.SessionID)
+	}
+
+	keyID := c.GetHeader(EncryptionKeyIDHeader)
+	if keyID == "" {
+		response.HandleErrorWithAbort(c, http.StatusBadRequest, "Missing encryption key header")
+		return nil, false
+	}
+
+	key, err := codec.Key(c.Request.Context(), keyID, owners...)
+	if err != nil {
+		logger.Warn("encryption key rejected",
+			"error", err,
+			"key_id", keyID,
+			"user_id", userID,
+			"request_id", requestID(c),
+			"action", constants.ActionAuthFailed,
+		)
+		if errors.Is(err, payloadcrypto.ErrKeyNotFound) || errors.Is(err, payloadcrypto.ErrInvalidKey) {
+			response.HandleUnAuthorizedErrorWithAbort(c, "Invalid encryption key")
+		} else {
+			resp := response.InternalServerError("Payload decryption unavailable")
+			c.AbortWithStatusJSON(resp.Status, resp)
+		}
+		return nil, false
+	}
+
+	c.Set(EncryptionKeyKey, key)
+	return key, true
+}
@@ -0,0 +1,111 @@
+package middlewares
+
+import (
+	"common/dto"
+	"common/pkg/logger/loggertest"
+	"common/pkg/payloadcrypto"
+	"common/pkg/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPayloadEncryption(t *testing.T) {
+	log, recorder := loggertest.New()
+	key, err := payloadcrypto.NewKey("user-1", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _ := payloadcrypto.NewKey("user-2", time.Hour)
+	codec := payloadcrypto.NewCodec(payloadcrypto.NewMemoryKeyProvider(key, otherKey), payloadcrypto.NewMemoryNonceStore(), time.Minute)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", "user-1") })
+	router.POST("/transfers", DecryptMiddleware(log, codec), EncryptResponseMiddleware(log, codec), func(c *gin.Context) {
+		decrypted, err := utils.GetDecryptedDataFromContext(c)
+		if err != nil {
+			t.Errorf("GetDecryptedDataFromContext: %v", err)
+		}
+		var body struct {
+			Amount int `json:"amount"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			t.Errorf("bind decrypted body: %v", err)
+		}
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"echo": decrypted, "amount": body.Amount}, "message": "Success", "status": 200})
+	})
+
+	sealed := func(k payloadcrypto.Key, plaintext string) *payloadcrypto.Envelope {
+		env, err := codec.Seal(&k, []byte(plaintext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return env
+	}
+	send := func(env *payloadcrypto.Envelope) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(dto.EncryptedRequest{Data: env.Data, CrcValue: env.MAC})
+		req := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(string(body)))
+		req.Header.Set(EncryptionKeyIDHeader, env.KeyID)
+		req.Header.Set(EncryptionNonceHeader, env.Nonce)
+		req.Header.Set(EncryptionTimestampHeader, strconv.FormatInt(env.Timestamp, 10))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	env := sealed(key, `{"amount":10}`)
+	w := send(env)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body)
+	}
+
+	// the response data is sealed with the request's key and opens to the handler's data
+	var resp struct {
+		Data    dto.EncryptedRequest `json:"data"`
+		Message string               `json:"message"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Message != "Success" {
+		t.Fatalf("body = %s", w.Body)
+	}
+	if strings.Contains(w.Body.String(), "amount") {
+		t.Errorf("response data sent in plaintext: %s", w.Body)
+	}
+	timestamp, _ := strconv.ParseInt(w.Header().Get(EncryptionTimestampHeader), 10, 64)
+	plaintext, err := codec.Open(context.Background(), &key, &payloadcrypto.Envelope{
+		KeyID:     w.Header().Get(EncryptionKeyIDHeader),
+		Nonce:     w.Header().Get(EncryptionNonceHeader),
+		Timestamp: timestamp,
+		Data:      resp.Data.Data,
+		MAC:       resp.Data.CrcValue,
+	})
+	if err != nil || string(plaintext) != `{"amount":10,"echo":"{\"amount\":10}"}` {
+		t.Errorf("response plaintext = %s, %v", plaintext, err)
+	}
+
+	tests := []struct {
+		name string
+		env  *payloadcrypto.Envelope
+		want int
+	}{
+		{"replayed", env, http.StatusBadRequest},
+		{"tampered", func() *payloadcrypto.Envelope { e := sealed(key, "{}"); e.MAC = strings.Repeat("0", 64); return e }(), http.StatusBadRequest},
+		{"key of another user", sealed(otherKey, "{}"), http.StatusUnauthorized},
+		{"unknown key", func() *payloadcrypto.Envelope { e := sealed(key, "{}"); e.KeyID = "missing"; return e }(), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		recorder.Reset()
+		if w := send(tt.env); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+		if recorder.Len() == 0 {
+			t.Errorf("%s: rejection was not logged", tt.name)
+		}
+	}
+}
@@ -1,50 +1,172 @@
 package middlewares
 
 import (
+	"common/constants"
 	"common/dto"
+	"common/pkg/device"
+	"common/pkg/logger"
+	"common/pkg/utils"
 	"common/pkg/utils/response"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	DeviceOSHeader        = "X-OS"
+	DeviceOSVersionHeader = "X-OS-Version"
+	DeviceIPHeader        = "X-Device-Ip"
+	DeviceLatLongHeader   = "X-Lat-Long"
+	DeviceIDHeader        = "X-Device-Id"
+)
+
+// DefaultDeviceHeaders are the headers DeviceInfoMiddleware requires
+var DefaultDeviceHeaders = []string{DeviceOSHeader, DeviceOSVersionHeader, DeviceIPHeader, DeviceLatLongHeader}
+
+var deviceHeaderNames = map[string]string{
+	DeviceOSHeader:        "OS",
+	DeviceOSVersionHeader: "OS version",
+	DeviceIPHeader:        "Device IP",
+	DeviceLatLongHeader:   "Latitude/Longitude",
+	DeviceIDHeader:        "Device ID",
+}
+
+type DeviceInfoConfig struct {
+	// Required lists the headers a request must send. Every other device
+	// header is still read and validated when present
+	Required []string
+
+	// Routes overrides Required for specific routes, keyed by gin's route
+	// template ("/users/:id") or by method and template ("POST /login")
+	Routes map[string][]string
+
+	// Store remembers each user's devices; new device detection is off when
+	// nil. With a Store every request must send X-Device-Id, since without it
+	// all devices of the same type, OS and browser share a fingerprint
+	Store device.Store
+
+	// OnNewDevice is called on the first request from a device. Its errors
+	// are logged and do not fail the request
+	OnNewDevice device.NewDeviceHook
+}
+
+// DeviceInfoMiddleware requires the X-OS, X-OS-Version, X-Device-Ip and
+// X-Lat-Long headers on every request
 func DeviceInfoMiddleware() gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		userID := ctx.MustGet("user_id").(string)
+	return NewDeviceInfoMiddleware(nil, DeviceInfoConfig{Required: DefaultDeviceHeaders})
+}
 
-		os := ctx.GetHeader("X-OS")
-		if os == "" {
-			response.HandleErrorWithAbort(ctx, http.StatusBadRequest, "Missing OS header", nil)
+// NewDeviceInfoMiddleware stores a *dto.DeviceInfo under "device_info" for
+// the authenticated user. OS and browser come from the X-OS headers, falling
+// back to the User-Agent; the device IP from X-Device-Ip, falling back to the
+// client IP. Malformed IP or location headers are rejected with 400
+func NewDeviceInfoMiddleware(logger logger.Logger, config DeviceInfoConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			response.HandleUnAuthorizedErrorWithAbort(c, "Authentication required")
 			return
 		}
 
-		osVersion := ctx.GetHeader("X-OS-Version")
-		if osVersion == "" {
-			response.HandleErrorWithAbort(ctx, http.StatusBadRequest, "Missing OS version header", nil)
-			return
+		required := config.required(c)
+		if config.Store != nil {
+			required = append(required[:len(required):len(required)], DeviceIDHeader)
+		}
+		for _, header := range required {
+			if c.GetHeader(header) == "" {
+				response.HandleErrorWithAbort(c, http.StatusBadRequest, "Missing "+deviceHeaderName(header)+" header", nil)
+				return
+			}
 		}
 
-		deviceIP := ctx.GetHeader("X-Device-Ip")
-		if deviceIP == "" {
-			response.HandleErrorWithAbort(ctx, http.StatusBadRequest, "Missing Device IP header", nil)
+		info, message := deviceInfo(c, userID)
+		if message != "" {
+			response.HandleErrorWithAbort(c, http.StatusBadRequest, message, nil)
 			return
 		}
 
-		latLong := ctx.GetHeader("X-Lat-Long")
-		if latLong == "" {
-			response.HandleErrorWithAbort(ctx, http.StatusBadRequest, "Missing Latitude/Longitude header", nil)
-			return
+		if config.Store != nil {
+			info.NewDevice, err = config.Store.Remember(c.Request.Context(), userID, info.Fingerprint)
+			if err != nil && logger != nil {
+				logger.Warn("device lookup failed", "error", err, "user_id", userID, "request_id", requestID(c))
+			}
+		}
+		if info.NewDevice && config.OnNewDevice != nil {
+			if err := config.OnNewDevice(c.Request.Context(), info); err != nil && logger != nil {
+				logger.Warn("new device hook failed",
+					"error", err,
+					"user_id", userID,
+					"request_id", requestID(c),
+					"action", constants.ActionMiddlewareError,
+				)
+			}
 		}
 
-		req := &dto.DeviceInfo{
-			UserID:    userID,
-			OS:        os,
-			OSVersion: osVersion,
-			DeviceIP:  deviceIP,
-			LatLong:   latLong,
+		c.Set("device_info", info)
+		c.Next()
+	}
+}
+
+func (config DeviceInfoConfig) required(c *gin.Context) []string {
+	route := c.FullPath()
+	if headers, ok := config.Routes[c.Request.Method+" "+route]; ok {
+		return headers
+	}
+	if headers, ok := config.Routes[route]; ok {
+		return headers
+	}
+	return config.Required
+}
+
+// deviceInfo collects the device headers, returning an error message for
+// malformed values
+func deviceInfo(c *gin.Context, userID string) (*dto.DeviceInfo, string) {
+	userAgent := c.GetHeader("User-Agent")
+	ua := device.ParseUserAgent(userAgent)
+	info := &dto.DeviceInfo{
+		UserID:         userID,
+		OS:             c.GetHeader(DeviceOSHeader),
+		OSVersion:      c.GetHeader(DeviceOSVersionHeader),
+		LatLong:        c.GetHeader(DeviceLatLongHeader),
+		DeviceID:       c.GetHeader(DeviceIDHeader),
+		DeviceType:     ua.DeviceType,
+		Browser:        ua.Browser,
+		BrowserVersion: ua.BrowserVersion,
+		UserAgent:      userAgent,
+	}
+
+	if info.OS == "" {
+		info.OS = ua.OS
+	}
+	if info.OSVersion == "" && strings.EqualFold(info.OS, ua.OS) {
+		info.OSVersion = ua.OSVersion
+	}
+
+	info.DeviceIP = c.ClientIP()
+	if header := c.GetHeader(DeviceIPHeader); header != "" {
+		ip, err := device.ParseIP(header)
+		if err != nil {
+			return nil, "Invalid Device IP header"
+		}
+		info.DeviceIP = ip
+	}
+
+	if info.LatLong != "" {
+		location, err := device.ParseLatLong(info.LatLong)
+		if err != nil {
+			return nil, "Invalid Latitude/Longitude header"
 		}
+		info.Location = location
+	}
+
+	info.Fingerprint = device.Fingerprint(info)
+	return info, ""
+}
 
-		ctx.Set("device_info", req)
-		ctx.Next()
+func deviceHeaderName(header string) string {
+	if name, ok := deviceHeaderNames[header]; ok {
+		return name
 	}
+	return header
 }
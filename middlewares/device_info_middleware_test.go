@@ -0,0 +1,136 @@
+package middlewares
+
+import (
+	"common/dto"
+	"common/pkg/device"
+	"common/pkg/logger/loggertest"
+	"common/pkg/utils"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const iPhoneUserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+
+func TestDeviceInfoMiddleware(t *testing.T) {
+	log, recorder := loggertest.New()
+	var notified []*dto.DeviceInfo
+	config := DeviceInfoConfig{
+		Required: DefaultDeviceHeaders,
+		Routes:   map[string][]string{"POST /login": {DeviceIDHeader}},
+		Store:    device.NewMemoryStore(),
+		OnNewDevice: func(ctx context.Context, info *dto.DeviceInfo) error {
+			notified = append(notified, info)
+			return errors.New("queue unavailable")
+		},
+	}
+
+	var got *dto.DeviceInfo
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", "user-1") })
+	router.Use(NewDeviceInfoMiddleware(log, config))
+	handler := func(c *gin.Context) {
+		got, _ = utils.GetDeviceInfoFromContext(c)
+		c.Status(http.StatusNoContent)
+	}
+	router.GET("/profile", handler)
+	router.POST("/login", handler)
+
+	send := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		got = nil
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	full := map[string]string{
+		DeviceOSHeader:        "iOS",
+		DeviceOSVersionHeader: "17.5",
+		DeviceIPHeader:        "192.168.1.10",
+		DeviceLatLongHeader:   "27.7172,85.3240",
+		DeviceIDHeader:        "device-1",
+		"User-Agent":          iPhoneUserAgent,
+	}
+	if w := send(http.MethodGet, "/profile", full); w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body)
+	}
+	if got.Location == nil || got.Location.Latitude != 27.7172 || got.DeviceType != device.TypeMobile || got.Browser != "Safari" {
+		t.Errorf("device info = %+v", got)
+	}
+	if !got.NewDevice || len(notified) != 1 {
+		t.Errorf("first request: NewDevice = %v, hook calls = %d", got.NewDevice, len(notified))
+	}
+	recorder.AssertLogged(t, loggertest.WarnLevel, "new device hook failed", map[string]interface{}{"user_id": "user-1"})
+
+	// the same device moving and updating is not new
+	full[DeviceIPHeader], full[DeviceOSVersionHeader] = "10.0.0.1", "17.6"
+	send(http.MethodGet, "/profile", full)
+	if got == nil || got.NewDevice || len(notified) != 1 {
+		t.Errorf("repeat request: info = %+v, hook calls = %d", got, len(notified))
+	}
+
+	// the login route only requires a device ID; the rest comes from the User-Agent and client IP
+	w := send(http.MethodPost, "/login", map[string]string{DeviceIDHeader: "device-2", "User-Agent": iPhoneUserAgent})
+	if w.Code != http.StatusNoContent || got.OS != "iOS" || got.OSVersion != "17.5" || got.DeviceIP != "203.0.113.7" || got.Location != nil {
+		t.Errorf("login: status = %d, info = %+v", w.Code, got)
+	}
+
+	tests := []struct {
+		name     string
+		override map[string]string
+		want     int
+	}{
+		{"missing OS", map[string]string{DeviceOSHeader: ""}, http.StatusBadRequest},
+		// new device detection cannot tell identical phones apart without an ID
+		{"missing device ID", map[string]string{DeviceIDHeader: ""}, http.StatusBadRequest},
+		{"invalid IP", map[string]string{DeviceIPHeader: "300.1.1.1"}, http.StatusBadRequest},
+		{"latitude out of range", map[string]string{DeviceLatLongHeader: "95,10"}, http.StatusBadRequest},
+		{"malformed location", map[string]string{DeviceLatLongHeader: "somewhere"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		headers := map[string]string{}
+		for name, value := range full {
+			headers[name] = value
+		}
+		for name, value := range tt.override {
+			headers[name] = value
+		}
+		if w := send(http.MethodGet, "/profile", headers); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestDeviceInfoMiddlewareWithoutStore(t *testing.T) {
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", "user-1") })
+	router.GET("/", NewDeviceInfoMiddleware(nil, DeviceInfoConfig{}), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", iPhoneUserAgent)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status without a device ID = %d, want 204", w.Code)
+	}
+}
+
+func TestDeviceInfoMiddlewareRequiresUser(t *testing.T) {
+	router := gin.New()
+	router.GET("/", DeviceInfoMiddleware(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", w.Code)
+	}
+}
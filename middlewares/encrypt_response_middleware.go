@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"bytes"
+	"common/constants"
+	"common/dto"
+	"common/pkg/logger"
+	"common/pkg/payloadcrypto"
+	"common/pkg/utils/response"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EncryptResponseMiddleware seals the Data of response.APIResponse bodies
+// with the caller's key, the mirror image of DecryptMiddleware. Data becomes
+// a dto.EncryptedRequest and the X-Encryption-* response headers carry the
+// key ID, a fresh nonce and the timestamp. Message, status and errors stay
+// readable. The key is resolved before the handler runs, so requests without
+// a valid key never reach it
+func EncryptResponseMiddleware(logger logger.Logger, codec *payloadcrypto.Codec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := encryptionKey(c, logger, codec)
+		if !ok {
+			return
+		}
+
+		writer := &encryptWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		body, err := encryptResponseBody(codec, key, writer.body.Bytes(), writer.Header())
+		if err != nil {
+			logger.Error("failed to encrypt response",
+				"error", err,
+				"key_id", key.ID,
+				"request_id", requestID(c),
+				"action", constants.ActionRequestError,
+			)
+			// never fall back to sending the plaintext
+			writer.Header().Del(EncryptionKeyIDHeader)
+			writer.Header().Del(EncryptionNonceHeader)
+			writer.Header().Del(EncryptionTimestampHeader)
+			resp := response.InternalServerError("Failed to encrypt response")
+			body, _ = json.Marshal(resp)
+			writer.ResponseWriter.WriteHeader(resp.Status)
+		}
+		if len(body) > 0 {
+			writer.Header().Del("Content-Length")
+			writer.ResponseWriter.Write(body)
+		}
+	}
+}
+
+// encryptResponseBody seals the data field of an APIResponse body. Bodies
+// that are not APIResponse JSON, or carry no data, are returned unchanged
+func encryptResponseBody(codec *payloadcrypto.Codec, key *payloadcrypto.Key, body []byte, header http.Header) ([]byte, error) {
+	var envelope map[string]json.RawMessage
+	if len(body) == 0 || json.Unmarshal(body, &envelope) != nil {
+		return body, nil
+	}
+	data, ok := envelope["data"]
+	if !ok || bytes.Equal(data, []byte("null")) {
+		return body, nil
+	}
+
+	sealed, err := codec.Seal(key, data)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := json.Marshal(dto.EncryptedRequest{Data: sealed.Data, CrcValue: sealed.MAC})
+	if err != nil {
+		return nil, err
+	}
+	envelope["data"] = encrypted
+
+	header.Set(EncryptionKeyIDHeader, sealed.KeyID)
+	header.Set(EncryptionNonceHeader, sealed.Nonce)
+	header.Set(EncryptionTimestampHeader, strconv.FormatInt(sealed.Timestamp, 10))
+	return json.Marshal(envelope)
+}
+
+// encryptWriter holds the body back until the handler has finished
+type encryptWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *encryptWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *encryptWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// Written reports buffered bodies as written, so later middleware does not
+// write a second response on top
+func (w *encryptWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Flush is a no-op: streaming the body would send it unencrypted
+func (w *encryptWriter) Flush() {}
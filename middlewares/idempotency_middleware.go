@@ -0,0 +1,212 @@
+package middlewares
+
+import (
+	"bytes"
+	"common/constants"
+	"common/pkg/idempotency"
+	"common/pkg/logger"
+	"common/pkg/utils/response"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set to "true" on replayed responses
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	DefaultIdempotencyTTL     = 24 * time.Hour
+	DefaultIdempotencyLockTTL = time.Minute
+	maxIdempotencyKeyLength   = 255
+)
+
+type IdempotencyConfig struct {
+	// Methods are the methods the middleware applies to; POST when empty
+	Methods []string
+	// Required rejects requests without an Idempotency-Key with 400
+	Required bool
+	// TTL is how long responses are kept for replay; DefaultIdempotencyTTL when zero
+	TTL time.Duration
+	// LockTTL bounds how long an unfinished request blocks retries, in case
+	// the instance handling it dies; DefaultIdempotencyLockTTL when zero
+	LockTTL time.Duration
+	// KeyFunc scopes keys to a caller; RateLimitByUser when nil, so two
+	// users can send the same key
+	KeyFunc RateLimitKeyFunc
+}
+
+// IdempotencyMiddleware replays the stored response when a request is
+// retried with the same Idempotency-Key. The first request runs the handler
+// and its status, headers and body are stored, unless it fails with a 5xx
+// or panics so the client can retry. A duplicate sent while the first is
+// still running gets 409, and reusing a key for a different method, path or
+// body gets 422. Store errors fail the request, since letting it through
+// could repeat its side effects; for the same reason a response that could
+// not be stored keeps the key locked until LockTTL, so retries get 409
+func IdempotencyMiddleware(logger logger.Logger, store idempotency.Store, config IdempotencyConfig) gin.HandlerFunc {
+	methods := config.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodPost}
+	}
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	lockTTL := config.LockTTL
+	if lockTTL <= 0 {
+		lockTTL = DefaultIdempotencyLockTTL
+	}
+	keyFunc := config.KeyFunc
+	if keyFunc == nil {
+		keyFunc = RateLimitByUser
+	}
+
+	return func(c *gin.Context) {
+		if !containsString(methods, c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			if config.Required {
+				response.HandleErrorWithAbort(c, http.StatusBadRequest, "Missing Idempotency-Key header")
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			response.HandleErrorWithAbort(c, http.StatusBadRequest, "Idempotency-Key is too long")
+			return
+		}
+
+		requestHash, err := hashRequest(c)
+		if err != nil {
+			response.HandleErrorWithAbort(c, http.StatusBadRequest, "Invalid request payload", err)
+			return
+		}
+
+		key := keyFunc(c) + "|" + idempotencyKey
+		record, err := store.Begin(c.Request.Context(), key, requestHash, lockTTL)
+		switch {
+		case errors.Is(err, idempotency.ErrInProgress):
+			response.HandleErrorWithAbort(c, http.StatusConflict, "A request with this Idempotency-Key is in progress")
+			return
+		case errors.Is(err, idempotency.ErrMismatch):
+			response.HandleErrorWithAbort(c, http.StatusUnprocessableEntity, "Idempotency-Key was used for a different request")
+			return
+		case err != nil:
+			logger.Error("idempotency check failed",
+				"error", err,
+				"request_id", requestID(c),
+				"action", constants.ActionMiddlewareError,
+			)
+			resp := response.InternalServerError("Idempotency check unavailable")
+			c.AbortWithStatusJSON(resp.Status, resp)
+			return
+		case record != nil:
+			replayResponse(c, record)
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		// the outcome must be recorded even if the client has gone away
+		ctx := context.WithoutCancel(c.Request.Context())
+		release := true
+		defer func() {
+			// runs on panics too, so a crashed handler does not hold the key
+			if release {
+				if err := store.Release(ctx, key); err != nil {
+					logger.Warn("failed to release idempotency key", "error", err, "request_id", requestID(c))
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		// the handler succeeded, so releasing the key would let a retry
+		// repeat its side effects even if the response cannot be stored
+		release = false
+		err = store.Complete(ctx, key, idempotency.Record{
+			RequestHash: requestHash,
+			Status:      status,
+			Header:      writer.Header().Clone(),
+			Body:        writer.body.Bytes(),
+			CreatedAt:   time.Now().UTC(),
+		}, ttl)
+		if err != nil {
+			logger.Error("failed to store idempotent response",
+				"error", err,
+				"request_id", requestID(c),
+				"action", constants.ActionMiddlewareError,
+			)
+		}
+	}
+}
+
+// hashRequest identifies the request by method, route and body, restoring
+// the body for the handler
+func hashRequest(c *gin.Context) (string, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(c.Request.Body); err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func replayResponse(c *gin.Context, record *idempotency.Record) {
+	header := c.Writer.Header()
+	for name, values := range record.Header {
+		header[name] = values
+	}
+	header.Set(IdempotentReplayedHeader, "true")
+	c.Status(record.Status)
+	c.Writer.Write(record.Body)
+	c.Abort()
+}
+
+// recordingWriter passes the response through while keeping a copy of the body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package middlewares
+
+import (
+	"common/pkg/idempotency"
+	"common/pkg/logger/loggertest"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	log, _ := loggertest.New()
+	var calls atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", c.GetHeader("X-User")) })
+	router.Use(IdempotencyMiddleware(log, idempotency.NewMemoryStore(), IdempotencyConfig{}))
+	router.POST("/payments", func(c *gin.Context) {
+		n := calls.Add(1)
+		c.Header("Location", "/payments/1")
+		c.String(http.StatusCreated, "payment %d", n)
+	})
+	router.POST("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusNoContent)
+	})
+	router.POST("/fail", func(c *gin.Context) {
+		calls.Add(1)
+		c.Status(http.StatusServiceUnavailable)
+	})
+	router.POST("/panic", func(c *gin.Context) { panic("boom") })
+
+	send := func(path, user, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("X-User", user)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("/payments", "u1", "key-1", `{"amount":10}`)
+	retry := send("/payments", "u1", "key-1", `{"amount":10}`)
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated || retry.Body.String() != "payment 1" {
+		t.Fatalf("retry = %d %q, want replay of %d %q", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if retry.Header().Get("Location") != "/payments/1" || retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("replayed headers = %v", retry.Header())
+	}
+	if first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Error("first response marked as replayed")
+	}
+
+	tests := []struct {
+		name              string
+		path, user, key   string
+		body              string
+		wantStatus        int
+		wantCallsIncrease bool
+	}{
+		{"different body", "/payments", "u1", "key-1", `{"amount":20}`, http.StatusUnprocessableEntity, false},
+		{"other user, same key", "/payments", "u2", "key-1", `{"amount":10}`, http.StatusCreated, true},
+		{"no key", "/payments", "u1", "", `{"amount":10}`, http.StatusCreated, true},
+		{"key too long", "/payments", "u1", strings.Repeat("k", 256), "", http.StatusBadRequest, false},
+		{"server error is not stored", "/fail", "u1", "key-2", "", http.StatusServiceUnavailable, true},
+		{"server error retry runs again", "/fail", "u1", "key-2", "", http.StatusServiceUnavailable, true},
+	}
+	for _, tt := range tests {
+		before := calls.Load()
+		w := send(tt.path, tt.user, tt.key, tt.body)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+		if increased := calls.Load() > before; increased != tt.wantCallsIncrease {
+			t.Errorf("%s: handler ran = %v, want %v", tt.name, increased, tt.wantCallsIncrease)
+		}
+	}
+
+	// a duplicate arriving while the first is running is rejected
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send("/slow", "u1", "key-3", "") }()
+	<-started
+	duplicate := send("/slow", "u1", "key-3", "")
+	close(release)
+	if duplicate.Code != http.StatusConflict {
+		t.Errorf("in-flight duplicate: status = %d, want 409", duplicate.Code)
+	}
+	if w := <-done; w.Code != http.StatusNoContent {
+		t.Errorf("first slow request: status = %d", w.Code)
+	}
+
+	// a panicking handler releases the key
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected the handler to panic again, not a replay or 409")
+				}
+			}()
+			send("/panic", "u1", "key-4", "")
+		}()
+	}
+}
+
+// failingCompleteStore cannot store responses and records the context
+// state Complete was called with
+type failingCompleteStore struct {
+	idempotency.Store
+	completeCtxErr error
+}
+
+func (s *failingCompleteStore) Complete(ctx context.Context, key string, record idempotency.Record, ttl time.Duration) error {
+	s.completeCtxErr = ctx.Err()
+	return errors.New("redis unavailable")
+}
+
+func TestIdempotencyMiddlewareCompleteFails(t *testing.T) {
+	log, recorder := loggertest.New()
+	store := &failingCompleteStore{Store: idempotency.NewMemoryStore()}
+	var calls atomic.Int32
+
+	router := gin.New()
+	router.Use(IdempotencyMiddleware(log, store, IdempotencyConfig{KeyFunc: RateLimitByIP}))
+	router.POST("/payments", func(c *gin.Context) {
+		calls.Add(1)
+		c.Status(http.StatusCreated)
+	})
+
+	send := func(cancelled bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/payments", nil)
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		if cancelled {
+			// the client hangs up before the response is stored
+			ctx, cancel := context.WithCancel(req.Context())
+			cancel()
+			req = req.WithContext(ctx)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(true); w.Code != http.StatusCreated {
+		t.Fatalf("first request: status = %d, want 201", w.Code)
+	}
+	if store.completeCtxErr != nil {
+		t.Errorf("Complete called with a cancelled context: %v", store.completeCtxErr)
+	}
+	recorder.AssertLogged(t, loggertest.ErrorLevel, "failed to store idempotent response", nil)
+
+	// the handler ran, so the key stays locked rather than letting a retry repeat it
+	if w := send(false); w.Code != http.StatusConflict {
+		t.Errorf("retry: status = %d, want 409", w.Code)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
+
+func TestIdempotencyMiddlewareRequired(t *testing.T) {
+	log, _ := loggertest.New()
+	router := gin.New()
+	router.Use(IdempotencyMiddleware(log, idempotency.NewMemoryStore(), IdempotencyConfig{Required: true}))
+	router.POST("/payments", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	router.GET("/payments", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, tt := range []struct {
+		method string
+		want   int
+	}{
+		{http.MethodPost, http.StatusBadRequest},
+		{http.MethodGet, http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, "/payments", nil))
+		if w.Code != tt.want {
+			t.Errorf("%s without key: status = %d, want %d", tt.method, w.Code, tt.want)
+		}
+	}
+}
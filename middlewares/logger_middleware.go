@@ -7,6 +7,9 @@ import (
 	"common/pkg/logger"
 	"common/pkg/utils"
 	"io"
+	"mime"
+	"net/http"
+	"strings"
 	"time"
 
 	"common/constants"
@@ -14,51 +17,113 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	DefaultLogMaxBodyBytes = 4 << 10
+
+	redactedHeaderValue = "[REDACTED]"
+	truncatedBodySuffix = "...[truncated]"
+)
+
+var (
+	// DefaultLogContentTypes are the body media types logged by default.
+	// Entries ending in "/" match a whole type, such as "text/"
+	DefaultLogContentTypes = []string{"application/json", "application/problem+json", "application/xml", "application/x-www-form-urlencoded", "text/"}
+
+	DefaultLogSkipPaths = []string{"/health", "/metrics"}
+
+	DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", ServiceAuthHeader, ServiceTokenHeader, DefaultAPIKeyHeader, "X-CSRF-Token"}
+)
+
+type LoggerConfig struct {
+	// MaxBodyBytes caps how much of each request and response body is
+	// logged; longer bodies are truncated. Zero disables body capture
+	MaxBodyBytes int
+	// ContentTypes lists the media types whose bodies are logged. Requests
+	// without a Content-Type are logged too, since they are rarely binary
+	ContentTypes []string
+	// SkipPaths are not logged at all; a path also covers its sub-paths
+	SkipPaths []string
+	// RedactHeaders are logged with their values replaced
+	RedactHeaders []string
+}
+
+// DefaultLoggerConfig logs up to 4 KiB of text bodies and skips health and
+// metrics endpoints
+func DefaultLoggerConfig() LoggerConfig {
+	return LoggerConfig{
+		MaxBodyBytes:  DefaultLogMaxBodyBytes,
+		ContentTypes:  DefaultLogContentTypes,
+		SkipPaths:     DefaultLogSkipPaths,
+		RedactHeaders: DefaultRedactedHeaders,
+	}
+}
+
+// LoggerMiddleware logs requests with DefaultLoggerConfig
 func LoggerMiddleware(logger logger.Logger, jwt jwt.JWT) gin.HandlerFunc {
+	return NewLoggerMiddleware(logger, DefaultLoggerConfig())
+}
+
+// NewLoggerMiddleware logs each request when it starts and when it
+// completes. Bodies are captured only up to MaxBodyBytes and for allowed
+// content types, so uploads and downloads are streamed without buffering
+func NewLoggerMiddleware(logger logger.Logger, config LoggerConfig) gin.HandlerFunc {
+	redact := make(map[string]bool, len(config.RedactHeaders))
+	for _, name := range config.RedactHeaders {
+		redact[http.CanonicalHeaderKey(name)] = true
+	}
+
 	return func(c *gin.Context) {
-		startTime := time.Now()
+		if skipPath(config.SkipPaths, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
 
-		var requestBodyBytes []byte
+		startTime := time.Now()
 
-		if c.Request.Body != nil {
-			requestBodyBytes, _ = io.ReadAll(c.Request.Body)
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBodyBytes))
+		var requestBody interface{}
+		var capturedSize int
+		if c.Request.Body != nil && config.MaxBodyBytes > 0 && loggableContentType(config.ContentTypes, c.GetHeader("Content-Type"), true) {
+			var captured []byte
+			captured, c.Request.Body = captureRequestBody(c.Request.Body, config.MaxBodyBytes)
+			capturedSize = len(captured)
+			requestBody = truncateBody(captured, config.MaxBodyBytes)
+		}
+		requestSize := capturedSize
+		if c.Request.ContentLength >= 0 {
+			requestSize = int(c.Request.ContentLength)
 		}
 
 		logEntry := dto.LogEntry{
-			RequestID:   c.GetString("request_id"),
-			TraceID:     c.GetString("trace_id"),
-			SpanID:      c.GetString("span_id"),
-			Host:        c.Request.Host,
-			Path:        c.Request.URL.RequestURI(),
-			ClientIP:    c.ClientIP(),
-			LatLong:     c.GetHeader("X-Lat-Long"),
-			UserID:      c.GetString("user_id"),
-			RequestSize: utils.FormatMemorySize(requestBodyBytes),
-			Method:      c.Request.Method,
-			RequestBody: string(requestBodyBytes),
-			UserAgent:   c.Request.UserAgent(),
-			Action:      constants.ActionMiddlewareStart,
-			EndTime:     time.Now(),
+			StartTime:     startTime,
+			RequestID:     c.GetString(RequestIDKey),
+			CorrelationID: c.GetString(CorrelationIDKey),
+			TraceID:       c.GetString("trace_id"),
+			SpanID:        c.GetString("span_id"),
+			Host:          c.Request.Host,
+			Path:          c.Request.URL.RequestURI(),
+			ClientIP:      c.ClientIP(),
+			LatLong:       c.GetHeader("X-Lat-Long"),
+			UserID:        c.GetString("user_id"),
+			RequestSize:   utils.FormatMemorySize(requestSize),
+			Method:        c.Request.Method,
+			RequestHeader: headersForLog(c.Request.Header, redact),
+			RequestBody:   requestBody,
+			UserAgent:     c.Request.UserAgent(),
+			Action:        constants.ActionMiddlewareStart,
 		}
-		logEntry.Action = constants.ActionMiddlewareStart
 		logger.Info("Request started", logEntry)
 
-		blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
+		blw := &bodyLogWriter{ResponseWriter: c.Writer, config: &config}
 		c.Writer = blw
 
 		c.Next()
 
 		endTime := time.Now()
 		logEntry.EndTime = endTime
-		logEntry.ResponseBody = blw.body.String()
+		logEntry.ResponseBody = truncateBody(blw.body.Bytes(), config.MaxBodyBytes)
 		logEntry.StatusCode = blw.Status()
-
-		latency := endTime.Sub(startTime)
-
-		logEntry.Duration = latency
-		logEntry.Action = constants.ActionMiddlewareEnd
-		logEntry.ResponseSize = utils.FormatMemorySize(blw.body.Bytes())
+		logEntry.Duration = endTime.Sub(startTime)
+		logEntry.ResponseSize = utils.FormatMemorySize(blw.size)
 
 		switch {
 		case blw.Status() >= 500:
@@ -74,12 +139,95 @@ func LoggerMiddleware(logger logger.Logger, jwt jwt.JWT) gin.HandlerFunc {
 	}
 }
 
+// captureRequestBody reads up to limit+1 bytes, one more than is logged so
+// truncation can be detected, and returns a body that replays them before
+// the unread rest
+func captureRequestBody(body io.ReadCloser, limit int) ([]byte, io.ReadCloser) {
+	captured, _ := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	return captured, struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(captured), body), body}
+}
+
+func truncateBody(body []byte, limit int) string {
+	if len(body) > limit {
+		return string(body[:limit]) + truncatedBodySuffix
+	}
+	return string(body)
+}
+
+func loggableContentType(allowed []string, contentType string, allowEmpty bool) bool {
+	if contentType == "" {
+		return allowEmpty
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, entry := range allowed {
+		if mediaType == entry || strings.HasSuffix(entry, "/") && strings.HasPrefix(mediaType, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+func headersForLog(header http.Header, redact map[string]bool) map[string]string {
+	out := make(map[string]string, len(header))
+	for name, values := range header {
+		if redact[name] {
+			out[name] = redactedHeaderValue
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+func skipPath(paths []string, path string) bool {
+	for _, skip := range paths {
+		if path == skip || strings.HasPrefix(path, strings.TrimSuffix(skip, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyLogWriter keeps up to MaxBodyBytes+1 of a loggable response body and
+// counts the rest
 type bodyLogWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	config *LoggerConfig
+	body   bytes.Buffer
+	size   int
+
+	checked, loggable bool
 }
 
 func (w *bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	w.capture(b)
 	return w.ResponseWriter.Write(b)
 }
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyLogWriter) capture(b []byte) {
+	w.size += len(b)
+	if !w.checked {
+		w.checked = true
+		w.loggable = w.config.MaxBodyBytes > 0 && loggableContentType(w.config.ContentTypes, w.Header().Get("Content-Type"), false)
+	}
+	if !w.loggable {
+		return
+	}
+	if room := w.config.MaxBodyBytes + 1 - w.body.Len(); room > 0 {
+		if len(b) > room {
+			b = b[:room]
+		}
+		w.body.Write(b)
+	}
+}
@@ -0,0 +1,144 @@
+package middlewares
+
+import (
+	"common/constants"
+	"common/pkg/logger/loggertest"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestLoggerMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		level   loggertest.Level
+		message string
+		action  constants.LogAction
+	}{
+		{"success", http.StatusOK, loggertest.InfoLevel, "Request Completed", constants.ActionMiddlewareEnd},
+		{"client error", http.StatusNotFound, loggertest.WarnLevel, "Client Error", constants.ActionMiddlewareError},
+		{"server error", http.StatusInternalServerError, loggertest.ErrorLevel, "Server Error", constants.ActionMiddlewareError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, logs := loggertest.New()
+
+			router := gin.New()
+			router.Use(LoggerMiddleware(log, nil))
+			router.POST("/users", func(c *gin.Context) {
+				c.String(tt.status, "done")
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"jane"}`))
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			logs.AssertLogged(t, loggertest.InfoLevel, "Request started", map[string]interface{}{
+				"method":       http.MethodPost,
+				"path":         "/users",
+				"request_body": `{"name":"jane"}`,
+				"action":       constants.ActionMiddlewareStart,
+			})
+
+			logs.AssertLogged(t, tt.level, tt.message, map[string]interface{}{
+				"status_code":   tt.status,
+				"response_body": "done",
+				"action":        tt.action,
+			})
+		})
+	}
+}
+
+func TestLoggerMiddlewareBodyCapture(t *testing.T) {
+	log, logs := loggertest.New()
+	config := DefaultLoggerConfig()
+	config.MaxBodyBytes = 8
+
+	router := gin.New()
+	router.Use(NewLoggerMiddleware(log, config))
+	router.POST("/echo", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, c.GetHeader("Content-Type"), body)
+	})
+	router.GET("/health/live", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantBody    interface{}
+	}{
+		{"short json", "application/json", `{"a":1}`, `{"a":1}`},
+		{"long text is truncated", "text/plain; charset=utf-8", "0123456789", "01234567" + truncatedBodySuffix},
+		{"binary is skipped", "application/octet-stream", "\x00\x01\x02", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			router.ServeHTTP(w, req)
+
+			if w.Body.String() != tt.body {
+				t.Errorf("handler saw body %q, want %q", w.Body, tt.body)
+			}
+			started := logs.FilterMessage("Request started").All()
+			completed := logs.FilterMessage("Request Completed").All()
+			if len(started) != 1 || len(completed) != 1 {
+				t.Fatalf("logged %d start and %d completion entries", len(started), len(completed))
+			}
+			if got := started[0].ContextMap()["request_body"]; got != tt.wantBody {
+				t.Errorf("request_body = %#v, want %#v", got, tt.wantBody)
+			}
+			got := completed[0].ContextMap()["response_body"]
+			if got == "" {
+				got = nil
+			}
+			if got != tt.wantBody {
+				t.Errorf("response_body = %#v, want %#v", got, tt.wantBody)
+			}
+		})
+	}
+
+	logs.Reset()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if logs.Len() != 0 {
+		t.Errorf("skipped path logged %d entries", logs.Len())
+	}
+}
+
+func TestLoggerMiddlewareHeaders(t *testing.T) {
+	log, logs := loggertest.New()
+	router := gin.New()
+	router.Use(LoggerMiddleware(log, nil))
+	router.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Accept", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.FilterMessage("Request started").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d start entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	headers, _ := fields["request_header"].(map[string]string)
+	if headers["Authorization"] != redactedHeaderValue || headers["Accept"] != "application/json" {
+		t.Errorf("request_header = %v", fields["request_header"])
+	}
+	if start, _ := fields["start_time"].(time.Time); start.IsZero() {
+		t.Errorf("start_time = %v, want the request start", fields["start_time"])
+	}
+}
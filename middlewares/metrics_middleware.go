@@ -0,0 +1,173 @@
+package middlewares
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// unmatchedRoute labels requests that did not match any route, so unknown
+// paths cannot blow up label cardinality
+const unmatchedRoute = "unmatched"
+
+var (
+	DefaultDurationBuckets = prometheus.DefBuckets
+	DefaultSizeBuckets     = prometheus.ExponentialBuckets(128, 4, 8) // 128B .. 2MB
+	DefaultMetricsExcludes = []string{"/health", "/metrics"}
+)
+
+type MetricsConfig struct {
+	Namespace string `json:"namespace" yaml:"namespace"`
+	Subsystem string `json:"subsystem" yaml:"subsystem"`
+
+	// DurationBuckets are latency histogram buckets in seconds
+	DurationBuckets []float64 `json:"duration_buckets" yaml:"duration_buckets"`
+	// SizeBuckets are request and response size histogram buckets in bytes
+	SizeBuckets []float64 `json:"size_buckets" yaml:"size_buckets"`
+
+	// ExcludePaths are URL paths that are not measured; a path also covers
+	// its sub-paths, so "/health" excludes "/health/ready" but not "/healthcare"
+	ExcludePaths []string `json:"exclude_paths" yaml:"exclude_paths"`
+
+	// Registerer defaults to prometheus.DefaultRegisterer. When it is also a
+	// prometheus.Gatherer, such as a *prometheus.Registry, pkg/server serves
+	// it on /metrics
+	Registerer prometheus.Registerer `json:"-" yaml:"-"`
+}
+
+type httpMetrics struct {
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	inFlight     prometheus.Gauge
+	requestSize  *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+}
+
+// MetricsMiddleware records request rate, errors and duration (RED) metrics
+// labelled by method, route template and status code. When the request is
+// traced, latency observations carry the trace ID as an exemplar
+func MetricsMiddleware(config MetricsConfig) gin.HandlerFunc {
+	if config.DurationBuckets == nil {
+		config.DurationBuckets = DefaultDurationBuckets
+	}
+	if config.SizeBuckets == nil {
+		config.SizeBuckets = DefaultSizeBuckets
+	}
+	if config.ExcludePaths == nil {
+		config.ExcludePaths = DefaultMetricsExcludes
+	}
+	if config.Registerer == nil {
+		config.Registerer = prometheus.DefaultRegisterer
+	}
+
+	m := newHTTPMetrics(config)
+
+	return func(c *gin.Context) {
+		if skipPath(config.ExcludePaths, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		status := strconv.Itoa(c.Writer.Status())
+
+		m.requests.WithLabelValues(method, route, status).Inc()
+
+		duration := m.duration.WithLabelValues(method, route, status)
+		elapsed := time.Since(start).Seconds()
+		if traceID := requestTraceID(c); traceID != "" {
+			duration.(prometheus.ExemplarObserver).ObserveWithExemplar(elapsed, prometheus.Labels{"trace_id": traceID})
+		} else {
+			duration.Observe(elapsed)
+		}
+
+		if size := c.Request.ContentLength; size >= 0 {
+			m.requestSize.WithLabelValues(method, route).Observe(float64(size))
+		}
+		m.responseSize.WithLabelValues(method, route, status).Observe(float64(max(c.Writer.Size(), 0)))
+	}
+}
+
+func newHTTPMetrics(config MetricsConfig) *httpMetrics {
+	ns, sub := config.Namespace, config.Subsystem
+
+	m := &httpMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: sub,
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Subsystem: sub,
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency in seconds.",
+			Buckets: config.DurationBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns, Subsystem: sub,
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served.",
+		}),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Subsystem: sub,
+			Name:    "http_request_size_bytes",
+			Help:    "HTTP request body size in bytes.",
+			Buckets: config.SizeBuckets,
+		}, []string{"method", "route"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Subsystem: sub,
+			Name:    "http_response_size_bytes",
+			Help:    "HTTP response body size in bytes.",
+			Buckets: config.SizeBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	m.requests = registerCollector(config.Registerer, m.requests)
+	m.duration = registerCollector(config.Registerer, m.duration)
+	m.inFlight = registerCollector(config.Registerer, m.inFlight)
+	m.requestSize = registerCollector(config.Registerer, m.requestSize)
+	m.responseSize = registerCollector(config.Registerer, m.responseSize)
+
+	return m
+}
+
+// registerCollector registers c, returning the existing collector when an
+// identical one was registered before (for example by a second server)
+func registerCollector[T prometheus.Collector](registerer prometheus.Registerer, c T) T {
+	if err := registerer.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+// requestTraceID returns the trace ID set by TracingMiddleware, or the one
+// from the request context's span
+func requestTraceID(c *gin.Context) string {
+	if traceID := c.GetString("trace_id"); traceID != "" {
+		return traceID
+	}
+	if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
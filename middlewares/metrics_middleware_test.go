@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736")
+	})
+	router.Use(MetricsMiddleware(MetricsConfig{Namespace: "users", Registerer: registry}))
+	router.GET("/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "jane") })
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/healthcare/records", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/users/1", "/users/2", "/health", "/healthcare/records", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	requests, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+
+	counts := map[string]float64{}
+	for _, family := range requests {
+		if family.GetName() != "users_http_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			counts[labels["route"]+" "+labels["status"]] = metric.GetCounter().GetValue()
+		}
+	}
+
+	want := map[string]float64{"/users/:id 200": 2, "/healthcare/records 200": 1, "unmatched 404": 1}
+	for key, value := range want {
+		if counts[key] != value {
+			t.Errorf("requests_total{%s} = %v, want %v", key, counts[key], value)
+		}
+	}
+	if len(counts) != len(want) {
+		t.Errorf("unexpected series %v; /health should be excluded", counts)
+	}
+
+	if n := testutil.CollectAndCount(registry, "users_http_request_duration_seconds"); n != 3 {
+		t.Errorf("duration series = %d, want 3", n)
+	}
+
+	for _, family := range requests {
+		if family.GetName() != "users_http_request_duration_seconds" {
+			continue
+		}
+		found := false
+		for _, bucket := range family.GetMetric()[0].GetHistogram().GetBucket() {
+			if ex := bucket.GetExemplar(); ex != nil && ex.GetLabel()[0].GetValue() == "4bf92f3577b34da6a3ce929d0e0e4736" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("latency histogram has no trace_id exemplar")
+		}
+	}
+}
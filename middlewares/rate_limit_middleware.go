@@ -0,0 +1,105 @@
+package middlewares
+
+import (
+	"common/pkg/logger"
+	"common/pkg/ratelimit"
+	"common/pkg/utils/response"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// RateLimitKeyFunc identifies the client a request is counted against
+type RateLimitKeyFunc func(c *gin.Context) string
+
+type RateLimitConfig struct {
+	// Rule is the default limit applied to every route
+	Rule ratelimit.Rule
+
+	// Routes overrides Rule for specific routes, keyed by gin's route template
+	// ("/users/:id") or by method and template ("POST /users")
+	Routes map[string]ratelimit.Rule
+
+	// KeyFunc defaults to RateLimitByIP
+	KeyFunc RateLimitKeyFunc
+}
+
+// RateLimitByIP counts requests per client IP. gin only reads the IP from
+// X-Forwarded-For when the peer is a trusted proxy, so engines must call
+// SetTrustedProxies; pkg/server does this from Config.TrustedProxies
+func RateLimitByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitByUser counts requests per user_id set by AuthMiddleware, falling
+// back to the client IP for unauthenticated requests
+func RateLimitByUser(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID
+	}
+	return RateLimitByIP(c)
+}
+
+// RateLimitByAPIKey counts requests per API key header, falling back to the client IP
+func RateLimitByAPIKey(header string) RateLimitKeyFunc {
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+	return func(c *gin.Context) string {
+		if key := c.GetHeader(header); key != "" {
+			return "key:" + key
+		}
+		return RateLimitByIP(c)
+	}
+}
+
+// RateLimitMiddleware enforces token bucket limits and reports them through
+// the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+// Store errors are logged and the request is let through
+func RateLimitMiddleware(logger logger.Logger, store ratelimit.Store, config RateLimitConfig) gin.HandlerFunc {
+	keyFunc := config.KeyFunc
+	if keyFunc == nil {
+		keyFunc = RateLimitByIP
+	}
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		rule, scope := config.Rule, "default"
+		if override, ok := config.Routes[c.Request.Method+" "+route]; ok {
+			rule, scope = override, c.Request.Method+" "+route
+		} else if override, ok := config.Routes[route]; ok {
+			rule, scope = override, route
+		}
+
+		result, err := store.Allow(c.Request.Context(), scope+"|"+keyFunc(c), rule)
+		if err != nil {
+			if logger != nil {
+				logger.Warn("rate limit check failed", "error", err, "path", route)
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			resp := response.TooManyRequests("Too many requests, please try again later")
+			c.JSON(resp.Status, resp)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
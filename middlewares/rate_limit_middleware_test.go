@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"common/pkg/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(RateLimitMiddleware(nil, ratelimit.NewMemoryStore(), RateLimitConfig{
+		Rule: ratelimit.Rule{Requests: 5, Duration: time.Minute},
+		Routes: map[string]ratelimit.Rule{
+			"POST /login": {Requests: 1, Duration: time.Minute},
+		},
+	}))
+	router.POST("/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	if w := do(http.MethodPost, "/login"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("first login: status %d limit %q", w.Code, w.Header().Get("RateLimit-Limit"))
+	}
+
+	w := do(http.MethodPost, "/login")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second login: status %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "60" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("429 headers: Retry-After %q, RateLimit-Remaining %q", w.Header().Get("Retry-After"), w.Header().Get("RateLimit-Remaining"))
+	}
+
+	if w := do(http.MethodGet, "/users"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "4" {
+		t.Errorf("default rule: status %d remaining %q", w.Code, w.Header().Get("RateLimit-Remaining"))
+	}
+}
@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"common/constants"
+	"common/pkg/correlation"
+	"common/pkg/logger"
+	"common/pkg/utils/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecoveryMiddleware turns panics into a 500 APIResponse and logs them with
+// the stack and request ID. Panics caused by the client hanging up are
+// logged as warnings and no response is attempted
+func RecoveryMiddleware(logger logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// net/http uses this to abort a response on purpose
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			err, ok := recovered.(error)
+			if !ok {
+				err = fmt.Errorf("%v", recovered)
+			}
+
+			fields := []interface{}{
+				"error", err,
+				"stack", string(debug.Stack()),
+				"request_id", requestID(c),
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"action", constants.ActionRequestPanic,
+			}
+
+			if isBrokenPipe(err) {
+				logger.Warn("client connection closed", fields...)
+				c.Abort()
+				return
+			}
+
+			logger.Error("panic recovered", fields...)
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			resp := response.InternalServerError("Internal server error")
+			c.AbortWithStatusJSON(resp.Status, resp)
+		}()
+
+		c.Next()
+	}
+}
+
+// requestID returns the ID set by RequestIDMiddleware or TracingMiddleware,
+// falling back to the X-Request-ID header
+func requestID(c *gin.Context) string {
+	if id := c.GetString(RequestIDKey); id != "" {
+		return id
+	}
+	if id := correlation.RequestIDFromContext(c.Request.Context()); id != "" {
+		return id
+	}
+	return c.GetHeader(correlation.HeaderRequestID)
+}
+
+func isBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
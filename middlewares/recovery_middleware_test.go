@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"common/pkg/logger/loggertest"
+	"common/pkg/utils/response"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	log, recorder := loggertest.New()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("request_id", "req-1")
+	})
+	router.Use(RecoveryMiddleware(log))
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+	router.GET("/hangup", func(c *gin.Context) { panic(&httpError{syscall.EPIPE}) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	var body response.APIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Status != http.StatusInternalServerError {
+		t.Errorf("body = %s, want APIResponse with status 500", w.Body.String())
+	}
+
+	entries := recorder.FilterMessage("panic recovered").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d panic entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["request_id"] != "req-1" {
+		t.Errorf("request_id = %v, want req-1", fields["request_id"])
+	}
+	if stack, _ := fields["stack"].(string); !strings.Contains(stack, "recovery_middleware_test.go") {
+		t.Errorf("stack does not include the panicking handler:\n%s", stack)
+	}
+
+	recorder.Reset()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hangup", nil))
+	recorder.AssertLogged(t, loggertest.WarnLevel, "client connection closed", nil)
+	recorder.AssertNotLogged(t, loggertest.ErrorLevel, "panic recovered")
+}
+
+type httpError struct{ err error }
+
+func (e *httpError) Error() string { return "write: " + e.err.Error() }
+func (e *httpError) Unwrap() error { return e.err }
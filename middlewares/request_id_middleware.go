@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"common/pkg/correlation"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDKey and CorrelationIDKey are the gin context keys holding the IDs
+	RequestIDKey     = "request_id"
+	CorrelationIDKey = "correlation_id"
+)
+
+// RequestIDMiddleware reuses a valid inbound X-Request-ID and
+// X-Correlation-ID or generates a request ID with generate (UUIDv7 when nil).
+// The IDs are stored in the gin context, in the request context for
+// correlation.FromContext, and echoed in the response headers
+func RequestIDMiddleware(generate correlation.Generator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ensureCorrelation(c, generate)
+		c.Next()
+	}
+}
+
+// ensureCorrelation returns the IDs set by an earlier middleware, or
+// extracts them from the request and stores them
+func ensureCorrelation(c *gin.Context, generate correlation.Generator) correlation.IDs {
+	if ids, ok := correlation.FromContext(c.Request.Context()); ok {
+		return ids
+	}
+
+	ids := correlation.Extract(c.Request.Header, generate)
+	c.Set(RequestIDKey, ids.RequestID)
+	c.Set(CorrelationIDKey, ids.CorrelationID)
+	c.Request = c.Request.WithContext(correlation.NewContext(c.Request.Context(), ids))
+	c.Header(correlation.HeaderRequestID, ids.RequestID)
+	c.Header(correlation.HeaderCorrelationID, ids.CorrelationID)
+	return ids
+}
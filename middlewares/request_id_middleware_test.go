@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"common/pkg/correlation"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	generate := func() string { return "generated" }
+
+	tests := []struct {
+		name              string
+		requestID         string
+		correlationID     string
+		wantRequestID     string
+		wantCorrelationID string
+	}{
+		{"generated", "", "", "generated", "generated"},
+		{"inbound request id", "req-1", "", "req-1", "req-1"},
+		{"inbound both", "req-1", "corr-1", "req-1", "corr-1"},
+		{"invalid request id", "bad id\n", "corr-1", "generated", "corr-1"},
+		{"too long", strings.Repeat("a", correlation.MaxIDLength+1), "", "generated", "generated"},
+		{"invalid correlation id", "req-1", "bad id", "req-1", "req-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotKey string
+			var gotCtx correlation.IDs
+			router := gin.New()
+			router.Use(RequestIDMiddleware(generate))
+			router.GET("/", func(c *gin.Context) {
+				gotKey = c.GetString(RequestIDKey)
+				gotCtx, _ = correlation.FromContext(c.Request.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestID != "" {
+				req.Header.Set(correlation.HeaderRequestID, tt.requestID)
+			}
+			if tt.correlationID != "" {
+				req.Header.Set(correlation.HeaderCorrelationID, tt.correlationID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			want := correlation.IDs{RequestID: tt.wantRequestID, CorrelationID: tt.wantCorrelationID}
+			if gotCtx != want {
+				t.Errorf("context ids = %+v, want %+v", gotCtx, want)
+			}
+			if gotKey != tt.wantRequestID {
+				t.Errorf("gin request_id = %q, want %q", gotKey, tt.wantRequestID)
+			}
+			if got := w.Header().Get(correlation.HeaderRequestID); got != tt.wantRequestID {
+				t.Errorf("X-Request-ID = %q, want %q", got, tt.wantRequestID)
+			}
+			if got := w.Header().Get(correlation.HeaderCorrelationID); got != tt.wantCorrelationID {
+				t.Errorf("X-Correlation-ID = %q, want %q", got, tt.wantCorrelationID)
+			}
+		})
+	}
+}
+
+func TestTracingMiddlewareReusesRequestID(t *testing.T) {
+	router := gin.New()
+	router.Use(RequestIDMiddleware(func() string { return "first" }))
+	router.Use(TracingMiddleware(noop.NewTracerProvider().Tracer("test")))
+	var got string
+	router.GET("/", func(c *gin.Context) { got = c.GetString(RequestIDKey) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got != "first" || w.Header().Get(correlation.HeaderRequestID) != "first" {
+		t.Errorf("request id = %q, header %q; want the id from RequestIDMiddleware", got, w.Header().Get(correlation.HeaderRequestID))
+	}
+}
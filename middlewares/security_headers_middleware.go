@@ -0,0 +1,272 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	FrameOptionsDeny       = "DENY"
+	FrameOptionsSameOrigin = "SAMEORIGIN"
+
+	// hstsPreloadMinAge is the shortest max-age the HSTS preload list accepts
+	hstsPreloadMinAge = 365 * 24 * time.Hour
+)
+
+// referrerPolicies are the values browsers accept for Referrer-Policy
+var referrerPolicies = map[string]bool{
+	"no-referrer":                     true,
+	"no-referrer-when-downgrade":      true,
+	"origin":                          true,
+	"origin-when-cross-origin":        true,
+	"same-origin":                     true,
+	"strict-origin":                   true,
+	"strict-origin-when-cross-origin": true,
+	"unsafe-url":                      true,
+}
+
+// SecurityHeadersPolicy lists the headers sent with each response. Empty
+// fields and a zero HSTS max age leave the header out
+type SecurityHeadersPolicy struct {
+	HSTS HSTSPolicy `json:"hsts" yaml:"hsts"`
+	// ContentSecurityPolicy is the CSP header value; see NewCSP
+	ContentSecurityPolicy string `json:"content_security_policy" yaml:"content_security_policy"`
+	// CSPReportOnly sends the CSP as Content-Security-Policy-Report-Only, to
+	// try a policy out without enforcing it
+	CSPReportOnly bool `json:"csp_report_only" yaml:"csp_report_only"`
+	// NoSniff sends X-Content-Type-Options: nosniff
+	NoSniff bool `json:"no_sniff" yaml:"no_sniff"`
+	// FrameOptions is FrameOptionsDeny or FrameOptionsSameOrigin
+	FrameOptions      string `json:"frame_options" yaml:"frame_options"`
+	ReferrerPolicy    string `json:"referrer_policy" yaml:"referrer_policy"`
+	PermissionsPolicy string `json:"permissions_policy" yaml:"permissions_policy"`
+}
+
+type HSTSPolicy struct {
+	MaxAge            time.Duration `json:"max_age" yaml:"max_age"`
+	IncludeSubdomains bool          `json:"include_subdomains" yaml:"include_subdomains"`
+	// Preload opts into browser preload lists, which requires a max age of
+	// at least a year and IncludeSubdomains
+	Preload bool `json:"preload" yaml:"preload"`
+}
+
+type SecurityHeadersConfig struct {
+	SecurityHeadersPolicy `yaml:",inline"`
+
+	// Groups replaces the policy for requests whose path is under the given
+	// prefix ("/admin"). The longest matching prefix wins
+	Groups map[string]SecurityHeadersPolicy `json:"groups" yaml:"groups"`
+}
+
+// DefaultSecurityHeadersPolicy suits JSON APIs: responses may not be framed,
+// run scripts or load anything. Frontends need a CSP of their own
+func DefaultSecurityHeadersPolicy() SecurityHeadersPolicy {
+	return SecurityHeadersPolicy{
+		HSTS:                  HSTSPolicy{MaxAge: 365 * 24 * time.Hour, IncludeSubdomains: true},
+		ContentSecurityPolicy: NewCSP().DefaultSrc(CSPNone).FrameAncestors(CSPNone).String(),
+		NoSniff:               true,
+		FrameOptions:          FrameOptionsDeny,
+		ReferrerPolicy:        "no-referrer",
+		PermissionsPolicy:     "camera=(), microphone=(), geolocation=(), payment=()",
+	}
+}
+
+// Validate rejects values browsers would ignore
+func (c SecurityHeadersConfig) Validate() error {
+	if err := c.SecurityHeadersPolicy.Validate(); err != nil {
+		return err
+	}
+	for prefix, policy := range c.Groups {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("security headers group %q: prefix must start with /", prefix)
+		}
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("security headers group %q: %w", prefix, err)
+		}
+	}
+	return nil
+}
+
+func (p SecurityHeadersPolicy) Validate() error {
+	if p.HSTS.MaxAge < 0 {
+		return errors.New("security headers: hsts max age must not be negative")
+	}
+	if p.HSTS.Preload && (p.HSTS.MaxAge < hstsPreloadMinAge || !p.HSTS.IncludeSubdomains) {
+		return errors.New("security headers: hsts preload requires a max age of at least a year and include_subdomains")
+	}
+	switch p.FrameOptions {
+	case "", FrameOptionsDeny, FrameOptionsSameOrigin:
+	default:
+		return fmt.Errorf("security headers: frame options must be %s or %s, got %q", FrameOptionsDeny, FrameOptionsSameOrigin, p.FrameOptions)
+	}
+	if p.ReferrerPolicy != "" {
+		for _, policy := range strings.Split(p.ReferrerPolicy, ",") {
+			if !referrerPolicies[strings.TrimSpace(policy)] {
+				return fmt.Errorf("security headers: unknown referrer policy %q", policy)
+			}
+		}
+	}
+	if p.CSPReportOnly && p.ContentSecurityPolicy == "" {
+		return errors.New("security headers: csp report only requires a content security policy")
+	}
+	for _, value := range []string{p.ContentSecurityPolicy, p.PermissionsPolicy} {
+		if strings.ContainsAny(value, "\r\n") {
+			return errors.New("security headers: header values must not contain line breaks")
+		}
+	}
+	return nil
+}
+
+// headers renders the policy once, so requests only copy strings
+func (p SecurityHeadersPolicy) headers() [][2]string {
+	var headers [][2]string
+	if p.HSTS.MaxAge > 0 {
+		value := "max-age=" + strconv.FormatInt(int64(p.HSTS.MaxAge/time.Second), 10)
+		if p.HSTS.IncludeSubdomains {
+			value += "; includeSubDomains"
+		}
+		if p.HSTS.Preload {
+			value += "; preload"
+		}
+		headers = append(headers, [2]string{"Strict-Transport-Security", value})
+	}
+	if p.ContentSecurityPolicy != "" {
+		name := "Content-Security-Policy"
+		if p.CSPReportOnly {
+			name = "Content-Security-Policy-Report-Only"
+		}
+		headers = append(headers, [2]string{name, p.ContentSecurityPolicy})
+	}
+	if p.NoSniff {
+		headers = append(headers, [2]string{"X-Content-Type-Options", "nosniff"})
+	}
+	if p.FrameOptions != "" {
+		headers = append(headers, [2]string{"X-Frame-Options", p.FrameOptions})
+	}
+	if p.ReferrerPolicy != "" {
+		headers = append(headers, [2]string{"Referrer-Policy", p.ReferrerPolicy})
+	}
+	if p.PermissionsPolicy != "" {
+		headers = append(headers, [2]string{"Permissions-Policy", p.PermissionsPolicy})
+	}
+	return headers
+}
+
+// SecurityHeadersMiddleware sets the headers of the root policy, or of the
+// longest matching group, before the handler runs so that error responses
+// carry them too. The config must be valid; see SecurityHeadersConfig.Validate
+func SecurityHeadersMiddleware(config SecurityHeadersConfig) gin.HandlerFunc {
+	root := config.SecurityHeadersPolicy.headers()
+
+	type group struct {
+		prefix  string
+		headers [][2]string
+	}
+	groups := make([]group, 0, len(config.Groups))
+	for prefix, policy := range config.Groups {
+		groups = append(groups, group{strings.TrimSuffix(prefix, "/"), policy.headers()})
+	}
+	sort.Slice(groups, func(i, j int) bool { return len(groups[i].prefix) > len(groups[j].prefix) })
+
+	return func(c *gin.Context) {
+		headers := root
+		path := c.Request.URL.Path
+		for _, g := range groups {
+			if path == g.prefix || strings.HasPrefix(path, g.prefix+"/") || g.prefix == "" {
+				headers = g.headers
+				break
+			}
+		}
+
+		h := c.Writer.Header()
+		for _, header := range headers {
+			h.Set(header[0], header[1])
+		}
+		c.Next()
+	}
+}
+
+// CSP source keywords
+const (
+	CSPSelf          = "'self'"
+	CSPNone          = "'none'"
+	CSPUnsafeInline  = "'unsafe-inline'"
+	CSPUnsafeEval    = "'unsafe-eval'"
+	CSPStrictDynamic = "'strict-dynamic'"
+	CSPData          = "data:"
+	CSPBlob          = "blob:"
+	CSPHTTPS         = "https:"
+)
+
+// CSP builds a Content-Security-Policy value. Directives keep the order in
+// which they were first added, and adding a directive again appends sources:
+//
+//	NewCSP().DefaultSrc(CSPSelf).ScriptSrc(CSPSelf, "https://cdn.example.com").String()
+type CSP struct {
+	names   []string
+	sources map[string][]string
+}
+
+func NewCSP() *CSP {
+	return &CSP{sources: make(map[string][]string)}
+}
+
+// Directive adds sources to the named directive. Directives without sources,
+// such as upgrade-insecure-requests, are added with none
+func (p *CSP) Directive(name string, sources ...string) *CSP {
+	existing, ok := p.sources[name]
+	if !ok {
+		p.names = append(p.names, name)
+	}
+	for _, source := range sources {
+		if !containsString(existing, source) {
+			existing = append(existing, source)
+		}
+	}
+	p.sources[name] = existing
+	return p
+}
+
+func (p *CSP) DefaultSrc(sources ...string) *CSP { return p.Directive("default-src", sources...) }
+func (p *CSP) ScriptSrc(sources ...string) *CSP  { return p.Directive("script-src", sources...) }
+func (p *CSP) StyleSrc(sources ...string) *CSP   { return p.Directive("style-src", sources...) }
+func (p *CSP) ImgSrc(sources ...string) *CSP     { return p.Directive("img-src", sources...) }
+func (p *CSP) FontSrc(sources ...string) *CSP    { return p.Directive("font-src", sources...) }
+func (p *CSP) ConnectSrc(sources ...string) *CSP { return p.Directive("connect-src", sources...) }
+func (p *CSP) MediaSrc(sources ...string) *CSP   { return p.Directive("media-src", sources...) }
+func (p *CSP) ObjectSrc(sources ...string) *CSP  { return p.Directive("object-src", sources...) }
+func (p *CSP) FrameSrc(sources ...string) *CSP   { return p.Directive("frame-src", sources...) }
+func (p *CSP) WorkerSrc(sources ...string) *CSP  { return p.Directive("worker-src", sources...) }
+func (p *CSP) BaseURI(sources ...string) *CSP    { return p.Directive("base-uri", sources...) }
+func (p *CSP) FormAction(sources ...string) *CSP { return p.Directive("form-action", sources...) }
+
+// FrameAncestors controls who may embed the page, superseding X-Frame-Options
+func (p *CSP) FrameAncestors(sources ...string) *CSP {
+	return p.Directive("frame-ancestors", sources...)
+}
+
+func (p *CSP) UpgradeInsecureRequests() *CSP { return p.Directive("upgrade-insecure-requests") }
+
+// ReportURI sends violation reports to uri
+func (p *CSP) ReportURI(uri string) *CSP { return p.Directive("report-uri", uri) }
+
+// ReportTo sends violation reports to a Reporting-Endpoints group
+func (p *CSP) ReportTo(group string) *CSP { return p.Directive("report-to", group) }
+
+func (p *CSP) String() string {
+	directives := make([]string, 0, len(p.names))
+	for _, name := range p.names {
+		if sources := p.sources[name]; len(sources) > 0 {
+			directives = append(directives, name+" "+strings.Join(sources, " "))
+		} else {
+			directives = append(directives, name)
+		}
+	}
+	return strings.Join(directives, "; ")
+}
@@ -0,0 +1,135 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSecurityHeadersConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  SecurityHeadersConfig
+		wantErr bool
+	}{
+		{"default", SecurityHeadersConfig{SecurityHeadersPolicy: DefaultSecurityHeadersPolicy()}, false},
+		{"empty", SecurityHeadersConfig{}, false},
+		{"unknown frame options", SecurityHeadersConfig{SecurityHeadersPolicy: SecurityHeadersPolicy{FrameOptions: "ALLOW-FROM https://a.example.com"}}, true},
+		{"unknown referrer policy", SecurityHeadersConfig{SecurityHeadersPolicy: SecurityHeadersPolicy{ReferrerPolicy: "never"}}, true},
+		{"referrer fallback list", SecurityHeadersConfig{SecurityHeadersPolicy: SecurityHeadersPolicy{ReferrerPolicy: "no-referrer, strict-origin-when-cross-origin"}}, false},
+		{"preload with short max age", SecurityHeadersConfig{SecurityHeadersPolicy: SecurityHeadersPolicy{HSTS: HSTSPolicy{MaxAge: time.Hour, IncludeSubdomains: true, Preload: true}}}, true},
+		{"report only without csp", SecurityHeadersConfig{SecurityHeadersPolicy: SecurityHeadersPolicy{CSPReportOnly: true}}, true},
+		{"line break", SecurityHeadersConfig{SecurityHeadersPolicy: SecurityHeadersPolicy{ContentSecurityPolicy: "default-src 'self'\r\nX-Injected: 1"}}, true},
+		{"invalid group", SecurityHeadersConfig{Groups: map[string]SecurityHeadersPolicy{"/admin": {FrameOptions: "allow"}}}, true},
+		{"group without slash", SecurityHeadersConfig{Groups: map[string]SecurityHeadersPolicy{"admin": {}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	adminCSP := NewCSP().DefaultSrc(CSPSelf).ImgSrc(CSPSelf, CSPData).FrameAncestors(CSPNone).String()
+
+	router := gin.New()
+	router.Use(SecurityHeadersMiddleware(SecurityHeadersConfig{
+		SecurityHeadersPolicy: DefaultSecurityHeadersPolicy(),
+		Groups: map[string]SecurityHeadersPolicy{
+			"/admin": {
+				ContentSecurityPolicy: adminCSP,
+				NoSniff:               true,
+				FrameOptions:          FrameOptionsSameOrigin,
+				ReferrerPolicy:        "strict-origin-when-cross-origin",
+			},
+		},
+	}))
+	router.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/admin/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/administrators", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name string
+		path string
+		want map[string]string
+	}{
+		{"root policy", "/users", map[string]string{
+			"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+			"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+			"X-Content-Type-Options":    "nosniff",
+			"X-Frame-Options":           "DENY",
+			"Referrer-Policy":           "no-referrer",
+			"Permissions-Policy":        "camera=(), microphone=(), geolocation=(), payment=()",
+		}},
+		{"admin group", "/admin/users", map[string]string{
+			"Strict-Transport-Security": "",
+			"Content-Security-Policy":   "default-src 'self'; img-src 'self' data:; frame-ancestors 'none'",
+			"X-Frame-Options":           "SAMEORIGIN",
+			"Referrer-Policy":           "strict-origin-when-cross-origin",
+			"Permissions-Policy":        "",
+		}},
+		{"prefix is not a path segment", "/administrators", map[string]string{
+			"X-Frame-Options": "DENY",
+		}},
+		{"unmatched route", "/missing", map[string]string{
+			"X-Content-Type-Options": "nosniff",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			for name, want := range tt.want {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersReportOnly(t *testing.T) {
+	router := gin.New()
+	router.Use(SecurityHeadersMiddleware(SecurityHeadersConfig{SecurityHeadersPolicy: SecurityHeadersPolicy{
+		ContentSecurityPolicy: "default-src 'self'",
+		CSPReportOnly:         true,
+		HSTS:                  HSTSPolicy{MaxAge: 2 * 365 * 24 * time.Hour, IncludeSubdomains: true, Preload: true},
+	}}))
+	router.GET("/", func(c *gin.Context) {})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := w.Header().Get("Content-Security-Policy-Report-Only"); got != "default-src 'self'" {
+		t.Errorf("report only header = %q", got)
+	}
+	if got := w.Header().Get("Content-Security-Policy"); got != "" {
+		t.Errorf("enforced CSP = %q, want none", got)
+	}
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=63072000; includeSubDomains; preload" {
+		t.Errorf("Strict-Transport-Security = %q", got)
+	}
+}
+
+func TestCSPBuilder(t *testing.T) {
+	got := NewCSP().
+		DefaultSrc(CSPSelf).
+		ScriptSrc(CSPSelf, "https://cdn.example.com").
+		ScriptSrc(CSPSelf, CSPStrictDynamic).
+		ObjectSrc(CSPNone).
+		UpgradeInsecureRequests().
+		ReportURI("/csp-report").
+		String()
+	want := "default-src 'self'; script-src 'self' https://cdn.example.com 'strict-dynamic'; object-src 'none'; upgrade-insecure-requests; report-uri /csp-report"
+	if got != want {
+		t.Errorf("CSP =\n%s\nwant\n%s", got, want)
+	}
+}
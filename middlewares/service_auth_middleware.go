@@ -1,21 +1,27 @@
 package middlewares
 
 import (
-	"common/pkg/jwt"
+	"common/constants"
 	"common/pkg/logger"
+	"common/pkg/serviceauth"
 	"common/pkg/utils/response"
-	"crypto/subtle"
-	"net/http"
-	"time"
+	"errors"
 
 	"github.com/gin-gonic/gin"
 )
 
 const (
-	ServiceAuthHeader = "X-Service-API-Key"
+	ServiceAuthHeader  = "X-Service-API-Key"
+	ServiceTokenHeader = "X-Service-Token"
+
+	// ServiceIdentityKey is the gin context key holding the *serviceauth.Identity
+	ServiceIdentityKey = "service_identity"
 )
 
-func ServiceAuthMiddleware(logger logger.Logger, jwt jwt.JWT) gin.HandlerFunc {
+// ServiceAuthMiddleware authenticates the calling service by its API key
+// and returns a service token in the X-Service-Token header for the
+// requests that follow. The identity is stored in the gin and request contexts
+func ServiceAuthMiddleware(logger logger.Logger, authenticator *serviceauth.Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		apiKey := c.GetHeader(ServiceAuthHeader)
 		if apiKey == "" {
@@ -23,24 +29,57 @@ func ServiceAuthMiddleware(logger logger.Logger, jwt jwt.JWT) gin.HandlerFunc {
 			return
 		}
 
-		// expectedAPIKey := os.Getenv("SERVICE_API_KEY")
-		expectedAPIKey := "SERVICE_API_KEY"
-		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(expectedAPIKey)) != 1 {
-			response.HandleErrorWithAbort(c, http.StatusBadRequest, "invalid service authentication token")
+		identity, err := authenticator.AuthenticateKey(c.Request.Context(), apiKey)
+		if err != nil {
+			// never log the key itself
+			logger.Warn("service authentication failed",
+				"error", err,
+				"ip", c.ClientIP(),
+				"request_id", requestID(c),
+				"action", constants.ActionAuthFailed,
+			)
+			if errors.Is(err, serviceauth.ErrInvalidKey) {
+				response.HandleUnAuthorizedErrorWithAbort(c, "invalid service authentication key")
+			} else {
+				resp := response.InternalServerError("service authentication unavailable")
+				c.AbortWithStatusJSON(resp.Status, resp)
+			}
 			return
 		}
 
-		// Generate JWT token for subsequent requests
-		token, err := jwt.GenerateToken(map[string]interface{}{
-			"service": "authorized-service",
-			"exp":     time.Now().Add(time.Hour * 1).Unix(),
-		})
+		token, err := authenticator.IssueToken(identity)
 		if err != nil {
-			response.HandleServerErrorWithAbort(c, "failed to generate token")
+			logger.Error("failed to issue service token", "error", err, "service_id", identity.ServiceID)
+			resp := response.InternalServerError("failed to generate token")
+			c.AbortWithStatusJSON(resp.Status, resp)
 			return
 		}
 
-		c.Header("X-Service-Token", token)
+		logger.Info("service authenticated",
+			"service_id", identity.ServiceID,
+			"key_id", identity.KeyID,
+			"ip", c.ClientIP(),
+			"action", constants.ActionAuthSuccess,
+		)
+		c.Header(ServiceTokenHeader, token)
+		setServiceIdentity(c, identity)
 		c.Next()
 	}
 }
+
+// GetServiceIdentity returns the calling service stored by the service
+// auth middlewares, or serviceauth.ErrNoIdentity
+func GetServiceIdentity(c *gin.Context) (*serviceauth.Identity, error) {
+	if value, ok := c.Get(ServiceIdentityKey); ok {
+		if identity, ok := value.(*serviceauth.Identity); ok {
+			return identity, nil
+		}
+	}
+	return serviceauth.FromContext(c.Request.Context())
+}
+
+func setServiceIdentity(c *gin.Context, identity *serviceauth.Identity) {
+	c.Set("service_id", identity.ServiceID)
+	c.Set(ServiceIdentityKey, identity)
+	c.Request = c.Request.WithContext(serviceauth.NewContext(c.Request.Context(), identity))
+}
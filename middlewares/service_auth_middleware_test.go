@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"common/pkg/jwt"
+	"common/pkg/logger/loggertest"
+	"common/pkg/serviceauth"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServiceAuthentication(t *testing.T) {
+	log, recorder := loggertest.New()
+	plaintext, key, err := serviceauth.NewAPIKey("payment-service", []string{"transaction:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := serviceauth.NewAuthenticator(serviceauth.NewMemoryStore(key),
+		jwt.New(jwt.JWTConfig{SecretKey: []byte("secret"), TokenDuration: time.Hour}), "ledger")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := func(c *gin.Context) {
+		identity, err := GetServiceIdentity(c)
+		if err != nil {
+			t.Errorf("GetServiceIdentity: %v", err)
+			return
+		}
+		c.String(http.StatusOK, identity.ServiceID)
+	}
+	router := gin.New()
+	router.POST("/service/auth", ServiceAuthMiddleware(log, authenticator), handler)
+	service := router.Group("/service", ServiceTokenMiddleware(log, authenticator))
+	service.GET("/transactions", RequireServiceScopes("transaction:read"), handler)
+	service.POST("/transactions", RequireServiceScopes("transaction:write"), handler)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/service/auth", nil)
+	req.Header.Set(ServiceAuthHeader, plaintext)
+	router.ServeHTTP(w, req)
+	token := w.Header().Get(ServiceTokenHeader)
+	if w.Code != http.StatusOK || token == "" || w.Body.String() != "payment-service" {
+		t.Fatalf("auth: status = %d, token = %q, body = %s", w.Code, token, w.Body)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{"token with scope", http.MethodGet, ServiceTokenHeader, token, http.StatusOK},
+		{"token without scope", http.MethodPost, ServiceTokenHeader, token, http.StatusForbidden},
+		{"missing token", http.MethodGet, "", "", http.StatusUnauthorized},
+		{"tampered token", http.MethodGet, ServiceTokenHeader, token + "x", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/service/transactions", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/service/auth", nil)
+	req.Header.Set(ServiceAuthHeader, "pk_payment-service_wrong")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong key: status = %d, want 401", w.Code)
+	}
+	for _, entry := range recorder.All() {
+		for _, value := range entry.ContextMap() {
+			if s, ok := value.(string); ok && strings.Contains(s, "pk_payment-service_") {
+				t.Errorf("api key logged in %q", entry.Message)
+			}
+		}
+	}
+}
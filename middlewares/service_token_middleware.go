@@ -1,34 +1,59 @@
 package middlewares
 
 import (
-	"common/pkg/jwt"
-	"net/http"
+	"common/constants"
+	"common/pkg/logger"
+	"common/pkg/serviceauth"
+	"common/pkg/utils/response"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ServiceTokenMiddleware(jwt jwt.JWT) gin.HandlerFunc {
+// ServiceTokenMiddleware validates the service token issued by
+// ServiceAuthMiddleware, including its audience, and stores the calling
+// service's identity in the gin and request contexts
+func ServiceTokenMiddleware(logger logger.Logger, authenticator *serviceauth.Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString := c.GetHeader("X-Service-Token")
-		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing service token"})
-			c.Abort()
+		token := c.GetHeader(ServiceTokenHeader)
+		if token == "" {
+			response.HandleUnAuthorizedErrorWithAbort(c, "missing service token")
 			return
 		}
 
-		// Parse and validate JWT token
-		token, err := jwt.ValidateToken(tokenString)
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid service token"})
-			c.Abort()
+		identity, err := authenticator.ValidateToken(token)
+		if err != nil {
+			logger.Warn("invalid service token",
+				"error", err,
+				"ip", c.ClientIP(),
+				"request_id", requestID(c),
+				"action", constants.ActionAuthFailed,
+			)
+			response.HandleUnAuthorizedErrorWithAbort(c, "invalid service token")
 			return
 		}
 
-		// // Extract service ID from claims
-		// if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		// 	c.Set("service_id", claims["service_id"])
-		// }
+		setServiceIdentity(c, identity)
+		c.Next()
+	}
+}
 
+// RequireServiceScopes allows calling services granted every one of scopes,
+// directly or through a wildcard. It runs after ServiceAuthMiddleware or
+// ServiceTokenMiddleware
+func RequireServiceScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, err := GetServiceIdentity(c)
+		if err != nil {
+			response.HandleUnAuthorizedErrorWithAbort(c, "service authentication required")
+			return
+		}
+		for _, scope := range scopes {
+			if !identity.HasScope(scope) {
+				resp := response.Forbidden("service is not allowed to perform this action")
+				c.AbortWithStatusJSON(resp.Status, resp)
+				return
+			}
+		}
 		c.Next()
 	}
 }
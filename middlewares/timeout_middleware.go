@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"common/constants"
+	"common/pkg/logger"
+	"common/pkg/utils/response"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutMiddleware gives every request a context deadline. Handlers, database
+// calls and HTTP clients that honour the request context stop at the deadline;
+// when nothing was written by then the client receives a 503.
+//
+// The handler keeps running on the request goroutine, so a handler that
+// ignores its context is not interrupted. Every request that overran its
+// deadline is logged as a warning
+func TimeoutMiddleware(logger logger.Logger, timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+		logger.Warn("request timed out",
+			"request_id", requestID(c),
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"timeout", timeout.String(),
+			"action", constants.ActionRequestTimeout,
+		)
+		if !c.Writer.Written() {
+			resp := response.ServiceUnavailable("Request timed out")
+			c.AbortWithStatusJSON(resp.Status, resp)
+		}
+	}
+}
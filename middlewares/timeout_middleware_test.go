@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"common/constants"
+	"common/pkg/logger/loggertest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	log, recorder := loggertest.New()
+	router := gin.New()
+	router.Use(TimeoutMiddleware(log, 20*time.Millisecond))
+	router.GET("/slow", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(time.Second):
+			c.Status(http.StatusOK)
+		}
+	})
+	router.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("slow status = %d, want 503", w.Code)
+	}
+	recorder.AssertLogged(t, loggertest.WarnLevel, "request timed out", map[string]interface{}{
+		"path":   "/slow",
+		"action": constants.ActionRequestTimeout,
+	})
+	recorder.Reset()
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("fast status = %d, want 200", w.Code)
+	}
+	recorder.AssertNotLogged(t, loggertest.WarnLevel, "request timed out")
+}
@@ -1,24 +1,17 @@
 package middlewares
 
 import (
-	"context"
-
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
-var ContextKey string
-
 func TracingMiddleware(tracer trace.Tracer) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
-			requestID = uuid.New().String()
-		}
+		// 1. Request and correlation IDs, reused when RequestIDMiddleware ran first
+		ids := ensureCorrelation(c, nil)
 
 		// 2. Extract existing trace context from headers
 		propagator := otel.GetTextMapPropagator()
@@ -27,7 +20,8 @@ func TracingMiddleware(tracer trace.Tracer) gin.HandlerFunc {
 		// 3. Start the root span with extracted context
 		ctx, span := tracer.Start(ctx, "http.request",
 			trace.WithAttributes(
-				attribute.String("request_id", requestID),
+				attribute.String("request_id", ids.RequestID),
+				attribute.String("correlation_id", ids.CorrelationID),
 				attribute.String("path", c.FullPath()),
 				attribute.String("method", c.Request.Method),
 				attribute.String("user_agent", c.Request.UserAgent()),
@@ -40,17 +34,13 @@ func TracingMiddleware(tracer trace.Tracer) gin.HandlerFunc {
 		traceID := span.SpanContext().TraceID().String()
 		spanID := span.SpanContext().SpanID().String()
 
-		// 5. Store values in both Gin context and request context
-		c.Set("request_id", requestID)
+		// 5. Store values in the Gin context; the request context carries
+		// the span and the correlation IDs
 		c.Set("trace_id", traceID)
 		c.Set("span_id", spanID)
 
-		ctx = context.WithValue(ctx, "request_id", requestID)
-		ctx = context.WithValue(ctx, "trace_id", traceID)
-
 		// 6. Set response headers
 		c.Header("X-Trace-ID", traceID)
-		c.Header("X-Request-ID", requestID)
 
 		// 7. Update request context
 		c.Request = c.Request.WithContext(ctx)
@@ -0,0 +1,442 @@
+// Package audit records business actions as tamper-evident audit events
+package audit
+
+import (
+	"bytes"
+	"common/constants"
+	"common/dto"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMissingAction        = errors.New("audit event action is required")
+	ErrQueryNotSupported    = errors.New("audit sink does not support queries")
+	ErrChainBroken          = errors.New("audit hash chain broken")
+	ErrHashMismatch         = errors.New("audit event hash mismatch")
+	ErrAuditorClosed        = errors.New("auditor is closed")
+	ErrInvalidSinkArguments = errors.New("invalid audit sink arguments")
+	// ErrSecondarySink means a multi-sink stored the event in its primary
+	// sink but not in every other one
+	ErrSecondarySink = errors.New("audit event not written to every secondary sink")
+)
+
+const (
+	ActorTypeUser    = "user"
+	ActorTypeService = "service"
+	ActorTypeSystem  = "system"
+)
+
+// AuditEvent is a single audited business action
+type AuditEvent struct {
+	ID        string                 `json:"id"`
+	Timestamp time.Time              `json:"timestamp"`
+	Action    constants.LogAction    `json:"action"`
+	Actor     Actor                  `json:"actor"`
+	Resource  Resource               `json:"resource"`
+	Changes   []Change               `json:"changes,omitempty"`
+	IP        string                 `json:"ip,omitempty"`
+	Device    *dto.DeviceInfo        `json:"device,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+
+	// PrevHash and Hash link every event to the one recorded before it
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// Actor is whoever performed the action
+type Actor struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// Resource is the entity the action was performed on
+type Resource struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Change is a single field that differs between the before and after state
+type Change struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// Filter selects events in a query. Zero values are ignored; From is
+// inclusive and To is exclusive
+type Filter struct {
+	ActorID      string
+	Action       constants.LogAction
+	ResourceType string
+	ResourceID   string
+	From         time.Time
+	To           time.Time
+	Limit        int
+}
+
+// Sink durably stores audit events
+type Sink interface {
+	Write(ctx context.Context, event AuditEvent) error
+	Close() error
+}
+
+// Querier is implemented by sinks that can read events back, oldest first
+type Querier interface {
+	Query(ctx context.Context, filter Filter) ([]AuditEvent, error)
+}
+
+// HashSource is implemented by sinks that can report the hash of the last
+// stored event, so the chain continues across restarts
+type HashSource interface {
+	LastHash(ctx context.Context) (string, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, event AuditEvent) (AuditEvent, error)
+	Query(ctx context.Context, filter Filter) ([]AuditEvent, error)
+	Close() error
+}
+
+type auditor struct {
+	mu       sync.Mutex
+	sink     Sink
+	lastHash string
+	closed   bool
+}
+
+// New creates an Auditor that chains events onto whatever the sink already holds
+func New(ctx context.Context, sink Sink) (Auditor, error) {
+	if sink == nil {
+		return nil, fmt.Errorf("%w: sink is nil", ErrInvalidSinkArguments)
+	}
+
+	a := &auditor{sink: sink}
+
+	if source, ok := sink.(HashSource); ok {
+		lastHash, err := source.LastHash(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("reading last audit hash: %w", err)
+		}
+		a.lastHash = lastHash
+	}
+
+	return a, nil
+}
+
+// Record fills in the ID, timestamp and chain hashes and writes the event to
+// the sink. When only a secondary sink fails the event is stored and chained,
+// and it is returned together with an ErrSecondarySink error
+func (a *auditor) Record(ctx context.Context, event AuditEvent) (AuditEvent, error) {
+	if event.Action == "" {
+		return AuditEvent{}, ErrMissingAction
+	}
+
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	event.Timestamp = event.Timestamp.UTC()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return AuditEvent{}, ErrAuditorClosed
+	}
+
+	event.PrevHash = a.lastHash
+	hash, err := ComputeHash(event)
+	if err != nil {
+		return AuditEvent{}, err
+	}
+	event.Hash = hash
+
+	if err := a.sink.Write(ctx, event); err != nil {
+		if !errors.Is(err, ErrSecondarySink) {
+			return AuditEvent{}, fmt.Errorf("writing audit event: %w", err)
+		}
+		// the primary holds the event, so the next one must chain onto it
+		a.lastHash = event.Hash
+		return event, fmt.Errorf("writing audit event: %w", err)
+	}
+
+	a.lastHash = event.Hash
+	return event, nil
+}
+
+func (a *auditor) Query(ctx context.Context, filter Filter) ([]AuditEvent, error) {
+	querier, ok := a.sink.(Querier)
+	if !ok {
+		return nil, ErrQueryNotSupported
+	}
+	return querier.Query(ctx, filter)
+}
+
+func (a *auditor) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+	return a.sink.Close()
+}
+
+// ComputeHash returns the SHA-256 of the event's canonical JSON form with the
+// Hash field cleared. PrevHash is part of the input, which forms the chain.
+// Metadata and change values are canonicalised first, so an event hashes the
+// same after a round trip through a sink turns structs into maps with sorted
+// keys and integers into generic numbers
+func ComputeHash(event AuditEvent) (string, error) {
+	event.Hash = ""
+
+	if event.Metadata != nil {
+		metadata, err := canonicalJSON(event.Metadata)
+		if err != nil {
+			return "", fmt.Errorf("encoding audit metadata: %w", err)
+		}
+		event.Metadata = metadata.(map[string]interface{})
+	}
+	if event.Changes != nil {
+		changes := make([]Change, len(event.Changes))
+		for i, change := range event.Changes {
+			before, err := canonicalJSON(change.Before)
+			if err != nil {
+				return "", fmt.Errorf("encoding audit change %s: %w", change.Field, err)
+			}
+			after, err := canonicalJSON(change.After)
+			if err != nil {
+				return "", fmt.Errorf("encoding audit change %s: %w", change.Field, err)
+			}
+			changes[i] = Change{Field: change.Field, Before: before, After: after}
+		}
+		event.Changes = changes
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("encoding audit event: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON returns value as it reads back from JSON: maps instead of
+// structs and numbers in one textual form, so that 3, int64(3) and 3.0 are
+// the same value
+func canonicalJSON(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := unmarshalUseNumber(data, &decoded); err != nil {
+		return nil, err
+	}
+	return canonicalNumbers(decoded), nil
+}
+
+// canonicalNumbers formats every number as an integer when it is one and
+// as the shortest float otherwise, which also undoes the different spellings
+// Postgres JSONB and encoding/json use for the same number
+func canonicalNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = canonicalNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = canonicalNumbers(item)
+		}
+	case json.Number:
+		if n, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return json.Number(strconv.FormatInt(n, 10))
+		}
+		if f, err := strconv.ParseFloat(v.String(), 64); err == nil {
+			return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+		}
+	}
+	return value
+}
+
+// decodeEvent reads an event written by a sink, keeping numbers exact so
+// its hash can be verified
+func decodeEvent(data []byte) (AuditEvent, error) {
+	var event AuditEvent
+	err := unmarshalUseNumber(data, &event)
+	return event, err
+}
+
+func unmarshalUseNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// Verify checks that events, oldest first, form an unbroken chain and that
+// none of them was modified after it was recorded
+func Verify(events []AuditEvent) error {
+	for i, event := range events {
+		if i > 0 && event.PrevHash != events[i-1].Hash {
+			return fmt.Errorf("%w: event %s does not follow %s", ErrChainBroken, event.ID, events[i-1].ID)
+		}
+
+		hash, err := ComputeHash(event)
+		if err != nil {
+			return err
+		}
+		if hash != event.Hash {
+			return fmt.Errorf("%w: event %s", ErrHashMismatch, event.ID)
+		}
+	}
+	return nil
+}
+
+// Diff returns the top-level fields that differ between before and after,
+// using their JSON representation. Either side may be nil for creations and deletions
+func Diff(before, after interface{}) ([]Change, error) {
+	beforeFields, err := toFieldMap(before)
+	if err != nil {
+		return nil, fmt.Errorf("encoding before state: %w", err)
+	}
+	afterFields, err := toFieldMap(after)
+	if err != nil {
+		return nil, fmt.Errorf("encoding after state: %w", err)
+	}
+
+	keys := make(map[string]struct{}, len(beforeFields)+len(afterFields))
+	for key := range beforeFields {
+		keys[key] = struct{}{}
+	}
+	for key := range afterFields {
+		keys[key] = struct{}{}
+	}
+
+	var changes []Change
+	for key := range keys {
+		if !reflect.DeepEqual(beforeFields[key], afterFields[key]) {
+			changes = append(changes, Change{
+				Field:  key,
+				Before: beforeFields[key],
+				After:  afterFields[key],
+			})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return changes, nil
+}
+
+func toFieldMap(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// FromGinContext starts an event populated with the request details set by
+// the auth, tracing and device info middlewares
+func FromGinContext(c *gin.Context, action constants.LogAction, resource Resource) AuditEvent {
+	event := AuditEvent{
+		Action:    action,
+		Resource:  resource,
+		IP:        c.ClientIP(),
+		RequestID: c.GetString("request_id"),
+	}
+
+	if userID := c.GetString("user_id"); userID != "" {
+		event.Actor = Actor{ID: userID, Type: ActorTypeUser}
+	}
+
+	if value, ok := c.Get("device_info"); ok {
+		if device, ok := value.(*dto.DeviceInfo); ok {
+			event.Device = device
+		}
+	}
+
+	return event
+}
+
+// multiSink fans events out to several sinks. Chain head lookups and queries
+// are served by the primary sink
+type multiSink struct {
+	primary Sink
+	others  []Sink
+}
+
+// NewMultiSink writes every event to primary first and then to the others.
+// Failures of the others are reported as ErrSecondarySink
+func NewMultiSink(primary Sink, others ...Sink) Sink {
+	return &multiSink{primary: primary, others: others}
+}
+
+func (m *multiSink) Write(ctx context.Context, event AuditEvent) error {
+	if err := m.primary.Write(ctx, event); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, sink := range m.others {
+		if err := sink.Write(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrSecondarySink, errors.Join(errs...))
+	}
+	return nil
+}
+
+func (m *multiSink) LastHash(ctx context.Context) (string, error) {
+	if source, ok := m.primary.(HashSource); ok {
+		return source.LastHash(ctx)
+	}
+	return "", nil
+}
+
+func (m *multiSink) Query(ctx context.Context, filter Filter) ([]AuditEvent, error) {
+	if querier, ok := m.primary.(Querier); ok {
+		return querier.Query(ctx, filter)
+	}
+	return nil, ErrQueryNotSupported
+}
+
+func (m *multiSink) Close() error {
+	errs := []error{m.primary.Close()}
+	for _, sink := range m.others {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,240 @@
+package audit
+
+import (
+	"common/constants"
+	"common/pkg/rabbitmq"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+type user struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func TestAuditorChainsEventsAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	record := func(action constants.LogAction, before, after interface{}) {
+		t.Helper()
+
+		sink, err := NewFileSink(path)
+		if err != nil {
+			t.Fatalf("opening sink: %v", err)
+		}
+		auditor, err := New(ctx, sink)
+		if err != nil {
+			t.Fatalf("creating auditor: %v", err)
+		}
+		defer auditor.Close()
+
+		changes, err := Diff(before, after)
+		if err != nil {
+			t.Fatalf("diff: %v", err)
+		}
+
+		_, err = auditor.Record(ctx, AuditEvent{
+			Action:   action,
+			Actor:    Actor{ID: "admin-1", Type: ActorTypeUser},
+			Resource: Resource{Type: "user", ID: "user-1"},
+			Changes:  changes,
+		})
+		if err != nil {
+			t.Fatalf("recording event: %v", err)
+		}
+	}
+
+	record(constants.ActionUserCreated, nil, user{Name: "Jane", Email: "jane@example.com"})
+	record(constants.ActionUserUpdated, user{Name: "Jane", Email: "jane@example.com"}, user{Name: "Jane", Email: "jane@corp.com"})
+
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("opening sink: %v", err)
+	}
+	defer sink.Close()
+
+	events, err := sink.Query(ctx, Filter{ResourceID: "user-1"})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[1].PrevHash != events[0].Hash {
+		t.Errorf("second event does not chain onto the first")
+	}
+	if got := events[1].Changes; len(got) != 1 || got[0].Field != "email" {
+		t.Errorf("update changes = %+v, want a single email change", got)
+	}
+	if err := Verify(events); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+
+	updates, err := sink.Query(ctx, Filter{Action: constants.ActionUserUpdated})
+	if err != nil || len(updates) != 1 {
+		t.Errorf("action filter returned %d events (err %v), want 1", len(updates), err)
+	}
+
+	events[0].Actor.ID = "someone-else"
+	if err := Verify(events); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("verify tampered event = %v, want ErrHashMismatch", err)
+	}
+}
+
+func TestVerifyAfterSinkRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	sink, err := NewFileSink(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("opening sink: %v", err)
+	}
+	auditor, err := New(ctx, sink)
+	if err != nil {
+		t.Fatalf("creating auditor: %v", err)
+	}
+	defer auditor.Close()
+
+	type order struct {
+		Total    int64   `json:"total"`
+		Currency string  `json:"currency"`
+		Ratio    float64 `json:"ratio"`
+	}
+	recorded, err := auditor.Record(ctx, AuditEvent{
+		Action:   constants.ActionUserUpdated,
+		Actor:    Actor{ID: "admin-1", Type: ActorTypeUser},
+		Resource: Resource{Type: "order", ID: "order-1"},
+		Changes: []Change{
+			{Field: "order", Before: order{Total: 12345678901234567, Currency: "EUR", Ratio: 0.25}, After: order{Total: 1, Currency: "USD"}},
+			{Field: "count", Before: int64(3), After: 4},
+		},
+		Metadata: map[string]interface{}{
+			"order":  order{Total: 9007199254740993, Currency: "EUR"},
+			"big":    1e21,
+			"labels": []string{"b", "a"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("recording event: %v", err)
+	}
+
+	events, err := sink.Query(ctx, Filter{})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("query returned %d events (err %v), want 1", len(events), err)
+	}
+	if err := Verify(events); err != nil {
+		t.Errorf("verify after file round trip: %v", err)
+	}
+
+	// JSONB stores objects with sorted keys and spells numbers its own way
+	payload, err := json.Marshal(recorded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var generic map[string]interface{}
+	if err := unmarshalUseNumber(payload, &generic); err != nil {
+		t.Fatal(err)
+	}
+	generic["metadata"].(map[string]interface{})["big"] = json.Number("1000000000000000000000")
+	jsonb, err := json.Marshal(generic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := decodeEvent(jsonb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify([]AuditEvent{event}); err != nil {
+		t.Errorf("verify after JSONB-style round trip: %v", err)
+	}
+}
+
+type failingSink struct{}
+
+func (failingSink) Write(ctx context.Context, event AuditEvent) error {
+	return errors.New("siem unavailable")
+}
+
+func (failingSink) Close() error { return nil }
+
+func TestSecondarySinkFailureKeepsChain(t *testing.T) {
+	ctx := context.Background()
+	primary, err := NewFileSink(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("opening sink: %v", err)
+	}
+	auditor, err := New(ctx, NewMultiSink(primary, failingSink{}))
+	if err != nil {
+		t.Fatalf("creating auditor: %v", err)
+	}
+	defer auditor.Close()
+
+	for i := 0; i < 2; i++ {
+		event, err := auditor.Record(ctx, AuditEvent{Action: constants.ActionUserUpdated, Resource: Resource{Type: "user", ID: "user-1"}})
+		if !errors.Is(err, ErrSecondarySink) {
+			t.Fatalf("record err = %v, want ErrSecondarySink", err)
+		}
+		if event.Hash == "" {
+			t.Error("stored event not returned with the secondary error")
+		}
+	}
+
+	events, err := auditor.Query(ctx, Filter{})
+	if err != nil || len(events) != 2 {
+		t.Fatalf("query returned %d events (err %v), want 2", len(events), err)
+	}
+	if err := Verify(events); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+}
+
+type recordingMQ struct {
+	rabbitmq.RabbitMQService
+	published []string
+}
+
+func (m *recordingMQ) Publish(queueName string, message rabbitmq.Message) error {
+	m.published = append(m.published, "publish:"+queueName)
+	return nil
+}
+
+type contextMQ struct {
+	recordingMQ
+}
+
+func (m *contextMQ) PublishContext(ctx context.Context, queueName string, message rabbitmq.Message) error {
+	m.published = append(m.published, "context:"+queueName)
+	return nil
+}
+
+func TestRabbitMQSinkPublishesWithContextWhenSupported(t *testing.T) {
+	plain := &recordingMQ{}
+	withContext := &contextMQ{}
+
+	tests := []struct {
+		name string
+		mq   rabbitmq.RabbitMQService
+		got  *[]string
+		want string
+	}{
+		{"plain service", plain, &plain.published, "publish:audit"},
+		{"context publisher", withContext, &withContext.published, "context:audit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, err := NewRabbitMQSink(tt.mq, "audit")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := sink.Write(context.Background(), AuditEvent{}); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if len(*tt.got) != 1 || (*tt.got)[0] != tt.want {
+				t.Errorf("published = %v, want [%s]", *tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// fileSink appends events to a JSON lines file
+type fileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileSink opens (or creates) a JSON lines audit file for appending
+func NewFileSink(path string) (*fileSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%w: file path is empty", ErrInvalidSinkArguments)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit file: %w", err)
+	}
+
+	return &fileSink{path: path, file: file}, nil
+}
+
+func (s *fileSink) Write(ctx context.Context, event AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding audit event: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(line); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *fileSink) LastHash(ctx context.Context) (string, error) {
+	var last string
+	err := s.scan(func(event AuditEvent) bool {
+		last = event.Hash
+		return true
+	})
+	return last, err
+}
+
+func (s *fileSink) Query(ctx context.Context, filter Filter) ([]AuditEvent, error) {
+	var events []AuditEvent
+	err := s.scan(func(event AuditEvent) bool {
+		if filter.matches(event) {
+			events = append(events, event)
+		}
+		return filter.Limit <= 0 || len(events) < filter.Limit
+	})
+	return events, err
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// scan decodes every event in the file in order until fn returns false
+func (s *fileSink) scan(fn func(AuditEvent) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("opening audit file: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			event, decodeErr := decodeEvent(line)
+			if decodeErr != nil {
+				return fmt.Errorf("decoding audit file: %w", decodeErr)
+			}
+			if !fn(event) {
+				return nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading audit file: %w", err)
+		}
+	}
+}
+
+// matches reports whether the event passes every non-zero filter field
+func (f Filter) matches(event AuditEvent) bool {
+	switch {
+	case f.ActorID != "" && event.Actor.ID != f.ActorID:
+		return false
+	case f.Action != "" && event.Action != f.Action:
+		return false
+	case f.ResourceType != "" && event.Resource.Type != f.ResourceType:
+		return false
+	case f.ResourceID != "" && event.Resource.ID != f.ResourceID:
+		return false
+	case !f.From.IsZero() && event.Timestamp.Before(f.From):
+		return false
+	case !f.To.IsZero() && !event.Timestamp.Before(f.To):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package audit
+
+import (
+	database "common/pkg/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const DefaultTableName = "audit_events"
+
+var tableNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// postgresSink stores events in a Postgres table. The full event is kept as
+// JSONB next to indexed columns used for filtering
+type postgresSink struct {
+	db    *database.DB
+	table string
+}
+
+// NewPostgresSink creates a sink backed by the given table, creating it if needed.
+// The chain head is read once at startup, so every writer to a table should
+// share one Auditor (or one table per instance) to keep the chain linear
+func NewPostgresSink(ctx context.Context, db *database.DB, table string) (*postgresSink, error) {
+	if db == nil {
+		return nil, fmt.Errorf("%w: db is nil", ErrInvalidSinkArguments)
+	}
+	if table == "" {
+		table = DefaultTableName
+	}
+	if !tableNamePattern.MatchString(table) {
+		return nil, fmt.Errorf("%w: invalid table name %q", ErrInvalidSinkArguments, table)
+	}
+
+	s := &postgresSink{db: db, table: table}
+	if err := s.ensureSchema(ctx); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *postgresSink) ensureSchema(ctx context.Context) error {
+	index := strings.ReplaceAll(s.table, ".", "_")
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			seq           BIGSERIAL PRIMARY KEY,
+			id            UUID NOT NULL UNIQUE,
+			occurred_at   TIMESTAMPTZ NOT NULL,
+			action        TEXT NOT NULL,
+			actor_id      TEXT NOT NULL DEFAULT '',
+			actor_type    TEXT NOT NULL DEFAULT '',
+			resource_type TEXT NOT NULL DEFAULT '',
+			resource_id   TEXT NOT NULL DEFAULT '',
+			payload       JSONB NOT NULL,
+			prev_hash     TEXT NOT NULL,
+			hash          TEXT NOT NULL
+		)`, s.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_actor_idx ON %s (actor_id, occurred_at)`, index, s.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_resource_idx ON %s (resource_type, resource_id, occurred_at)`, index, s.table),
+	}
+
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("creating audit table: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *postgresSink) Write(ctx context.Context, event AuditEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding audit event: %w", err)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s
+		(id, occurred_at, action, actor_id, actor_type, resource_type, resource_id, payload, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`, s.table)
+
+	_, err = s.db.ExecContext(ctx, query,
+		event.ID, event.Timestamp, string(event.Action),
+		event.Actor.ID, event.Actor.Type,
+		event.Resource.Type, event.Resource.ID,
+		payload, event.PrevHash, event.Hash,
+	)
+	return err
+}
+
+func (s *postgresSink) LastHash(ctx context.Context) (string, error) {
+	var hash string
+	query := fmt.Sprintf(`SELECT hash FROM %s ORDER BY seq DESC LIMIT 1`, s.table)
+
+	err := s.db.QueryRowContext(ctx, query).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return hash, err
+}
+
+func (s *postgresSink) Query(ctx context.Context, filter Filter) ([]AuditEvent, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	where := func(column string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s $%d", column, len(args)))
+	}
+
+	if filter.ActorID != "" {
+		where("actor_id =", filter.ActorID)
+	}
+	if filter.Action != "" {
+		where("action =", string(filter.Action))
+	}
+	if filter.ResourceType != "" {
+		where("resource_type =", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		where("resource_id =", filter.ResourceID)
+	}
+	if !filter.From.IsZero() {
+		where("occurred_at >=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("occurred_at <", filter.To)
+	}
+
+	query := fmt.Sprintf("SELECT payload FROM %s", s.table)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY seq ASC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []AuditEvent
+	for rows.Next() {
+		var payload []byte
+		if err := rows.Scan(&payload); err != nil {
+			return nil, fmt.Errorf("scanning audit event: %w", err)
+		}
+
+		event, err := decodeEvent(payload)
+		if err != nil {
+			return nil, fmt.Errorf("decoding audit event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// Close is a no-op; the database pool is owned by the caller
+func (s *postgresSink) Close() error {
+	return nil
+}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"common/pkg/rabbitmq"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// rabbitMQSink publishes each event as JSON to a durable queue, for consumers
+// that forward audit events to long-term storage. Correlation headers are
+// added when the service implements rabbitmq.ContextPublisher
+type rabbitMQSink struct {
+	mq    rabbitmq.RabbitMQService
+	queue string
+}
+
+// NewRabbitMQSink creates a sink that publishes to the given queue
+func NewRabbitMQSink(mq rabbitmq.RabbitMQService, queue string) (*rabbitMQSink, error) {
+	if mq == nil || queue == "" {
+		return nil, fmt.Errorf("%w: rabbitmq service and queue are required", ErrInvalidSinkArguments)
+	}
+	return &rabbitMQSink{mq: mq, queue: queue}, nil
+}
+
+func (s *rabbitMQSink) Write(ctx context.Context, event AuditEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding audit event: %w", err)
+	}
+	message := rabbitmq.Message{Body: string(body)}
+	if publisher, ok := s.mq.(rabbitmq.ContextPublisher); ok {
+		return publisher.PublishContext(ctx, s.queue, message)
+	}
+	return s.mq.Publish(s.queue, message)
+}
+
+// Close is a no-op; the connection is owned by the caller
+func (s *rabbitMQSink) Close() error {
+	return nil
+}
@@ -0,0 +1,166 @@
+// Package auth carries the authenticated caller through context.Context, so
+// service and repository layers can read it without depending on gin
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ServiceTokenType is the token_type claim of service tokens issued by
+// pkg/serviceauth, which must never authenticate a user
+const ServiceTokenType = "service"
+
+var (
+	ErrNoPrincipal   = errors.New("auth: no principal in context")
+	ErrMissingClaim  = errors.New("auth: required claim missing")
+	ErrInvalidClaims = errors.New("auth: invalid claims")
+	ErrServiceToken  = errors.New("auth: service token used as a user token")
+)
+
+// Principal is the authenticated caller described by a token
+type Principal struct {
+	// Subject identifies the caller, from the user_id claim or "sub"
+	Subject   string
+	TenantID  string
+	Roles     []string
+	Scopes    []string
+	SessionID string
+	// ExpiresAt is zero when the token has no exp claim
+	ExpiresAt time.Time
+	// Claims holds every claim of the token, including the ones above
+	Claims map[string]interface{}
+}
+
+// FromClaims builds a principal from validated token claims. The subject is
+// required; every other claim is optional. Service tokens are rejected with
+// ErrServiceToken even when signed with the same secret as user tokens
+func FromClaims(claims map[string]interface{}) (*Principal, error) {
+	if claims["token_type"] == ServiceTokenType {
+		return nil, ErrServiceToken
+	}
+
+	p := &Principal{Claims: claims}
+
+	p.Subject = firstString(claims, "user_id", "sub")
+	if p.Subject == "" {
+		return nil, fmt.Errorf("%w: user_id or sub", ErrMissingClaim)
+	}
+	p.TenantID = firstString(claims, "tenant_id", "tid")
+	p.SessionID = firstString(claims, "session_id", "sid")
+
+	p.Roles = ClaimStrings(claims["roles"])
+	if role, ok := claims["role"].(string); ok && role != "" {
+		p.Roles = append(p.Roles, role)
+	}
+	p.Scopes = ClaimStrings(claims["scope"])
+	if len(p.Scopes) == 0 {
+		p.Scopes = ClaimStrings(claims["scopes"])
+	}
+
+	switch exp := claims["exp"].(type) {
+	case nil:
+	case float64:
+		p.ExpiresAt = time.Unix(int64(exp), 0)
+	case int64:
+		p.ExpiresAt = time.Unix(exp, 0)
+	case int:
+		p.ExpiresAt = time.Unix(int64(exp), 0)
+	default:
+		return nil, fmt.Errorf("%w: exp is %T", ErrInvalidClaims, exp)
+	}
+	return p, nil
+}
+
+// HasRole reports whether the token grants role directly; inherited roles
+// are resolved by pkg/authz
+func (p *Principal) HasRole(role string) bool {
+	return contains(p.Roles, role)
+}
+
+// HasScope reports whether the token grants scope exactly
+func (p *Principal) HasScope(scope string) bool {
+	return contains(p.Scopes, scope)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying p
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal stored by NewContext, or ErrNoPrincipal
+func FromContext(ctx context.Context) (*Principal, error) {
+	if ctx == nil {
+		return nil, ErrNoPrincipal
+	}
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	if !ok || p == nil {
+		return nil, ErrNoPrincipal
+	}
+	return p, nil
+}
+
+// SubjectFromContext returns the caller's subject, or ErrNoPrincipal
+func SubjectFromContext(ctx context.Context) (string, error) {
+	p, err := FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return p.Subject, nil
+}
+
+// TenantFromContext returns the caller's tenant, or ErrMissingClaim when the
+// token has none
+func TenantFromContext(ctx context.Context) (string, error) {
+	p, err := FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	if p.TenantID == "" {
+		return "", fmt.Errorf("%w: tenant_id", ErrMissingClaim)
+	}
+	return p.TenantID, nil
+}
+
+func firstString(claims map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := claims[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// ClaimStrings reads a claim holding a list, or a space-separated string as
+// in the OAuth 2.0 scope claim
+func ClaimStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
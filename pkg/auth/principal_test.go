@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFromClaims(t *testing.T) {
+	exp := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		claims  map[string]interface{}
+		want    Principal
+		wantErr error
+	}{
+		{
+			name: "all claims",
+			claims: map[string]interface{}{
+				"user_id": "u1", "tenant_id": "t1", "sid": "s1",
+				"roles": []interface{}{"admin", "user"}, "scope": "orders:read orders:write",
+				"exp": float64(exp.Unix()),
+			},
+			want: Principal{
+				Subject: "u1", TenantID: "t1", SessionID: "s1",
+				Roles: []string{"admin", "user"}, Scopes: []string{"orders:read", "orders:write"},
+				ExpiresAt: exp,
+			},
+		},
+		{
+			name:   "standard sub and single role",
+			claims: map[string]interface{}{"sub": "u2", "role": "user", "scopes": []interface{}{"profile"}},
+			want:   Principal{Subject: "u2", Roles: []string{"user"}, Scopes: []string{"profile"}},
+		},
+		{
+			name:    "missing subject",
+			claims:  map[string]interface{}{"role": "user"},
+			wantErr: ErrMissingClaim,
+		},
+		{
+			name:    "service token",
+			claims:  map[string]interface{}{"sub": "billing", "token_type": ServiceTokenType},
+			wantErr: ErrServiceToken,
+		},
+		{
+			name:    "malformed exp",
+			claims:  map[string]interface{}{"sub": "u3", "exp": "tomorrow"},
+			wantErr: ErrInvalidClaims,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromClaims(tt.claims)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			tt.want.Claims = tt.claims
+			if !got.ExpiresAt.Equal(tt.want.ExpiresAt) {
+				t.Errorf("expires at = %v, want %v", got.ExpiresAt, tt.want.ExpiresAt)
+			}
+			got.ExpiresAt, tt.want.ExpiresAt = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("principal = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextAccessors(t *testing.T) {
+	if _, err := FromContext(context.Background()); !errors.Is(err, ErrNoPrincipal) {
+		t.Errorf("empty context: err = %v, want ErrNoPrincipal", err)
+	}
+	if _, err := SubjectFromContext(context.Background()); !errors.Is(err, ErrNoPrincipal) {
+		t.Errorf("empty context: err = %v, want ErrNoPrincipal", err)
+	}
+
+	ctx := NewContext(context.Background(), &Principal{Subject: "u1"})
+	if subject, err := SubjectFromContext(ctx); err != nil || subject != "u1" {
+		t.Errorf("subject = %q, %v", subject, err)
+	}
+	if _, err := TenantFromContext(ctx); !errors.Is(err, ErrMissingClaim) {
+		t.Errorf("tenant: err = %v, want ErrMissingClaim", err)
+	}
+}
@@ -0,0 +1,162 @@
+// Package authz decides whether an authenticated subject may perform a
+// request. Subjects are built from an auth.Principal; roles may inherit
+// other roles and grant permissions, and permissions support wildcards such
+// as "orders:*"
+package authz
+
+import (
+	"common/pkg/auth"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultRolesClaim  = "roles"
+	DefaultScopesClaim = "scope"
+)
+
+// RoleHierarchy maps a role to the roles it inherits, so that with
+// {"admin": {"manager"}, "manager": {"user"}} an admin also holds manager
+// and user
+type RoleHierarchy map[string][]string
+
+// Expand returns roles together with every role they inherit
+func (h RoleHierarchy) Expand(roles ...string) []string {
+	seen := make(map[string]bool)
+	var visit func(role string)
+	visit = func(role string) {
+		if seen[role] {
+			return
+		}
+		seen[role] = true
+		for _, inherited := range h[role] {
+			visit(inherited)
+		}
+	}
+	for _, role := range roles {
+		visit(role)
+	}
+
+	expanded := make([]string, 0, len(seen))
+	for role := range seen {
+		expanded = append(expanded, role)
+	}
+	sort.Strings(expanded)
+	return expanded
+}
+
+// Model describes how a principal turns into a Subject
+type Model struct {
+	Hierarchy RoleHierarchy `json:"hierarchy" yaml:"hierarchy"`
+	// RolePermissions grants permissions to holders of a role, including
+	// holders that inherit it
+	RolePermissions map[string][]string `json:"role_permissions" yaml:"role_permissions"`
+	// RolesClaim holds a list or a single role. When empty the principal's
+	// roles are used, read from DefaultRolesClaim and a single-valued "role"
+	// claim; a custom claim is read alongside "role"
+	RolesClaim string `json:"roles_claim" yaml:"roles_claim"`
+	// ScopesClaim holds a space-separated string, as in OAuth 2.0, or a
+	// list. When empty the principal's scopes are used, read from
+	// DefaultScopesClaim or else "scopes"
+	ScopesClaim string `json:"scopes_claim" yaml:"scopes_claim"`
+}
+
+// Subject is the authenticated caller. Roles include inherited ones and
+// Permissions include the token scopes and those granted by roles
+type Subject struct {
+	UserID      string
+	Roles       []string
+	Permissions []string
+	Claims      map[string]interface{}
+}
+
+// Subject builds the subject of an authenticated principal
+func (m Model) Subject(p *auth.Principal) Subject {
+	roles := p.Roles
+	if m.RolesClaim != "" && m.RolesClaim != DefaultRolesClaim {
+		roles = auth.ClaimStrings(p.Claims[m.RolesClaim])
+		if role, ok := p.Claims["role"].(string); ok && role != "" {
+			roles = append(roles, role)
+		}
+	}
+	scopes := p.Scopes
+	if m.ScopesClaim != "" && m.ScopesClaim != DefaultScopesClaim {
+		scopes = auth.ClaimStrings(p.Claims[m.ScopesClaim])
+	}
+
+	s := Subject{UserID: p.Subject, Claims: p.Claims, Roles: m.Hierarchy.Expand(roles...)}
+
+	permissions := make(map[string]bool)
+	for _, scope := range scopes {
+		permissions[scope] = true
+	}
+	for _, role := range s.Roles {
+		for _, permission := range m.RolePermissions[role] {
+			permissions[permission] = true
+		}
+	}
+	for permission := range permissions {
+		s.Permissions = append(s.Permissions, permission)
+	}
+	sort.Strings(s.Permissions)
+	return s
+}
+
+// HasRole reports whether the subject holds role directly or by inheritance
+func (s Subject) HasRole(role string) bool {
+	for _, r := range s.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyRole reports whether the subject holds at least one of roles
+func (s Subject) HasAnyRole(roles ...string) bool {
+	for _, role := range roles {
+		if s.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether any granted permission matches required
+func (s Subject) HasPermission(required string) bool {
+	for _, granted := range s.Permissions {
+		if MatchPermission(granted, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermissions reports whether every one of required is granted
+func (s Subject) HasPermissions(required ...string) bool {
+	for _, permission := range required {
+		if !s.HasPermission(permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchPermission reports whether a granted permission covers a required
+// one. Permissions are colon-separated segments; "*" matches any one
+// segment, and a trailing "*" matches any remainder, so "orders:*" covers
+// "orders:read" and "orders:items:write", and "*" covers everything
+func MatchPermission(granted, required string) bool {
+	grantedParts := strings.Split(granted, ":")
+	requiredParts := strings.Split(required, ":")
+
+	for i, part := range grantedParts {
+		if part == "*" && i == len(grantedParts)-1 {
+			return len(requiredParts) > i
+		}
+		if i >= len(requiredParts) || (part != "*" && part != requiredParts[i]) {
+			return false
+		}
+	}
+	return len(grantedParts) == len(requiredParts)
+}
@@ -0,0 +1,114 @@
+package authz
+
+import (
+	"common/pkg/auth"
+	"reflect"
+	"testing"
+)
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		granted, required string
+		want              bool
+	}{
+		{"orders:read", "orders:read", true},
+		{"orders:read", "orders:write", false},
+		{"orders:*", "orders:read", true},
+		{"orders:*", "orders:items:write", true},
+		{"orders:*", "orders", false},
+		{"orders:*", "invoices:read", false},
+		{"*", "anything:at:all", true},
+		{"orders:*:read", "orders:items:read", true},
+		{"orders:*:read", "orders:items:write", false},
+		{"orders", "orders:read", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPermission(tt.granted, tt.required); got != tt.want {
+			t.Errorf("MatchPermission(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestRoleHierarchyExpand(t *testing.T) {
+	h := RoleHierarchy{
+		"admin":   {"manager"},
+		"manager": {"user"},
+		// cycles must terminate
+		"user":  {"admin"},
+		"guest": nil,
+	}
+	if got := h.Expand("manager"); !reflect.DeepEqual(got, []string{"admin", "manager", "user"}) {
+		t.Errorf("Expand(manager) = %v", got)
+	}
+	if got := h.Expand("guest", "auditor"); !reflect.DeepEqual(got, []string{"auditor", "guest"}) {
+		t.Errorf("Expand(guest, auditor) = %v", got)
+	}
+}
+
+func TestModelSubject(t *testing.T) {
+	model := Model{
+		Hierarchy:       RoleHierarchy{"admin": {"user"}},
+		RolePermissions: map[string][]string{"user": {"profile:read"}, "admin": {"orders:*"}},
+	}
+
+	tests := []struct {
+		name            string
+		rolesClaim      string
+		scopesClaim     string
+		claims          map[string]interface{}
+		wantRoles       []string
+		wantPermissions []string
+	}{
+		{
+			name:            "roles list and scope string",
+			claims:          map[string]interface{}{"user_id": "u1", "roles": []interface{}{"admin"}, "scope": "invoices:read reports:read"},
+			wantRoles:       []string{"admin", "user"},
+			wantPermissions: []string{"invoices:read", "orders:*", "profile:read", "reports:read"},
+		},
+		{
+			name:            "single role claim",
+			claims:          map[string]interface{}{"user_id": "u3", "role": "user"},
+			wantRoles:       []string{"user"},
+			wantPermissions: []string{"profile:read"},
+		},
+		{
+			name:            "scopes list",
+			claims:          map[string]interface{}{"sub": "billing", "scopes": []interface{}{"invoices:read"}},
+			wantRoles:       []string{},
+			wantPermissions: []string{"invoices:read"},
+		},
+		{
+			name:            "custom claims",
+			rolesClaim:      "groups",
+			scopesClaim:     "permissions",
+			claims:          map[string]interface{}{"sub": "u4", "groups": "admin", "roles": []interface{}{"ignored"}, "permissions": []interface{}{"reports:read"}},
+			wantRoles:       []string{"admin", "user"},
+			wantPermissions: []string{"orders:*", "profile:read", "reports:read"},
+		},
+		{
+			name:      "no roles",
+			claims:    map[string]interface{}{"user_id": "u2"},
+			wantRoles: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := auth.FromClaims(tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := model
+			m.RolesClaim, m.ScopesClaim = tt.rolesClaim, tt.scopesClaim
+			s := m.Subject(principal)
+			if s.UserID != principal.Subject {
+				t.Errorf("user id = %q, want %q", s.UserID, principal.Subject)
+			}
+			if !reflect.DeepEqual(s.Roles, tt.wantRoles) {
+				t.Errorf("roles = %v, want %v", s.Roles, tt.wantRoles)
+			}
+			if !reflect.DeepEqual(s.Permissions, tt.wantPermissions) {
+				t.Errorf("permissions = %v, want %v", s.Permissions, tt.wantPermissions)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Request is what a Policy decides on
+type Request struct {
+	Subject Subject
+	Method  string
+	// Route is the matched route template, such as "/orders/:id"
+	Route string
+	Path  string
+}
+
+type Decision struct {
+	Allowed bool
+	// Reason explains a denial; it is logged, not sent to the client
+	Reason string
+}
+
+func Allow() Decision             { return Decision{Allowed: true} }
+func Deny(reason string) Decision { return Decision{Reason: reason} }
+func (d Decision) String() string {
+	if d.Allowed {
+		return "allow"
+	}
+	return "deny: " + d.Reason
+}
+
+// Policy is the pluggable decision point. Implementations may evaluate
+// embedded rules, as RuleEngine does, or call out to an external engine;
+// an error is treated as a failure to decide rather than a denial
+type Policy interface {
+	Evaluate(ctx context.Context, req Request) (Decision, error)
+}
+
+// PolicyFunc adapts a function to Policy
+type PolicyFunc func(ctx context.Context, req Request) (Decision, error)
+
+func (f PolicyFunc) Evaluate(ctx context.Context, req Request) (Decision, error) {
+	return f(ctx, req)
+}
+
+// AnyRole allows subjects holding at least one of roles
+func AnyRole(roles ...string) Policy {
+	return PolicyFunc(func(ctx context.Context, req Request) (Decision, error) {
+		if req.Subject.HasAnyRole(roles...) {
+			return Allow(), nil
+		}
+		return Deny(fmt.Sprintf("requires one of roles %v", roles)), nil
+	})
+}
+
+// AllPermissions allows subjects granted every one of permissions
+func AllPermissions(permissions ...string) Policy {
+	return PolicyFunc(func(ctx context.Context, req Request) (Decision, error) {
+		for _, permission := range permissions {
+			if !req.Subject.HasPermission(permission) {
+				return Deny(fmt.Sprintf("missing permission %q", permission)), nil
+			}
+		}
+		return Allow(), nil
+	})
+}
+
+// AllOf allows a request only when every policy allows it
+func AllOf(policies ...Policy) Policy {
+	return PolicyFunc(func(ctx context.Context, req Request) (Decision, error) {
+		for _, policy := range policies {
+			decision, err := policy.Evaluate(ctx, req)
+			if err != nil || !decision.Allowed {
+				return decision, err
+			}
+		}
+		return Allow(), nil
+	})
+}
+
+// AnyOf allows a request when at least one policy allows it
+func AnyOf(policies ...Policy) Policy {
+	return PolicyFunc(func(ctx context.Context, req Request) (Decision, error) {
+		var reasons []string
+		for _, policy := range policies {
+			decision, err := policy.Evaluate(ctx, req)
+			if err != nil {
+				return Decision{}, err
+			}
+			if decision.Allowed {
+				return decision, nil
+			}
+			reasons = append(reasons, decision.Reason)
+		}
+		return Deny(strings.Join(reasons, "; ")), nil
+	})
+}
+
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
+
+// Rule applies to requests matching its Methods and Routes, and to subjects
+// holding one of its Roles and all of its Permissions. Empty lists match
+// everything, but an allow rule needs at least one condition so that an
+// empty rule cannot open every route. Routes are route templates; a trailing
+// "*" matches a prefix
+type Rule struct {
+	Name        string   `json:"name" yaml:"name"`
+	Effect      Effect   `json:"effect" yaml:"effect"`
+	Methods     []string `json:"methods" yaml:"methods"`
+	Routes      []string `json:"routes" yaml:"routes"`
+	Roles       []string `json:"roles" yaml:"roles"`
+	Permissions []string `json:"permissions" yaml:"permissions"`
+}
+
+// RuleEngine is an embedded Policy evaluating a list of rules. A matching
+// deny rule wins over any allow rule, and requests no rule allows are denied
+type RuleEngine struct {
+	rules []Rule
+}
+
+func NewRuleEngine(rules ...Rule) (*RuleEngine, error) {
+	for i, rule := range rules {
+		switch rule.Effect {
+		case EffectAllow, EffectDeny:
+		default:
+			return nil, fmt.Errorf("rule %d (%s): unknown effect %q", i, rule.Name, rule.Effect)
+		}
+		if len(rule.Methods) == 0 && len(rule.Routes) == 0 && len(rule.Roles) == 0 && len(rule.Permissions) == 0 && rule.Effect == EffectAllow {
+			return nil, fmt.Errorf("rule %d (%s): an allow rule must have at least one condition", i, rule.Name)
+		}
+	}
+	return &RuleEngine{rules: rules}, nil
+}
+
+func (e *RuleEngine) Evaluate(ctx context.Context, req Request) (Decision, error) {
+	if e == nil {
+		return Decision{}, errors.New("authz: nil rule engine")
+	}
+
+	allowed := false
+	for _, rule := range e.rules {
+		if !rule.matches(req) {
+			continue
+		}
+		if rule.Effect == EffectDeny {
+			return Deny(fmt.Sprintf("denied by rule %q", rule.Name)), nil
+		}
+		allowed = true
+	}
+	if allowed {
+		return Allow(), nil
+	}
+	return Deny("no rule allows the request"), nil
+}
+
+func (r Rule) matches(req Request) bool {
+	if len(r.Methods) > 0 && !containsFold(r.Methods, req.Method) {
+		return false
+	}
+	if len(r.Routes) > 0 && !matchRoute(r.Routes, req.Route) {
+		return false
+	}
+	if len(r.Roles) > 0 && !req.Subject.HasAnyRole(r.Roles...) {
+		return false
+	}
+	return req.Subject.HasPermissions(r.Permissions...)
+}
+
+func matchRoute(patterns []string, route string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(route, prefix) {
+				return true
+			}
+		} else if pattern == route {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
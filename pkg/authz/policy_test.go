@@ -0,0 +1,89 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRuleEngine(t *testing.T) {
+	engine, err := NewRuleEngine(
+		Rule{Name: "read orders", Effect: EffectAllow, Methods: []string{"GET"}, Routes: []string{"/orders*"}, Permissions: []string{"orders:read"}},
+		Rule{Name: "admins", Effect: EffectAllow, Roles: []string{"admin"}},
+		Rule{Name: "no deletes", Effect: EffectDeny, Methods: []string{"DELETE"}, Routes: []string{"/orders/:id"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := Subject{Permissions: []string{"orders:*"}}
+	admin := Subject{Roles: []string{"admin"}}
+
+	tests := []struct {
+		name    string
+		req     Request
+		allowed bool
+	}{
+		{"reader lists orders", Request{Subject: reader, Method: "GET", Route: "/orders"}, true},
+		{"reader gets an order", Request{Subject: reader, Method: "get", Route: "/orders/:id"}, true},
+		{"reader cannot write", Request{Subject: reader, Method: "POST", Route: "/orders"}, false},
+		{"reader outside route", Request{Subject: reader, Method: "GET", Route: "/invoices"}, false},
+		{"admin writes", Request{Subject: admin, Method: "POST", Route: "/orders"}, true},
+		{"deny wins over admin", Request{Subject: admin, Method: "DELETE", Route: "/orders/:id"}, false},
+		{"anonymous", Request{Method: "GET", Route: "/orders"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := engine.Evaluate(context.Background(), tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision.Allowed != tt.allowed {
+				t.Errorf("decision = %v, want allowed=%v", decision, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestNewRuleEngineValidation(t *testing.T) {
+	if _, err := NewRuleEngine(Rule{Name: "typo", Effect: "alow", Roles: []string{"admin"}}); err == nil {
+		t.Error("unknown effect accepted")
+	}
+	if _, err := NewRuleEngine(Rule{Name: "open", Effect: EffectAllow}); err == nil {
+		t.Error("unconditional allow rule accepted")
+	}
+	if _, err := NewRuleEngine(Rule{Name: "lockdown", Effect: EffectDeny}); err != nil {
+		t.Errorf("unconditional deny rule rejected: %v", err)
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	subject := Subject{Roles: []string{"user"}, Permissions: []string{"orders:read"}}
+	failing := PolicyFunc(func(ctx context.Context, req Request) (Decision, error) {
+		return Decision{}, errors.New("engine unavailable")
+	})
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		allowed bool
+		wantErr bool
+	}{
+		{"all of satisfied", AllOf(AnyRole("user"), AllPermissions("orders:read")), true, false},
+		{"all of missing permission", AllOf(AnyRole("user"), AllPermissions("orders:write")), false, false},
+		{"any of", AnyOf(AnyRole("admin"), AllPermissions("orders:read")), true, false},
+		{"any of none", AnyOf(AnyRole("admin"), AllPermissions("orders:write")), false, false},
+		{"error propagates", AllOf(AnyRole("user"), failing), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := tt.policy.Evaluate(context.Background(), Request{Subject: subject})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if decision.Allowed != tt.allowed {
+				t.Errorf("decision = %v, want allowed=%v", decision, tt.allowed)
+			}
+		})
+	}
+}
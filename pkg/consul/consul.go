@@ -1,6 +1,9 @@
 package consul
 
 import (
+	"common/pkg/logger"
+	"common/pkg/logger/adapter"
+	"context"
 	"fmt"
 	"log"
 
@@ -14,12 +17,42 @@ type ConsulClient interface {
 	DiscoverServiceByName(serviceName string) ([]*api.ServiceEntry, error)
 }
 
+// Pinger is implemented by clients that can check the agent is reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+var _ Pinger = (*consulClient)(nil)
+
 type consulClient struct {
 	client *api.Client
 }
 
-func NewConsulClient(address string) (*consulClient, error) {
+// Option configures the client built by NewConsulClient
+type Option func(*options)
+
+type options struct {
+	logger logger.Logger
+}
+
+// WithLogger routes the API client's internal logging through the given logger
+func WithLogger(l logger.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// NewConsulClient creates a Consul client
+func NewConsulClient(address string, opts ...Option) (*consulClient, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	config := api.DefaultConfig()
+	if o.logger != nil {
+		config = api.DefaultConfigWithLogger(adapter.NewHCLogger(o.logger))
+	}
 	config.Address = address
 	client, err := api.NewClient(config)
 	if err != nil {
@@ -74,3 +107,15 @@ func (c *consulClient) DiscoverServiceByName(serviceName string) ([]*api.Service
 
 	return specificService, nil
 }
+
+// Ping checks that the agent is reachable and the cluster has a leader
+func (c *consulClient) Ping(ctx context.Context) error {
+	leader, err := c.client.Status().LeaderWithQueryOptions((&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to reach Consul: %w", err)
+	}
+	if leader == "" {
+		return fmt.Errorf("consul cluster has no leader")
+	}
+	return nil
+}
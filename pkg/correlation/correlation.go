@@ -0,0 +1,109 @@
+// Package correlation carries request and correlation IDs across service
+// boundaries. The request ID identifies one request and is reused when a
+// caller sends a valid X-Request-ID; the correlation ID ties together all
+// work started by the original request and defaults to its request ID.
+//
+// IDs are read from inbound headers with Extract, kept in the context with
+// NewContext and written to outbound HTTP requests, gRPC metadata, task
+// payloads and message headers with Inject
+package correlation
+
+import (
+	"context"
+	"regexp"
+)
+
+const (
+	HeaderRequestID     = "X-Request-ID"
+	HeaderCorrelationID = "X-Correlation-ID"
+
+	// MaxIDLength bounds inbound IDs, which end up in logs and headers
+	MaxIDLength = 128
+)
+
+// idPattern admits UUIDs, ULIDs and similar tokens, and keeps out
+// whitespace and control characters that could forge log lines
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]+$`)
+
+// IDs are the identifiers of the current request
+type IDs struct {
+	RequestID     string `json:"request_id"`
+	CorrelationID string `json:"correlation_id"`
+}
+
+// Generator creates request IDs
+type Generator func() string
+
+// Carrier reads and writes header-like values. http.Header implements it
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// MapCarrier adapts a map, such as message headers, to Carrier
+type MapCarrier map[string]string
+
+func (m MapCarrier) Get(key string) string { return m[key] }
+
+func (m MapCarrier) Set(key, value string) { m[key] = value }
+
+// Valid reports whether id may be used as a request or correlation ID
+func Valid(id string) bool {
+	return len(id) <= MaxIDLength && idPattern.MatchString(id)
+}
+
+// Extract reads the IDs from carrier, generating a request ID when the
+// inbound one is missing or invalid. A nil generate uses NewUUIDv7
+func Extract(carrier Carrier, generate Generator) IDs {
+	if generate == nil {
+		generate = NewUUIDv7
+	}
+
+	ids := IDs{RequestID: carrier.Get(HeaderRequestID), CorrelationID: carrier.Get(HeaderCorrelationID)}
+	if !Valid(ids.RequestID) {
+		ids.RequestID = generate()
+	}
+	if !Valid(ids.CorrelationID) {
+		ids.CorrelationID = ids.RequestID
+	}
+	return ids
+}
+
+// Inject writes the IDs in ctx to carrier, leaving it unchanged when ctx
+// has none
+func Inject(ctx context.Context, carrier Carrier) {
+	ids, ok := FromContext(ctx)
+	if !ok {
+		return
+	}
+	carrier.Set(HeaderRequestID, ids.RequestID)
+	carrier.Set(HeaderCorrelationID, ids.CorrelationID)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying ids
+func NewContext(ctx context.Context, ids IDs) context.Context {
+	return context.WithValue(ctx, contextKey{}, ids)
+}
+
+// FromContext returns the IDs stored by NewContext
+func FromContext(ctx context.Context) (IDs, bool) {
+	ids, ok := ctx.Value(contextKey{}).(IDs)
+	return ids, ok
+}
+
+// RequestIDFromContext returns the request ID in ctx, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	ids, _ := FromContext(ctx)
+	return ids.RequestID
+}
+
+// Fields returns the IDs in ctx as logger key-value pairs, or nil
+func Fields(ctx context.Context) []interface{} {
+	ids, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []interface{}{"request_id", ids.RequestID, "correlation_id", ids.CorrelationID}
+}
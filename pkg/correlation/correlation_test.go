@@ -0,0 +1,75 @@
+package correlation
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestExtract(t *testing.T) {
+	generate := func() string { return "generated" }
+	tests := []struct {
+		name                  string
+		requestID, correlated string
+		want                  IDs
+	}{
+		{"none", "", "", IDs{"generated", "generated"}},
+		{"request id only", "req-1", "", IDs{"req-1", "req-1"}},
+		{"both", "req-1", "corr-1", IDs{"req-1", "corr-1"}},
+		{"invalid request id", "req 1\nforged", "corr-1", IDs{"generated", "corr-1"}},
+		{"too long", strings.Repeat("a", MaxIDLength+1), "", IDs{"generated", "generated"}},
+		{"invalid correlation id", "req-1", "<script>", IDs{"req-1", "req-1"}},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.requestID != "" {
+			header.Set(HeaderRequestID, tt.requestID)
+		}
+		if tt.correlated != "" {
+			header.Set(HeaderCorrelationID, tt.correlated)
+		}
+		if got := Extract(header, generate); got != tt.want {
+			t.Errorf("%s: Extract = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	carrier := MapCarrier{}
+	Inject(context.Background(), carrier)
+	if len(carrier) != 0 {
+		t.Errorf("context without IDs injected %v", carrier)
+	}
+
+	ctx := NewContext(context.Background(), IDs{RequestID: "req-1", CorrelationID: "corr-1"})
+	Inject(ctx, carrier)
+	if carrier[HeaderRequestID] != "req-1" || carrier[HeaderCorrelationID] != "corr-1" {
+		t.Errorf("carrier = %v", carrier)
+	}
+	if RequestIDFromContext(ctx) != "req-1" || len(Fields(ctx)) != 4 {
+		t.Errorf("RequestIDFromContext = %q, Fields = %v", RequestIDFromContext(ctx), Fields(ctx))
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	id, err := uuid.Parse(NewUUIDv7())
+	if err != nil || id.Version() != 7 {
+		t.Errorf("NewUUIDv7 = %v, %v", id, err)
+	}
+
+	ulidPattern := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	first := NewULID()
+	time.Sleep(2 * time.Millisecond)
+	second := NewULID()
+	if !ulidPattern.MatchString(first) || !Valid(first) {
+		t.Errorf("NewULID = %q", first)
+	}
+	if first >= second {
+		t.Errorf("ULIDs do not sort by time: %s >= %s", first, second)
+	}
+}
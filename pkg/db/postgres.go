@@ -1,6 +1,8 @@
 package database
 
 import (
+	"common/pkg/logger"
+	"common/pkg/logger/adapter"
 	"context"
 	"database/sql"
 	"errors"
@@ -27,6 +29,9 @@ type Config struct {
 	MaxOpenConns int
 	MaxIdleConns int
 	MaxLifetime  time.Duration
+
+	// Logger receives migration output; goose logs to stdout when nil
+	Logger logger.Logger
 }
 
 func (c *Config) Validate() error {
@@ -48,6 +53,7 @@ func DefaultConfig() *Config {
 
 type DB struct {
 	*sql.DB
+	logger logger.Logger
 }
 
 func New(cfg *Config) (*DB, error) {
@@ -82,11 +88,15 @@ func New(cfg *Config) (*DB, error) {
 		return nil, fmt.Errorf("%w: ping failed: %v", ErrConnection, err)
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db, logger: cfg.Logger}, nil
 }
 
 // ApplyMigrations runs database migrations from the specified directory
 func (db *DB) ApplyMigrations(ctx context.Context, migrationDir string) error {
+	if db.logger != nil {
+		goose.SetLogger(adapter.NewGooseLogger(db.logger))
+	}
+
 	if err := goose.SetDialect("postgres"); err != nil {
 		return fmt.Errorf("%w: failed to set dialect: %v", ErrMigration, err)
 	}
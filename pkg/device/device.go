@@ -0,0 +1,61 @@
+// Package device parses and validates what clients report about their
+// device: the User-Agent, IP and location headers. It fingerprints devices
+// and remembers which ones each user has used, so a login from a new device
+// can be reported
+package device
+
+import (
+	"common/dto"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidLocation = errors.New("device: location must be \"latitude,longitude\" within range")
+	ErrInvalidIP       = errors.New("device: invalid IP address")
+)
+
+// ParseLatLong parses "latitude,longitude" in decimal degrees
+func ParseLatLong(value string) (*dto.Coordinates, error) {
+	lat, long, ok := strings.Cut(value, ",")
+	if !ok {
+		return nil, ErrInvalidLocation
+	}
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil || math.IsNaN(latitude) || latitude < -90 || latitude > 90 {
+		return nil, ErrInvalidLocation
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(long), 64)
+	if err != nil || math.IsNaN(longitude) || longitude < -180 || longitude > 180 {
+		return nil, ErrInvalidLocation
+	}
+	return &dto.Coordinates{Latitude: latitude, Longitude: longitude}, nil
+}
+
+// ParseIP validates an IPv4 or IPv6 address and returns it in canonical form
+func ParseIP(value string) (string, error) {
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil {
+		return "", ErrInvalidIP
+	}
+	return ip.String(), nil
+}
+
+// Fingerprint identifies the device of info from the traits that stay the
+// same between sessions: the client supplied device ID, device type, OS and
+// browser. Versions, IP and location are left out so updates and travel do
+// not look like a new device. Without a device ID, identical phones of one
+// model share a fingerprint
+func Fingerprint(info *dto.DeviceInfo) string {
+	h := sha256.New()
+	for _, part := range []string{info.DeviceID, info.DeviceType, strings.ToLower(info.OS), strings.ToLower(info.Browser)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
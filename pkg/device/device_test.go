@@ -0,0 +1,86 @@
+package device
+
+import (
+	"common/dto"
+	"context"
+	"testing"
+)
+
+func TestParseLatLong(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    dto.Coordinates
+		wantErr bool
+	}{
+		{"27.7172,85.3240", dto.Coordinates{Latitude: 27.7172, Longitude: 85.3240}, false},
+		{" -33.86 , 151.21 ", dto.Coordinates{Latitude: -33.86, Longitude: 151.21}, false},
+		{"90,-180", dto.Coordinates{Latitude: 90, Longitude: -180}, false},
+		{"91,0", dto.Coordinates{}, true},
+		{"0,180.5", dto.Coordinates{}, true},
+		{"NaN,0", dto.Coordinates{}, true},
+		{"27.7172", dto.Coordinates{}, true},
+		{"north,east", dto.Coordinates{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLatLong(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLatLong(%q) err = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err == nil && *got != tt.want {
+			t.Errorf("ParseLatLong(%q) = %+v, want %+v", tt.value, *got, tt.want)
+		}
+	}
+}
+
+func TestParseIP(t *testing.T) {
+	tests := []struct {
+		value, want string
+		wantErr     bool
+	}{
+		{"192.168.1.10", "192.168.1.10", false},
+		{"2001:0db8:0000:0000:0000:0000:0000:0001", "2001:db8::1", false},
+		{"256.1.1.1", "", true},
+		{"example.com", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseIP(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseIP(%q) = %q, %v", tt.value, got, err)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	base := dto.DeviceInfo{DeviceID: "d1", DeviceType: TypeMobile, OS: "iOS", OSVersion: "17.5", Browser: "Safari", DeviceIP: "10.0.0.1"}
+
+	updated := base
+	updated.OSVersion, updated.DeviceIP = "18.0", "10.0.0.2"
+	if Fingerprint(&base) != Fingerprint(&updated) {
+		t.Error("fingerprint changed with OS version or IP")
+	}
+
+	other := base
+	other.DeviceID = "d2"
+	if Fingerprint(&base) == Fingerprint(&other) {
+		t.Error("fingerprint does not depend on the device ID")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for _, step := range []struct {
+		user, fingerprint string
+		want              bool
+	}{
+		{"u1", "f1", true},
+		{"u1", "f1", false},
+		{"u1", "f2", true},
+		{"u2", "f1", true},
+	} {
+		if got, err := store.Remember(ctx, step.user, step.fingerprint); err != nil || got != step.want {
+			t.Errorf("Remember(%s, %s) = %v, %v, want %v", step.user, step.fingerprint, got, err, step.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package device
+
+import (
+	"common/dto"
+	"common/pkg/task"
+	"context"
+)
+
+// TaskTypeNewDevice is the task type enqueued by EnqueueNewDevice
+const TaskTypeNewDevice = "device:new_login"
+
+// NewDeviceHook is called when a user makes a request from a device not seen
+// for them before
+type NewDeviceHook func(ctx context.Context, info *dto.DeviceInfo) error
+
+// EnqueueNewDevice returns a hook that enqueues a TaskTypeNewDevice task
+// with the device info as payload, for example to send a "new device login"
+// notification
+func EnqueueNewDevice(enqueuer task.TaskEnqueuer, queue string) NewDeviceHook {
+	return func(ctx context.Context, info *dto.DeviceInfo) error {
+		_, _, err := enqueuer.EnqueueContext(ctx, TaskTypeNewDevice, info, queue)
+		return err
+	}
+}
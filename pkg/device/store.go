@@ -0,0 +1,65 @@
+package device
+
+import (
+	"common/pkg/redis"
+	"context"
+	"sync"
+)
+
+// Store remembers the device fingerprints seen for each user.
+// Implementations must be safe for concurrent use
+type Store interface {
+	// Remember records fingerprint for userID and reports whether it was new
+	Remember(ctx context.Context, userID, fingerprint string) (bool, error)
+}
+
+// memoryStore keeps fingerprints in process, for tests and single-instance
+// setups
+type memoryStore struct {
+	mu      sync.Mutex
+	devices map[string]map[string]struct{}
+}
+
+// NewMemoryStore creates an in-process store
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{devices: make(map[string]map[string]struct{})}
+}
+
+func (s *memoryStore) Remember(ctx context.Context, userID, fingerprint string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	known, ok := s.devices[userID]
+	if !ok {
+		known = make(map[string]struct{})
+		s.devices[userID] = known
+	}
+	if _, ok := known[fingerprint]; ok {
+		return false, nil
+	}
+	known[fingerprint] = struct{}{}
+	return true, nil
+}
+
+// redisStore keeps the fingerprints of each user in a set under
+// <prefix>:<user id>
+type redisStore struct {
+	redis  redis.Redis
+	prefix string
+}
+
+// NewRedisStore creates a store that namespaces its keys with prefix
+func NewRedisStore(r redis.Redis, prefix string) *redisStore {
+	if prefix == "" {
+		prefix = "device:known"
+	}
+	return &redisStore{redis: r, prefix: prefix}
+}
+
+func (s *redisStore) Remember(ctx context.Context, userID, fingerprint string) (bool, error) {
+	added, err := s.redis.SAdd(s.prefix+":"+userID, fingerprint).Result()
+	if err != nil {
+		return false, err
+	}
+	return added > 0, nil
+}
@@ -0,0 +1,109 @@
+package device
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	TypeDesktop = "desktop"
+	TypeMobile  = "mobile"
+	TypeTablet  = "tablet"
+	TypeBot     = "bot"
+	TypeUnknown = "unknown"
+)
+
+// UserAgent is what ParseUserAgent recognises in a User-Agent header. Fields
+// it cannot tell are left empty, and DeviceType is TypeUnknown
+type UserAgent struct {
+	DeviceType     string
+	OS             string
+	OSVersion      string
+	Browser        string
+	BrowserVersion string
+}
+
+var botMarkers = []string{"bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-requests", "go-http-client"}
+
+type uaPattern struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// osPatterns are checked in order; iOS and Android user agents also contain
+// "Mac OS X" and "Linux"
+var osPatterns = []uaPattern{
+	{"Windows", regexp.MustCompile(`Windows NT ([\d.]+)`)},
+	{"iOS", regexp.MustCompile(`(?:iPhone|CPU) OS ([\d_]+)`)},
+	{"Android", regexp.MustCompile(`Android ([\d.]+)`)},
+	{"ChromeOS", regexp.MustCompile(`CrOS \S+ ([\d.]+)`)},
+	{"macOS", regexp.MustCompile(`Mac OS X ([\d_.]+)`)},
+	{"Linux", regexp.MustCompile(`Linux()`)},
+}
+
+// browserPatterns are checked in order; most browsers also claim to be
+// Chrome or Safari
+var browserPatterns = []uaPattern{
+	{"Edge", regexp.MustCompile(`Edg(?:e|A|iOS)?/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`OPR/([\d.]+)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+}
+
+// windowsVersions maps NT kernel versions to marketing names
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+}
+
+// ParseUserAgent extracts the device type, OS and browser from a User-Agent
+// header. It recognises the common browsers and platforms and is meant for
+// display and fingerprinting, not for feature detection
+func ParseUserAgent(header string) UserAgent {
+	ua := UserAgent{DeviceType: TypeUnknown}
+	if header == "" {
+		return ua
+	}
+
+	lower := strings.ToLower(header)
+	for _, marker := range botMarkers {
+		if strings.Contains(lower, marker) {
+			ua.DeviceType = TypeBot
+			return ua
+		}
+	}
+
+	for _, p := range osPatterns {
+		if m := p.pattern.FindStringSubmatch(header); m != nil {
+			ua.OS, ua.OSVersion = p.name, strings.ReplaceAll(m[1], "_", ".")
+			break
+		}
+	}
+	if ua.OS == "Windows" {
+		if name, ok := windowsVersions[ua.OSVersion]; ok {
+			ua.OSVersion = name
+		}
+	}
+
+	for _, p := range browserPatterns {
+		if m := p.pattern.FindStringSubmatch(header); m != nil {
+			ua.Browser, ua.BrowserVersion = p.name, m[1]
+			break
+		}
+	}
+
+	switch {
+	case strings.Contains(header, "iPad"), strings.Contains(header, "Tablet"),
+		ua.OS == "Android" && !strings.Contains(header, "Mobile"):
+		ua.DeviceType = TypeTablet
+	case strings.Contains(header, "Mobi"), strings.Contains(header, "iPhone"):
+		ua.DeviceType = TypeMobile
+	case ua.OS != "":
+		ua.DeviceType = TypeDesktop
+	}
+	return ua
+}
@@ -0,0 +1,61 @@
+package device
+
+import "testing"
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   UserAgent
+	}{
+		{
+			"chrome on windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			UserAgent{TypeDesktop, "Windows", "10", "Chrome", "126.0.0.0"},
+		},
+		{
+			"edge on windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.2592.87",
+			UserAgent{TypeDesktop, "Windows", "10", "Edge", "126.0.2592.87"},
+		},
+		{
+			"safari on iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			UserAgent{TypeMobile, "iOS", "17.5", "Safari", "17.5"},
+		},
+		{
+			"ipad",
+			"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/126.0.6478.153 Mobile/15E148 Safari/604.1",
+			UserAgent{TypeTablet, "iOS", "16.6", "Chrome", "126.0.6478.153"},
+		},
+		{
+			"chrome on android phone",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.6478.122 Mobile Safari/537.36",
+			UserAgent{TypeMobile, "Android", "14", "Chrome", "126.0.6478.122"},
+		},
+		{
+			"android tablet",
+			"Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/25.0 Chrome/121.0.0.0 Safari/537.36",
+			UserAgent{TypeTablet, "Android", "13", "Samsung Internet", "25.0"},
+		},
+		{
+			"firefox on macos",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:127.0) Gecko/20100101 Firefox/127.0",
+			UserAgent{TypeDesktop, "macOS", "10.15", "Firefox", "127.0"},
+		},
+		{
+			"firefox on linux",
+			"Mozilla/5.0 (X11; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0",
+			UserAgent{TypeDesktop, "Linux", "", "Firefox", "127.0"},
+		},
+		{"bot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", UserAgent{DeviceType: TypeBot}},
+		{"curl", "curl/8.6.0", UserAgent{DeviceType: TypeBot}},
+		{"unrecognised", "MyApp/1.0", UserAgent{DeviceType: TypeUnknown}},
+		{"empty", "", UserAgent{DeviceType: TypeUnknown}},
+	}
+	for _, tt := range tests {
+		if got := ParseUserAgent(tt.header); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
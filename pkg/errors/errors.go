@@ -2,6 +2,9 @@ package errors
 
 import (
 	"errors"
+	"fmt"
+	"runtime"
+	"strings"
 
 	"github.com/lib/pq"
 )
@@ -13,21 +16,55 @@ var (
 	ErrUnauthorized    = errors.New("unauthorized")
 )
 
+const maxStackDepth = 32
+
 type AppError struct {
 	Err        error
 	Message    string
 	StatusCode int
+
+	// stack holds the program counters captured when the error was created
+	stack []uintptr
 }
 
 func (e *AppError) Error() string {
 	return e.Message
 }
 
+// Unwrap exposes the wrapped error to errors.Is and errors.As
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// StackTrace returns the call stack captured by NewAppError, one frame per
+// "function\n\tfile:line" pair, or an empty string for literal AppErrors
+func (e *AppError) StackTrace() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
 func NewAppError(err error, message string, statusCode int) *AppError {
+	pcs := make([]uintptr, maxStackDepth)
+	// skip runtime.Callers and NewAppError itself
+	n := runtime.Callers(2, pcs)
+
 	return &AppError{
 		Err:        err,
 		Message:    message,
 		StatusCode: statusCode,
+		stack:      pcs[:n],
 	}
 }
 
@@ -2,6 +2,7 @@ package http_client
 
 import (
 	"bytes"
+	"common/pkg/correlation"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -485,6 +486,9 @@ func (r *request) addHeaders(req *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
+	// Continue the caller's request and correlation IDs downstream
+	correlation.Inject(req.Context(), req.Header)
+
 	// Add global headers
 	for key, value := range r.client.globalHeaders {
 		req.Header.Set(key, value)
@@ -2,14 +2,13 @@ package interceptors
 
 import (
 	"bytes"
+	"common/pkg/correlation"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 type Interceptor interface {
@@ -78,11 +77,16 @@ func (l *LoggerInterceptor) RoundTrip(req *http.Request) (*http.Response, error)
 		return nil, fmt.Errorf("request cannot be nil")
 	}
 
-	// Generate request ID if not present
-	reqID := req.Header.Get("X-Request-ID")
+	// A RoundTripper must not modify the caller's request, so the headers
+	// are set on a clone
+	req = req.Clone(req.Context())
+
+	// Use the caller's IDs, generating a request ID if there are none
+	correlation.Inject(req.Context(), req.Header)
+	reqID := req.Header.Get(correlation.HeaderRequestID)
 	if reqID == "" {
-		reqID = uuid.New().String()
-		req.Header.Set("X-Request-ID", reqID)
+		reqID = correlation.NewUUIDv7()
+		req.Header.Set(correlation.HeaderRequestID, reqID)
 	}
 
 	start := time.Now()
@@ -144,7 +148,7 @@ func (l *LoggerInterceptor) RoundTrip(req *http.Request) (*http.Response, error)
 		}
 	}
 
-	l.Logger.Info("received response", flattenFields(fields)...)
+	l.Logger.Info("received response", flattenFields(respFields)...)
 	return resp, nil
 }
 
@@ -0,0 +1,56 @@
+package interceptors
+
+import (
+	"common/pkg/correlation"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestLoggerInterceptorDoesNotModifyRequest(t *testing.T) {
+	var sent *http.Request
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	})
+	interceptor := NewLoggerInterceptor(next, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name          string
+		ids           *correlation.IDs
+		wantRequestID string
+	}{
+		{"ids from context", &correlation.IDs{RequestID: "req-1", CorrelationID: "corr-1"}, "req-1"},
+		{"generated request id", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/orders", nil)
+			if tt.ids != nil {
+				req = req.WithContext(correlation.NewContext(req.Context(), *tt.ids))
+			}
+
+			if _, err := interceptor.RoundTrip(req); err != nil {
+				t.Fatalf("RoundTrip: %v", err)
+			}
+
+			if len(req.Header) != 0 {
+				t.Errorf("caller's headers were modified: %v", req.Header)
+			}
+			got := sent.Header.Get(correlation.HeaderRequestID)
+			if got == "" || (tt.wantRequestID != "" && got != tt.wantRequestID) {
+				t.Errorf("sent %s = %q, want %q", correlation.HeaderRequestID, got, tt.wantRequestID)
+			}
+		})
+	}
+}
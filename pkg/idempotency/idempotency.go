@@ -0,0 +1,54 @@
+// Package idempotency records the first response to a request made with an
+// Idempotency-Key so retries of the same request get the same response
+// instead of repeating its side effects
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrInProgress means another request with the same key has not finished
+	ErrInProgress = errors.New("idempotency: request with this key is in progress")
+	// ErrMismatch means the key was used before for a different request
+	ErrMismatch = errors.New("idempotency: key reused with a different request")
+)
+
+// Record is a stored response, or a lock while Completed is false
+type Record struct {
+	RequestHash string      `json:"request_hash"`
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Store holds records. Implementations must be safe for concurrent use and
+// across instances sharing the store
+type Store interface {
+	// Begin locks key for lockTTL and returns nil when the caller should
+	// process the request. Otherwise it returns the completed record for the
+	// key, ErrInProgress while another request holds the lock, or
+	// ErrMismatch when the key was used with a different request hash
+	Begin(ctx context.Context, key, requestHash string, lockTTL time.Duration) (*Record, error)
+	// Complete stores the response for key, replacing the lock, for ttl
+	Complete(ctx context.Context, key string, record Record, ttl time.Duration) error
+	// Release removes the lock on key so the request can be retried. A
+	// completed record is kept
+	Release(ctx context.Context, key string) error
+}
+
+// check applies the Begin rules to an existing record
+func check(existing *Record, requestHash string) (*Record, error) {
+	if existing.RequestHash != requestHash {
+		return nil, ErrMismatch
+	}
+	if !existing.Completed {
+		return nil, ErrInProgress
+	}
+	return existing, nil
+}
@@ -0,0 +1,58 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	record    Record
+	expiresAt time.Time
+}
+
+// memoryStore keeps records in process, for tests and single-instance setups
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an in-process store
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+func (s *memoryStore) Begin(ctx context.Context, key, requestHash string, lockTTL time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		return check(&entry.record, requestHash)
+	}
+	s.entries[key] = memoryEntry{
+		record:    Record{RequestHash: requestHash, CreatedAt: now},
+		expiresAt: now.Add(lockTTL),
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record.Completed = true
+	s.entries[key] = memoryEntry{record: record, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && !entry.record.Completed {
+		delete(s.entries, key)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	if record, err := store.Begin(ctx, "k1", "h1", time.Minute); record != nil || err != nil {
+		t.Fatalf("first Begin = %v, %v", record, err)
+	}
+	if _, err := store.Begin(ctx, "k1", "h1", time.Minute); !errors.Is(err, ErrInProgress) {
+		t.Errorf("concurrent Begin: err = %v, want ErrInProgress", err)
+	}
+	if _, err := store.Begin(ctx, "k1", "h2", time.Minute); !errors.Is(err, ErrMismatch) {
+		t.Errorf("different request: err = %v, want ErrMismatch", err)
+	}
+
+	err := store.Complete(ctx, "k1", Record{RequestHash: "h1", Status: http.StatusCreated, Body: []byte("created")}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Release(ctx, "k1")
+	record, err := store.Begin(ctx, "k1", "h1", time.Minute)
+	if err != nil || record == nil || !record.Completed || string(record.Body) != "created" {
+		t.Fatalf("replay Begin = %+v, %v", record, err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if record, err := store.Begin(ctx, "k1", "h2", time.Minute); record != nil || err != nil {
+		t.Errorf("Begin after expiry = %v, %v", record, err)
+	}
+
+	// a released lock frees the key, and a lost lock expires
+	store.Release(ctx, "k1")
+	if record, err := store.Begin(ctx, "k1", "h1", time.Minute); record != nil || err != nil {
+		t.Errorf("Begin after release = %v, %v", record, err)
+	}
+	now = now.Add(2 * time.Minute)
+	if record, err := store.Begin(ctx, "k1", "h1", time.Minute); record != nil || err != nil {
+		t.Errorf("Begin after lock expiry = %v, %v", record, err)
+	}
+}
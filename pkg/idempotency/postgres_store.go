@@ -0,0 +1,97 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// PostgresSchema creates the table used by the Postgres store; add it to
+// the service's migrations. Expired rows are ignored and overwritten, and
+// can be purged with DELETE FROM idempotency_keys WHERE expires_at < now()
+const PostgresSchema = `
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key          TEXT PRIMARY KEY,
+	request_hash TEXT NOT NULL,
+	completed    BOOLEAN NOT NULL DEFAULT FALSE,
+	status       INTEGER NOT NULL DEFAULT 0,
+	header       JSONB,
+	body         BYTEA,
+	created_at   TIMESTAMPTZ NOT NULL,
+	expires_at   TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at ON idempotency_keys (expires_at);
+`
+
+// postgresStore keeps records in the idempotency_keys table. A lock is a
+// row with completed = false that expires after the lock TTL
+type postgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore uses db, typically the *sql.DB embedded in database.DB
+func NewPostgresStore(db *sql.DB) *postgresStore {
+	return &postgresStore{db: db}
+}
+
+func (s *postgresStore) Begin(ctx context.Context, key, requestHash string, lockTTL time.Duration) (*Record, error) {
+	now := time.Now().UTC()
+	// take the key when it is free or its previous record or lock expired
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (key, request_hash, completed, created_at, expires_at)
+		VALUES ($1, $2, FALSE, $3, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, completed = FALSE, status = 0, header = NULL, body = NULL,
+			created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < $3`,
+		key, requestHash, now, now.Add(lockTTL),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if rows == 1 {
+		return nil, nil
+	}
+
+	var existing Record
+	var header []byte
+	err = s.db.QueryRowContext(ctx,
+		`SELECT request_hash, completed, status, header, body, created_at FROM idempotency_keys WHERE key = $1`, key,
+	).Scan(&existing.RequestHash, &existing.Completed, &existing.Status, &header, &existing.Body, &existing.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// released between the insert and the select
+		return nil, ErrInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > 0 {
+		if err := json.Unmarshal(header, &existing.Header); err != nil {
+			return nil, err
+		}
+	}
+	return check(&existing, requestHash)
+}
+
+func (s *postgresStore) Complete(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET completed = TRUE, status = $2, header = $3, body = $4, expires_at = $5
+		WHERE key = $1`,
+		key, record.Status, header, record.Body, time.Now().UTC().Add(ttl),
+	)
+	return err
+}
+
+func (s *postgresStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND NOT completed`, key)
+	return err
+}
@@ -0,0 +1,85 @@
+package idempotency
+
+import (
+	"common/pkg/redis"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// beginScript stores the lock unless a record exists, returning the existing
+// record or nil
+const beginScript = `
+local existing = redis.call("GET", KEYS[1])
+if existing then
+	return existing
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return false
+`
+
+// releaseScript deletes the record only while it is still a lock
+const releaseScript = `
+local existing = redis.call("GET", KEYS[1])
+if existing and not cjson.decode(existing).completed then
+	redis.call("DEL", KEYS[1])
+end
+return 0
+`
+
+// redisStore keeps each record as JSON under <prefix>:<key>, expiring with
+// its lock or record TTL
+type redisStore struct {
+	redis  redis.Redis
+	prefix string
+}
+
+// NewRedisStore creates a store that namespaces its keys with prefix
+func NewRedisStore(r redis.Redis, prefix string) *redisStore {
+	if prefix == "" {
+		prefix = "idempotency"
+	}
+	return &redisStore{redis: r, prefix: prefix}
+}
+
+func (s *redisStore) Begin(ctx context.Context, key, requestHash string, lockTTL time.Duration) (*Record, error) {
+	lock, err := json.Marshal(Record{RequestHash: requestHash, CreatedAt: time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.redis.Eval(beginScript, []string{s.prefix + ":" + key}, lock, lockTTL.Milliseconds()).Result()
+	if errors.Is(err, goredis.Nil) {
+		// the script returned false: the lock is ours
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, ok := result.(string)
+	if !ok {
+		return nil, nil
+	}
+
+	var existing Record
+	if err := json.Unmarshal([]byte(data), &existing); err != nil {
+		return nil, err
+	}
+	return check(&existing, requestHash)
+}
+
+func (s *redisStore) Complete(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	record.Completed = true
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.redis.Set(s.prefix+":"+key, data, ttl).Err()
+}
+
+func (s *redisStore) Release(ctx context.Context, key string) error {
+	return s.redis.Eval(releaseScript, []string{s.prefix + ":" + key}).Err()
+}
@@ -0,0 +1,71 @@
+// Package adapter routes the logger interfaces of third-party libraries into logger.Logger
+package adapter
+
+import (
+	"common/pkg/logger"
+	"fmt"
+	"strings"
+
+	"github.com/hibiken/asynq"
+	"github.com/pressly/goose"
+)
+
+// asynqLogger implements asynq.Logger
+type asynqLogger struct {
+	log logger.Logger
+}
+
+// NewAsynqLogger returns an asynq.Logger that writes to the given logger
+func NewAsynqLogger(log logger.Logger) asynq.Logger {
+	return &asynqLogger{log: log.With("component", "asynq")}
+}
+
+func (a *asynqLogger) Debug(args ...interface{}) {
+	a.log.Debug(fmt.Sprint(args...))
+}
+
+func (a *asynqLogger) Info(args ...interface{}) {
+	a.log.Info(fmt.Sprint(args...))
+}
+
+func (a *asynqLogger) Warn(args ...interface{}) {
+	a.log.Warn(fmt.Sprint(args...))
+}
+
+func (a *asynqLogger) Error(args ...interface{}) {
+	a.log.Error(fmt.Sprint(args...))
+}
+
+func (a *asynqLogger) Fatal(args ...interface{}) {
+	a.log.Fatal(fmt.Sprint(args...))
+}
+
+// gooseLogger implements goose.Logger
+type gooseLogger struct {
+	log logger.Logger
+}
+
+// NewGooseLogger returns a goose.Logger that writes to the given logger
+func NewGooseLogger(log logger.Logger) goose.Logger {
+	return &gooseLogger{log: log.With("component", "goose")}
+}
+
+func (g *gooseLogger) Fatal(v ...interface{}) {
+	g.log.Fatal(fmt.Sprint(v...))
+}
+
+func (g *gooseLogger) Fatalf(format string, v ...interface{}) {
+	g.log.Fatal(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (g *gooseLogger) Print(v ...interface{}) {
+	g.log.Info(fmt.Sprint(v...))
+}
+
+func (g *gooseLogger) Println(v ...interface{}) {
+	g.log.Info(strings.TrimSpace(fmt.Sprintln(v...)))
+}
+
+func (g *gooseLogger) Printf(format string, v ...interface{}) {
+	g.log.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
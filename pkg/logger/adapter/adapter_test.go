@@ -0,0 +1,81 @@
+package adapter
+
+import (
+	"common/pkg/logger/loggertest"
+	"testing"
+)
+
+func TestAsynqLogger(t *testing.T) {
+	log, recorder := loggertest.New()
+	l := NewAsynqLogger(log)
+
+	tests := []struct {
+		name  string
+		write func(args ...interface{})
+		level loggertest.Level
+	}{
+		{"debug", l.Debug, loggertest.DebugLevel},
+		{"info", l.Info, loggertest.InfoLevel},
+		{"warn", l.Warn, loggertest.WarnLevel},
+		{"error", l.Error, loggertest.ErrorLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			tt.write("processing task ", 42)
+
+			recorder.AssertLogged(t, tt.level, "processing task 42", map[string]interface{}{"component": "asynq"})
+		})
+	}
+}
+
+func TestAsynqLoggerFatal(t *testing.T) {
+	log, recorder := loggertest.New()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Fatal to panic")
+		}
+		recorder.AssertLogged(t, loggertest.FatalLevel, "redis unreachable", map[string]interface{}{"component": "asynq"})
+	}()
+
+	NewAsynqLogger(log).Fatal("redis ", "unreachable")
+}
+
+func TestGooseLogger(t *testing.T) {
+	log, recorder := loggertest.New()
+	l := NewGooseLogger(log)
+
+	tests := []struct {
+		name  string
+		write func()
+		msg   string
+	}{
+		{"print", func() { l.Print("OK ", "00001_init.sql") }, "OK 00001_init.sql"},
+		{"println", func() { l.Println("goose:", "no migrations to run") }, "goose: no migrations to run"},
+		{"printf", func() { l.Printf("applied %d migrations\n", 3) }, "applied 3 migrations"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			tt.write()
+
+			recorder.AssertLogged(t, loggertest.InfoLevel, tt.msg, map[string]interface{}{"component": "goose"})
+		})
+	}
+}
+
+func TestGooseLoggerFatalf(t *testing.T) {
+	log, recorder := loggertest.New()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Fatalf to panic")
+		}
+		recorder.AssertLogged(t, loggertest.FatalLevel, "migration 2 failed", map[string]interface{}{"component": "goose"})
+	}()
+
+	NewGooseLogger(log).Fatalf("migration %d failed\n", 2)
+}
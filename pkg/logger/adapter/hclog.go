@@ -0,0 +1,124 @@
+package adapter
+
+import (
+	"bytes"
+	"common/pkg/logger"
+	"io"
+	"log"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// hcLogger implements hclog.Logger, used by the Consul API client
+type hcLogger struct {
+	log     logger.Logger
+	name    string
+	level   hclog.Level
+	implied []interface{}
+}
+
+// NewHCLogger returns an hclog.Logger that writes to the given logger.
+// Level filtering is left to the underlying logger unless SetLevel is called
+func NewHCLogger(log logger.Logger) hclog.Logger {
+	return &hcLogger{log: log, level: hclog.Trace}
+}
+
+func (h *hcLogger) Log(level hclog.Level, msg string, args ...interface{}) {
+	if level == hclog.Off || level < h.level {
+		return
+	}
+
+	l := h.log
+	if h.name != "" {
+		l = l.With("component", h.name)
+	}
+
+	switch level {
+	case hclog.Trace, hclog.Debug:
+		l.Debug(msg, args...)
+	case hclog.Warn:
+		l.Warn(msg, args...)
+	case hclog.Error:
+		l.Error(msg, args...)
+	default:
+		l.Info(msg, args...)
+	}
+}
+
+func (h *hcLogger) Trace(msg string, args ...interface{}) { h.Log(hclog.Trace, msg, args...) }
+func (h *hcLogger) Debug(msg string, args ...interface{}) { h.Log(hclog.Debug, msg, args...) }
+func (h *hcLogger) Info(msg string, args ...interface{})  { h.Log(hclog.Info, msg, args...) }
+func (h *hcLogger) Warn(msg string, args ...interface{})  { h.Log(hclog.Warn, msg, args...) }
+func (h *hcLogger) Error(msg string, args ...interface{}) { h.Log(hclog.Error, msg, args...) }
+
+func (h *hcLogger) IsTrace() bool { return h.level <= hclog.Trace }
+func (h *hcLogger) IsDebug() bool { return h.level <= hclog.Debug }
+func (h *hcLogger) IsInfo() bool  { return h.level <= hclog.Info }
+func (h *hcLogger) IsWarn() bool  { return h.level <= hclog.Warn }
+func (h *hcLogger) IsError() bool { return h.level <= hclog.Error }
+
+func (h *hcLogger) ImpliedArgs() []interface{} {
+	return h.implied
+}
+
+func (h *hcLogger) With(args ...interface{}) hclog.Logger {
+	clone := *h
+	clone.log = h.log.With(args...)
+	clone.implied = append(append([]interface{}{}, h.implied...), args...)
+	return &clone
+}
+
+func (h *hcLogger) Name() string {
+	return h.name
+}
+
+func (h *hcLogger) Named(name string) hclog.Logger {
+	clone := *h
+	if clone.name != "" {
+		clone.name = clone.name + "." + name
+	} else {
+		clone.name = name
+	}
+	return &clone
+}
+
+func (h *hcLogger) ResetNamed(name string) hclog.Logger {
+	clone := *h
+	clone.name = name
+	return &clone
+}
+
+func (h *hcLogger) SetLevel(level hclog.Level) {
+	h.level = level
+}
+
+func (h *hcLogger) GetLevel() hclog.Level {
+	return h.level
+}
+
+func (h *hcLogger) StandardLogger(opts *hclog.StandardLoggerOptions) *log.Logger {
+	return log.New(h.StandardWriter(opts), "", 0)
+}
+
+func (h *hcLogger) StandardWriter(opts *hclog.StandardLoggerOptions) io.Writer {
+	level := hclog.Info
+	if opts != nil && opts.ForceLevel != hclog.NoLevel {
+		level = opts.ForceLevel
+	}
+	return &hcWriter{logger: h, level: level}
+}
+
+// hcWriter forwards lines written through a standard library logger
+type hcWriter struct {
+	logger *hcLogger
+	level  hclog.Level
+}
+
+func (w *hcWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		if len(line) > 0 {
+			w.logger.Log(w.level, string(line))
+		}
+	}
+	return len(p), nil
+}
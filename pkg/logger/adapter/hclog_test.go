@@ -0,0 +1,79 @@
+package adapter
+
+import (
+	"common/pkg/logger/loggertest"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestHCLoggerLevels(t *testing.T) {
+	log, recorder := loggertest.New()
+	l := NewHCLogger(log)
+
+	tests := []struct {
+		name  string
+		level hclog.Level
+		want  loggertest.Level
+	}{
+		{"trace", hclog.Trace, loggertest.DebugLevel},
+		{"debug", hclog.Debug, loggertest.DebugLevel},
+		{"info", hclog.Info, loggertest.InfoLevel},
+		{"warn", hclog.Warn, loggertest.WarnLevel},
+		{"error", hclog.Error, loggertest.ErrorLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			l.Log(tt.level, "agent request", "service", "orders", "attempt", 2)
+
+			recorder.AssertLogged(t, tt.want, "agent request", map[string]interface{}{"service": "orders", "attempt": 2})
+		})
+	}
+}
+
+func TestHCLoggerSetLevel(t *testing.T) {
+	log, recorder := loggertest.New()
+	l := NewHCLogger(log)
+	l.SetLevel(hclog.Warn)
+
+	l.Info("dropped")
+	l.Warn("kept")
+	l.Log(hclog.Off, "off")
+
+	recorder.AssertNotLogged(t, loggertest.InfoLevel, "dropped")
+	recorder.AssertLogged(t, loggertest.WarnLevel, "kept", nil)
+	if recorder.Len() != 1 {
+		t.Errorf("recorded %d entries, want 1", recorder.Len())
+	}
+	if l.IsInfo() || !l.IsWarn() {
+		t.Errorf("IsInfo() = %v, IsWarn() = %v after SetLevel(Warn)", l.IsInfo(), l.IsWarn())
+	}
+}
+
+func TestHCLoggerWithAndNamed(t *testing.T) {
+	log, recorder := loggertest.New()
+	l := NewHCLogger(log).Named("consul").Named("watch").With("dc", "eu-1")
+
+	l.Error("watch failed", "key", "config/app")
+
+	recorder.AssertLogged(t, loggertest.ErrorLevel, "watch failed", map[string]interface{}{
+		"component": "consul.watch",
+		"dc":        "eu-1",
+		"key":       "config/app",
+	})
+	if got := l.ImpliedArgs(); len(got) != 2 || got[0] != "dc" || got[1] != "eu-1" {
+		t.Errorf("ImpliedArgs() = %v, want [dc eu-1]", got)
+	}
+}
+
+func TestHCLoggerStandardWriter(t *testing.T) {
+	log, recorder := loggertest.New()
+	std := NewHCLogger(log).StandardLogger(&hclog.StandardLoggerOptions{ForceLevel: hclog.Warn})
+
+	std.Print("first line\nsecond line")
+
+	recorder.AssertLogged(t, loggertest.WarnLevel, "first line", nil)
+	recorder.AssertLogged(t, loggertest.WarnLevel, "second line", nil)
+}
@@ -0,0 +1,314 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OverflowPolicy decides what happens to a write when the async buffer is full
+type OverflowPolicy string
+
+const (
+	// OverflowBlock makes the caller wait until the writer frees a slot
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropNewest discards the entry being written
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+	// OverflowDropOldest discards the oldest buffered entry to make room
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+)
+
+type AsyncConfig struct {
+	Enabled        bool
+	BufferSize     int            // Number of entries held before the overflow policy applies
+	OverflowPolicy OverflowPolicy // Defaults to OverflowBlock
+	FlushInterval  time.Duration  // How often buffered output is flushed and synced
+
+	// Registerer, when set, receives the writer's buffer and drop metrics
+	Registerer prometheus.Registerer
+}
+
+var AsyncConfigDefault = AsyncConfig{
+	BufferSize:     1024,
+	OverflowPolicy: OverflowBlock,
+	FlushInterval:  time.Second,
+}
+
+func (c AsyncConfig) withDefaults() AsyncConfig {
+	if c.BufferSize <= 0 {
+		c.BufferSize = AsyncConfigDefault.BufferSize
+	}
+	if c.OverflowPolicy == "" {
+		c.OverflowPolicy = AsyncConfigDefault.OverflowPolicy
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = AsyncConfigDefault.FlushInterval
+	}
+	return c
+}
+
+// AsyncStats is a snapshot of an AsyncWriter's buffer
+type AsyncStats struct {
+	Capacity    int
+	Occupancy   int
+	Dropped     uint64
+	WriteErrors uint64
+}
+
+// AsyncWriter buffers writes in a bounded ring and hands them to the
+// underlying writer from a single background goroutine
+type AsyncWriter struct {
+	out    io.Writer
+	outMu  sync.Mutex // serialises the consumer with writes made after Close
+	bw     *bufio.Writer
+	policy OverflowPolicy
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	ring    [][]byte
+	head    int
+	count   int
+	closed  bool
+
+	wake   chan struct{}
+	syncCh chan chan struct{}
+	quit   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	dropped     atomic.Uint64
+	writeErrors atomic.Uint64
+
+	occupancyDesc   *prometheus.Desc
+	capacityDesc    *prometheus.Desc
+	droppedDesc     *prometheus.Desc
+	writeErrorsDesc *prometheus.Desc
+}
+
+// NewAsyncWriter starts an AsyncWriter in front of out. Close must be called
+// to drain the buffer; if out is an io.Closer it is closed afterwards. It
+// fails when the metrics cannot be registered with config.Registerer
+func NewAsyncWriter(out io.Writer, config AsyncConfig) (*AsyncWriter, error) {
+	cfg := config.withDefaults()
+
+	w := newAsyncWriter(out, cfg)
+	if cfg.Registerer != nil {
+		if err := cfg.Registerer.Register(w); err != nil {
+			return nil, fmt.Errorf("logger: registering async writer metrics: %w", err)
+		}
+	}
+
+	go w.run(cfg.FlushInterval)
+
+	return w, nil
+}
+
+// newAsyncWriter builds the writer without starting its consumer goroutine
+func newAsyncWriter(out io.Writer, cfg AsyncConfig) *AsyncWriter {
+
+	w := &AsyncWriter{
+		out:    out,
+		bw:     bufio.NewWriter(out),
+		policy: cfg.OverflowPolicy,
+		ring:   make([][]byte, cfg.BufferSize),
+		wake:   make(chan struct{}, 1),
+		syncCh: make(chan chan struct{}),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+
+		occupancyDesc:   prometheus.NewDesc("logger_async_buffer_occupancy", "Number of log entries waiting in the async buffer.", nil, nil),
+		capacityDesc:    prometheus.NewDesc("logger_async_buffer_capacity", "Maximum number of log entries the async buffer can hold.", nil, nil),
+		droppedDesc:     prometheus.NewDesc("logger_async_dropped_total", "Log entries dropped because the async buffer was full.", []string{"policy"}, nil),
+		writeErrorsDesc: prometheus.NewDesc("logger_async_write_errors_total", "Errors returned by the underlying log writer.", nil, nil),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+
+	return w
+}
+
+// Write copies p into the buffer. Once the writer is closed, writes go
+// straight to the underlying writer
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	w.mu.Lock()
+	for w.count == len(w.ring) && !w.closed {
+		switch w.policy {
+		case OverflowDropNewest:
+			w.mu.Unlock()
+			w.dropped.Add(1)
+			return len(p), nil
+		case OverflowDropOldest:
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+			w.count--
+			w.dropped.Add(1)
+		default:
+			w.notFull.Wait()
+		}
+	}
+
+	if w.closed {
+		w.mu.Unlock()
+		w.outMu.Lock()
+		defer w.outMu.Unlock()
+		return w.out.Write(p)
+	}
+
+	w.ring[(w.head+w.count)%len(w.ring)] = entry
+	w.count++
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+
+	return len(p), nil
+}
+
+// Sync blocks until everything buffered so far has been written and flushed
+func (w *AsyncWriter) Sync() error {
+	ack := make(chan struct{})
+	select {
+	case w.syncCh <- ack:
+		<-ack
+		return nil
+	case <-w.done:
+		return syncWriter(w.out)
+	}
+}
+
+// Close drains the buffer, flushes it and closes the underlying writer
+func (w *AsyncWriter) Close() error {
+	var err error
+	w.once.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		close(w.quit)
+		<-w.done
+
+		if closer, ok := w.out.(io.Closer); ok {
+			err = closer.Close()
+		}
+	})
+	return err
+}
+
+// Stats returns the current buffer occupancy and drop counters
+func (w *AsyncWriter) Stats() AsyncStats {
+	w.mu.Lock()
+	occupancy := w.count
+	w.mu.Unlock()
+
+	return AsyncStats{
+		Capacity:    len(w.ring),
+		Occupancy:   occupancy,
+		Dropped:     w.dropped.Load(),
+		WriteErrors: w.writeErrors.Load(),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (w *AsyncWriter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- w.occupancyDesc
+	ch <- w.capacityDesc
+	ch <- w.droppedDesc
+	ch <- w.writeErrorsDesc
+}
+
+// Collect implements prometheus.Collector
+func (w *AsyncWriter) Collect(ch chan<- prometheus.Metric) {
+	stats := w.Stats()
+	ch <- prometheus.MustNewConstMetric(w.occupancyDesc, prometheus.GaugeValue, float64(stats.Occupancy))
+	ch <- prometheus.MustNewConstMetric(w.capacityDesc, prometheus.GaugeValue, float64(stats.Capacity))
+	ch <- prometheus.MustNewConstMetric(w.droppedDesc, prometheus.CounterValue, float64(stats.Dropped), string(w.policy))
+	ch <- prometheus.MustNewConstMetric(w.writeErrorsDesc, prometheus.CounterValue, float64(stats.WriteErrors))
+}
+
+// run is the single consumer of the ring buffer
+func (w *AsyncWriter) run(flushInterval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, len(w.ring))
+	for {
+		select {
+		case <-w.wake:
+			batch = w.drain(batch)
+		case <-ticker.C:
+			batch = w.drain(batch)
+			w.flush()
+		case ack := <-w.syncCh:
+			batch = w.drain(batch)
+			w.flush()
+			close(ack)
+		case <-w.quit:
+			w.drain(batch)
+			w.flush()
+			return
+		}
+	}
+}
+
+// drain moves every buffered entry into the bufio writer
+func (w *AsyncWriter) drain(batch [][]byte) [][]byte {
+	for {
+		batch = batch[:0]
+
+		w.mu.Lock()
+		for w.count > 0 {
+			batch = append(batch, w.ring[w.head])
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+			w.count--
+		}
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		if len(batch) == 0 {
+			return batch
+		}
+
+		w.outMu.Lock()
+		for _, entry := range batch {
+			if _, err := w.bw.Write(entry); err != nil {
+				w.writeErrors.Add(1)
+			}
+		}
+		w.outMu.Unlock()
+	}
+}
+
+func (w *AsyncWriter) flush() {
+	w.outMu.Lock()
+	defer w.outMu.Unlock()
+
+	if err := w.bw.Flush(); err != nil {
+		w.writeErrors.Add(1)
+		// bufio keeps returning the same error once a write has failed
+		w.bw.Reset(w.out)
+	}
+	if err := syncWriter(w.out); err != nil {
+		w.writeErrors.Add(1)
+	}
+}
+
+// syncWriter calls Sync on writers that support it, such as *os.File
+func syncWriter(out io.Writer) error {
+	if s, ok := out.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
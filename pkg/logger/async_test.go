@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAsyncWriterOverflowPolicies(t *testing.T) {
+	tests := []struct {
+		policy OverflowPolicy
+		want   string
+	}{
+		{OverflowDropNewest, "a\nb\n"},
+		{OverflowDropOldest, "c\nd\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			out := &lockedBuffer{}
+			cfg := AsyncConfig{BufferSize: 2, OverflowPolicy: tt.policy, FlushInterval: time.Hour}
+
+			// The consumer is started only after the buffer has overflowed
+			w := newAsyncWriter(out, cfg.withDefaults())
+			for _, line := range []string{"a\n", "b\n", "c\n", "d\n"} {
+				if _, err := w.Write([]byte(line)); err != nil {
+					t.Fatalf("write failed: %v", err)
+				}
+			}
+
+			stats := w.Stats()
+			if stats.Occupancy != 2 || stats.Dropped != 2 {
+				t.Errorf("stats = %+v, want occupancy 2 and 2 drops", stats)
+			}
+
+			go w.run(cfg.FlushInterval)
+			if err := w.Close(); err != nil {
+				t.Fatalf("close failed: %v", err)
+			}
+
+			if got := out.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsyncWriterBlockDrainsOnClose(t *testing.T) {
+	out := &lockedBuffer{}
+	w, err := NewAsyncWriter(out, AsyncConfig{BufferSize: 4, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = w.Write([]byte("entry\n"))
+		}()
+	}
+	wg.Wait()
+
+	if err := w.Sync(); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if got := strings.Count(out.String(), "entry\n"); got != 8 {
+		t.Errorf("synced %d entries, want 8", got)
+	}
+
+	_, _ = w.Write([]byte("last\n"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "last\n") {
+		t.Errorf("entry written before close was not drained: %q", out.String())
+	}
+	if stats := w.Stats(); stats.Dropped != 0 {
+		t.Errorf("block policy dropped %d entries", stats.Dropped)
+	}
+}
+
+func TestNewAsyncWriterReturnsRegistrationError(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := AsyncConfig{FlushInterval: time.Hour, Registerer: registry}
+
+	first, err := NewAsyncWriter(&lockedBuffer{}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+
+	// a second writer exports the same metric names
+	if _, err := NewAsyncWriter(&lockedBuffer{}, config); err == nil {
+		t.Error("NewAsyncWriter ignored a failed metrics registration")
+	}
+}
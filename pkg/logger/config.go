@@ -7,6 +7,9 @@ type Config struct {
 	MaxBackups int
 	MaxAge     int
 	Compress   bool
+
+	// Async moves file writes off the calling goroutine
+	Async AsyncConfig
 }
 
 var ConfigDefault = Config{
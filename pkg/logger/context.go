@@ -0,0 +1,16 @@
+package logger
+
+import (
+	"common/pkg/correlation"
+	"context"
+)
+
+// WithContext returns l tagged with the request and correlation IDs of ctx,
+// or l itself when ctx has none
+func WithContext(ctx context.Context, l Logger) Logger {
+	fields := correlation.Fields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
+}
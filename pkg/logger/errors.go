@@ -0,0 +1,98 @@
+package logger
+
+import (
+	apperrors "common/pkg/errors"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxErrorChain bounds how many wrapped errors are walked for a single field
+const maxErrorChain = 32
+
+// errorField logs err under key as an object holding the message, the Go type,
+// the unwrapped chain and, for AppErrors, the status code and stack trace
+func errorField(key string, err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Object(key, errorObject{err: err})
+}
+
+type errorObject struct {
+	err error
+}
+
+func (e errorObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", e.err.Error())
+	enc.AddString("type", fmt.Sprintf("%T", e.err))
+
+	if chain := errorChain(e.err); len(chain) > 1 {
+		if err := enc.AddArray("chain", chain); err != nil {
+			return err
+		}
+	}
+
+	var appErr *apperrors.AppError
+	if errors.As(e.err, &appErr) {
+		if appErr.StatusCode != 0 {
+			enc.AddInt("status_code", appErr.StatusCode)
+		}
+		if stack := appErr.StackTrace(); stack != "" {
+			enc.AddString("stack", stack)
+		}
+	}
+
+	return nil
+}
+
+// chainLink is a single error in an unwrapped chain
+type chainLink struct {
+	message string
+	errType string
+}
+
+func (l chainLink) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", l.message)
+	enc.AddString("type", l.errType)
+	return nil
+}
+
+type errorChainArray []chainLink
+
+func (a errorChainArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, link := range a {
+		if err := enc.AppendObject(link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errorChain walks errors.Unwrap and errors.Join trees depth first
+func errorChain(err error) errorChainArray {
+	var chain errorChainArray
+
+	var walk func(error)
+	walk = func(err error) {
+		if err == nil || len(chain) >= maxErrorChain {
+			return
+		}
+
+		chain = append(chain, chainLink{message: err.Error(), errType: fmt.Sprintf("%T", err)})
+
+		switch wrapped := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, e := range wrapped.Unwrap() {
+				walk(e)
+			}
+		case interface{ Unwrap() error }:
+			walk(wrapped.Unwrap())
+		}
+	}
+	walk(err)
+
+	return chain
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	apperrors "common/pkg/errors"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestErrorFieldsKeepKeysAndChain(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := &zapLogger{log: zap.New(core)}
+
+	appErr := apperrors.NewAppError(fmt.Errorf("query users: %w", apperrors.ErrNotFound), "user not found", http.StatusNotFound)
+	joined := errors.Join(errors.New("cache miss"), errors.New("db timeout"))
+
+	l.Error("lookup failed", "lookup_error", appErr, "cleanup_error", joined)
+
+	fields := logs.All()[0].ContextMap()
+
+	lookup, ok := fields["lookup_error"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("lookup_error = %v, want object", fields["lookup_error"])
+	}
+	if lookup["status_code"] != http.StatusNotFound {
+		t.Errorf("status_code = %v, want 404", lookup["status_code"])
+	}
+	if lookup["type"] != "*errors.AppError" {
+		t.Errorf("type = %v, want *errors.AppError", lookup["type"])
+	}
+	if stack, _ := lookup["stack"].(string); !strings.Contains(stack, "TestErrorFieldsKeepKeysAndChain") {
+		t.Errorf("stack does not point at the wrap site: %q", stack)
+	}
+	if chain, _ := lookup["chain"].([]interface{}); len(chain) != 3 {
+		t.Errorf("chain = %v, want 3 links", lookup["chain"])
+	}
+
+	cleanup, ok := fields["cleanup_error"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("cleanup_error = %v, want object", fields["cleanup_error"])
+	}
+	if chain, _ := cleanup["chain"].([]interface{}); len(chain) != 3 {
+		t.Errorf("joined chain = %v, want the join plus both errors", cleanup["chain"])
+	}
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"log/slog"
 	"os"
 	"reflect"
 	"strings"
@@ -18,11 +19,17 @@ type Logger interface {
 	Error(msg string, fields ...interface{})
 	Fatal(msg string, fields ...interface{})
 	With(fields ...interface{}) Logger
+	Slog() slog.Handler
 	Close()
 }
 
 type zapLogger struct {
 	log *zap.Logger
+
+	// closers release resources owned by the logger, such as the async file
+	// writer. Only the logger returned by New holds them; loggers derived
+	// with With share its outputs and must not close them
+	closers []func() error
 }
 
 func New(config ...Config) Logger {
@@ -47,13 +54,30 @@ func New(config ...Config) Logger {
 
 	fileEncoder := zapcore.NewJSONEncoder(encoderConfig)
 
-	fileSyncer := zapcore.AddSync(&lumberjack.Logger{
+	fileWriter := &lumberjack.Logger{
 		Filename:   cfg.Filename,
 		MaxSize:    cfg.MaxSize,
 		MaxBackups: cfg.MaxBackups,
 		MaxAge:     cfg.MaxAge,
 		// Compress:   cfg.Compress,
-	})
+	}
+
+	var closers []func() error
+	var asyncErr error
+
+	fileSyncer := zapcore.AddSync(fileWriter)
+	if cfg.Async.Enabled {
+		asyncWriter, err := NewAsyncWriter(fileWriter, cfg.Async)
+		if err != nil {
+			// keep writing asynchronously without metrics, and report the
+			// failure through the logger once it exists
+			asyncErr = err
+			cfg.Async.Registerer = nil
+			asyncWriter, _ = NewAsyncWriter(fileWriter, cfg.Async)
+		}
+		fileSyncer = asyncWriter
+		closers = append(closers, asyncWriter.Close)
+	}
 
 	fileCore := zapcore.NewTee(
 		zapcore.NewCore(fileEncoder, fileSyncer, zap.InfoLevel),
@@ -72,7 +96,16 @@ func New(config ...Config) Logger {
 		log = log.WithOptions(zap.Development())
 	}
 
-	return &zapLogger{log: log}
+	l := &zapLogger{log: log, closers: closers}
+	if asyncErr != nil {
+		l.Warn("async log writer metrics are not exported", "error", asyncErr)
+	}
+	return l
+}
+
+// NewFromZap wraps an existing zap logger, for callers that build their own cores
+func NewFromZap(log *zap.Logger) Logger {
+	return &zapLogger{log: log.WithOptions(zap.AddCallerSkip(1))}
 }
 
 func structToFields(obj interface{}) []zap.Field {
@@ -211,6 +244,10 @@ func valueToField(name string, v reflect.Value) *zap.Field {
 
 	default:
 		if v.IsValid() && !isEmptyValue(v) {
+			if err, ok := v.Interface().(error); ok {
+				field := errorField(name, err)
+				return &field
+			}
 			field := zap.Any(name, v.Interface())
 			return &field
 		}
@@ -220,7 +257,7 @@ func valueToField(name string, v reflect.Value) *zap.Field {
 }
 
 // processFields converts interface{} slice to zap.Field slice with proper key handling
-func (l *zapLogger) processFields(fields ...interface{}) []zap.Field {
+func processFields(fields ...interface{}) []zap.Field {
 	zapFields := make([]zap.Field, 0, len(fields))
 
 	for i := 0; i < len(fields); i++ {
@@ -277,36 +314,41 @@ func processKeyValue(key string, value interface{}) zap.Field {
 	case bool:
 		return zap.Bool(key, v)
 	case error:
-		return zap.Error(v)
+		return errorField(key, v)
 	default:
 		return zap.Any(key, v)
 	}
 }
 
 func (l *zapLogger) Debug(msg string, fields ...interface{}) {
-	l.log.Debug(msg, l.processFields(fields...)...)
+	l.log.Debug(msg, processFields(fields...)...)
 }
 
 func (l *zapLogger) Info(msg string, fields ...interface{}) {
-	l.log.Info(msg, l.processFields(fields...)...)
+	l.log.Info(msg, processFields(fields...)...)
 }
 
 func (l *zapLogger) Warn(msg string, fields ...interface{}) {
-	l.log.Warn(msg, l.processFields(fields...)...)
+	l.log.Warn(msg, processFields(fields...)...)
 }
 
 func (l *zapLogger) Error(msg string, fields ...interface{}) {
-	l.log.Error(msg, l.processFields(fields...)...)
+	l.log.Error(msg, processFields(fields...)...)
 }
 
 func (l *zapLogger) Fatal(msg string, fields ...interface{}) {
-	l.log.Fatal(msg, l.processFields(fields...)...)
+	l.log.Fatal(msg, processFields(fields...)...)
 }
 
 func (l *zapLogger) With(fields ...interface{}) Logger {
-	return &zapLogger{log: l.log.With(l.processFields(fields...)...)}
+	return &zapLogger{log: l.log.With(processFields(fields...)...)}
 }
 
+// Close syncs the logger. The logger returned by New also closes its file
+// and async writer; on a logger derived with With, Close only syncs
 func (l *zapLogger) Close() {
 	_ = l.log.Sync()
+	for _, closer := range l.closers {
+		_ = closer()
+	}
 }
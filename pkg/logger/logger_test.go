@@ -0,0 +1,24 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestWithDoesNotCloseParentOutputs(t *testing.T) {
+	closed := 0
+	parent := &zapLogger{log: zap.NewNop(), closers: []func() error{func() error {
+		closed++
+		return nil
+	}}}
+
+	parent.With("request_id", "abc").Close()
+	if closed != 0 {
+		t.Fatalf("closing a With logger closed the parent's outputs %d times", closed)
+	}
+	parent.Close()
+	if closed != 1 {
+		t.Errorf("closing the parent ran its closers %d times, want 1", closed)
+	}
+}
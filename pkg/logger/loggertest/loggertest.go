@@ -0,0 +1,164 @@
+// Package loggertest provides an in-memory logger.Logger for asserting on logs in tests
+package loggertest
+
+import (
+	"common/pkg/logger"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Level is the severity of a recorded entry
+type Level = zapcore.Level
+
+// Levels re-exported so tests do not need to import zapcore
+const (
+	DebugLevel = zapcore.DebugLevel
+	InfoLevel  = zapcore.InfoLevel
+	WarnLevel  = zapcore.WarnLevel
+	ErrorLevel = zapcore.ErrorLevel
+	FatalLevel = zapcore.FatalLevel
+)
+
+// Entry is a single recorded log entry
+type Entry = observer.LoggedEntry
+
+// Recorder holds the entries written to a logger returned by New
+type Recorder struct {
+	logs *observer.ObservedLogs
+}
+
+// New returns a logger that records every entry at or above the given level
+// (debug by default). Fatal entries panic instead of exiting the process
+func New(level ...Level) (logger.Logger, *Recorder) {
+	minLevel := DebugLevel
+	if len(level) > 0 {
+		minLevel = level[0]
+	}
+
+	core, logs := observer.New(minLevel)
+	log := zap.New(core, zap.AddCaller(), zap.WithFatalHook(zapcore.WriteThenPanic))
+
+	return logger.NewFromZap(log), &Recorder{logs: logs}
+}
+
+// All returns every recorded entry
+func (r *Recorder) All() []Entry {
+	return r.logs.All()
+}
+
+// Len returns the number of recorded entries
+func (r *Recorder) Len() int {
+	return r.logs.Len()
+}
+
+// Reset discards all recorded entries
+func (r *Recorder) Reset() {
+	r.logs.TakeAll()
+}
+
+// FilterLevel returns the entries logged at exactly the given level
+func (r *Recorder) FilterLevel(level Level) *Recorder {
+	return &Recorder{logs: r.logs.FilterLevelExact(level)}
+}
+
+// FilterMessage returns the entries with the given message
+func (r *Recorder) FilterMessage(msg string) *Recorder {
+	return &Recorder{logs: r.logs.FilterMessage(msg)}
+}
+
+// FilterMessageContains returns the entries whose message contains the snippet
+func (r *Recorder) FilterMessageContains(snippet string) *Recorder {
+	return &Recorder{logs: r.logs.FilterMessageSnippet(snippet)}
+}
+
+// FilterField returns the entries that carry the key with a matching value
+func (r *Recorder) FilterField(key string, value interface{}) *Recorder {
+	return &Recorder{logs: r.logs.Filter(func(e Entry) bool {
+		got, ok := e.ContextMap()[key]
+		return ok && valuesEqual(value, got)
+	})}
+}
+
+// FilterFieldKey returns the entries that carry the key, whatever its value
+func (r *Recorder) FilterFieldKey(key string) *Recorder {
+	return &Recorder{logs: r.logs.FilterFieldKey(key)}
+}
+
+// AssertLogged fails the test unless an entry with the level and message was
+// recorded carrying all of the given fields
+func (r *Recorder) AssertLogged(t testing.TB, level Level, msg string, fields map[string]interface{}) {
+	t.Helper()
+
+	matches := r.FilterLevel(level).FilterMessage(msg)
+	if matches.Len() == 0 {
+		t.Errorf("no %s entry with message %q was logged\n%s", level, msg, r.dump())
+		return
+	}
+
+	var mismatches []string
+	for _, e := range matches.All() {
+		diff := fieldDiff(e.ContextMap(), fields)
+		if len(diff) == 0 {
+			return
+		}
+		mismatches = append(mismatches, strings.Join(diff, ", "))
+	}
+
+	t.Errorf("%s entry %q was logged but fields did not match:\n  %s", level, msg, strings.Join(mismatches, "\n  "))
+}
+
+// AssertNotLogged fails the test if an entry with the level and message was recorded
+func (r *Recorder) AssertNotLogged(t testing.TB, level Level, msg string) {
+	t.Helper()
+
+	if n := r.FilterLevel(level).FilterMessage(msg).Len(); n > 0 {
+		t.Errorf("expected no %s entry with message %q, found %d", level, msg, n)
+	}
+}
+
+// dump renders the recorded entries for failure messages
+func (r *Recorder) dump() string {
+	entries := r.logs.All()
+	if len(entries) == 0 {
+		return "no entries were recorded"
+	}
+
+	lines := make([]string, 0, len(entries)+1)
+	lines = append(lines, "recorded entries:")
+	for _, e := range entries {
+		lines = append(lines, fmt.Sprintf("  %s %q %v", e.Level, e.Message, e.ContextMap()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fieldDiff lists the expected fields that are missing or differ in got
+func fieldDiff(got, want map[string]interface{}) []string {
+	var diff []string
+	for key, value := range want {
+		actual, ok := got[key]
+		if !ok {
+			diff = append(diff, fmt.Sprintf("%s missing", key))
+			continue
+		}
+		if !valuesEqual(value, actual) {
+			diff = append(diff, fmt.Sprintf("%s = %v, want %v", key, actual, value))
+		}
+	}
+	return diff
+}
+
+// valuesEqual compares an expected value with one decoded by the observer.
+// Fields are stored with their zap encoding (int64 for ints, string for named
+// string types), so values that print identically are treated as equal
+func valuesEqual(want, got interface{}) bool {
+	if reflect.DeepEqual(want, got) {
+		return true
+	}
+	return fmt.Sprint(want) == fmt.Sprint(got)
+}
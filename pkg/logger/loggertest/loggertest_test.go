@@ -0,0 +1,109 @@
+package loggertest
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewLevelFiltering(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   []Level
+		wantLen int
+	}{
+		{"debug by default", nil, 4},
+		{"info", []Level{InfoLevel}, 3},
+		{"warn", []Level{WarnLevel}, 2},
+		{"error", []Level{ErrorLevel}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, recorder := New(tt.level...)
+			log.Debug("debug")
+			log.Info("info")
+			log.Warn("warn")
+			log.Error("error")
+
+			if recorder.Len() != tt.wantLen {
+				t.Errorf("recorded %d entries, want %d", recorder.Len(), tt.wantLen)
+			}
+			recorder.AssertLogged(t, ErrorLevel, "error", nil)
+		})
+	}
+}
+
+func TestRecorderFilters(t *testing.T) {
+	log, recorder := New()
+	log.Info("user created", "user_id", 7)
+	log.Info("user deleted", "user_id", 8)
+	log.Warn("user created", "user_id", 9)
+
+	if n := recorder.FilterLevel(InfoLevel).Len(); n != 2 {
+		t.Errorf("FilterLevel(info) = %d entries, want 2", n)
+	}
+	if n := recorder.FilterMessage("user created").Len(); n != 2 {
+		t.Errorf("FilterMessage = %d entries, want 2", n)
+	}
+	if n := recorder.FilterMessageContains("user").Len(); n != 3 {
+		t.Errorf("FilterMessageContains = %d entries, want 3", n)
+	}
+	if n := recorder.FilterField("user_id", 8).Len(); n != 1 {
+		t.Errorf("FilterField = %d entries, want 1", n)
+	}
+	if n := recorder.FilterFieldKey("user_id").Len(); n != 3 {
+		t.Errorf("FilterFieldKey = %d entries, want 3", n)
+	}
+
+	recorder.Reset()
+	if recorder.Len() != 0 {
+		t.Errorf("recorded %d entries after Reset, want 0", recorder.Len())
+	}
+}
+
+func TestWithInheritsFields(t *testing.T) {
+	log, recorder := New()
+	child := log.With("request_id", "req-1").With("user_id", 42)
+
+	child.Info("handled", "status", 200)
+	log.Info("root")
+
+	recorder.AssertLogged(t, InfoLevel, "handled", map[string]interface{}{
+		"request_id": "req-1",
+		"user_id":    42,
+		"status":     200,
+	})
+	if n := recorder.FilterMessage("root").FilterFieldKey("request_id").Len(); n != 0 {
+		t.Errorf("parent logger picked up %d child fields", n)
+	}
+}
+
+func TestCallerSkip(t *testing.T) {
+	log, recorder := New()
+	log.Info("here")
+
+	entries := recorder.All()
+	if len(entries) != 1 {
+		t.Fatalf("recorded %d entries, want 1", len(entries))
+	}
+	caller := entries[0].Caller
+	if !caller.Defined {
+		t.Fatal("caller was not recorded")
+	}
+	if got := filepath.Base(caller.File); got != "loggertest_test.go" {
+		t.Errorf("caller file = %s, want loggertest_test.go", got)
+	}
+}
+
+func TestFatalPanics(t *testing.T) {
+	log, recorder := New()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Fatal to panic")
+		}
+		recorder.AssertLogged(t, FatalLevel, "boom", map[string]interface{}{"code": 1})
+	}()
+
+	log.Fatal("boom", "code", 1)
+}
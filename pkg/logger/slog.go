@@ -0,0 +1,261 @@
+package logger
+
+import (
+	"common/pkg/correlation"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelFatal is the slog level used for Fatal entries, one step above slog.LevelError
+const LevelFatal = slog.Level(12)
+
+// zapHandler implements slog.Handler on top of a zap core
+type zapHandler struct {
+	core zapcore.Core
+}
+
+// Slog returns a slog.Handler that writes through the same cores as the logger
+func (l *zapLogger) Slog() slog.Handler {
+	return &zapHandler{core: l.log.Core()}
+}
+
+func (h *zapHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(slogToZapLevel(level))
+}
+
+// Handle adds the request and correlation IDs of ctx, so slog's *Context
+// methods tag entries without passing the IDs explicitly
+func (h *zapHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := zapcore.Entry{
+		Level:   slogToZapLevel(record.Level),
+		Time:    record.Time,
+		Message: record.Message,
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, frame.PC != 0)
+	}
+
+	ce := h.core.Check(entry, nil)
+	if ce == nil {
+		return nil
+	}
+
+	fields := make([]zap.Field, 0, record.NumAttrs()+2)
+	hasRequestID := false
+	record.Attrs(func(attr slog.Attr) bool {
+		hasRequestID = hasRequestID || attr.Key == "request_id"
+		if field, ok := attrToField(attr); ok {
+			fields = append(fields, field)
+		}
+		return true
+	})
+	if ids, ok := correlation.FromContext(ctx); ok && !hasRequestID {
+		fields = append(fields, zap.String("request_id", ids.RequestID), zap.String("correlation_id", ids.CorrelationID))
+	}
+
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *zapHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		if field, ok := attrToField(attr); ok {
+			fields = append(fields, field)
+		}
+	}
+	return &zapHandler{core: h.core.With(fields)}
+}
+
+func (h *zapHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &zapHandler{core: h.core.With([]zap.Field{zap.Namespace(name)})}
+}
+
+// slogLogger implements Logger on top of any slog.Handler
+type slogLogger struct {
+	handler slog.Handler
+}
+
+// NewSlog wraps a slog.Handler as a Logger. Fields are accepted in the same
+// forms as the zap backed logger (key-value pairs, maps, structs and zap.Field)
+func NewSlog(handler slog.Handler) Logger {
+	return &slogLogger{handler: handler}
+}
+
+func (l *slogLogger) log(level slog.Level, msg string, fields ...interface{}) {
+	ctx := context.Background()
+	if !l.handler.Enabled(ctx, level) {
+		return
+	}
+
+	var pcs [1]uintptr
+	// skip runtime.Callers, this function and the exported level method
+	runtime.Callers(3, pcs[:])
+
+	record := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	record.AddAttrs(fieldsToAttrs(processFields(fields...))...)
+	_ = l.handler.Handle(ctx, record)
+}
+
+func (l *slogLogger) Debug(msg string, fields ...interface{}) {
+	l.log(slog.LevelDebug, msg, fields...)
+}
+
+func (l *slogLogger) Info(msg string, fields ...interface{}) {
+	l.log(slog.LevelInfo, msg, fields...)
+}
+
+func (l *slogLogger) Warn(msg string, fields ...interface{}) {
+	l.log(slog.LevelWarn, msg, fields...)
+}
+
+func (l *slogLogger) Error(msg string, fields ...interface{}) {
+	l.log(slog.LevelError, msg, fields...)
+}
+
+func (l *slogLogger) Fatal(msg string, fields ...interface{}) {
+	l.log(LevelFatal, msg, fields...)
+	os.Exit(1)
+}
+
+func (l *slogLogger) With(fields ...interface{}) Logger {
+	return &slogLogger{handler: l.handler.WithAttrs(fieldsToAttrs(processFields(fields...)))}
+}
+
+func (l *slogLogger) Slog() slog.Handler {
+	return l.handler
+}
+
+func (l *slogLogger) Close() {}
+
+// slogToZapLevel maps slog levels onto the closest zap level
+func slogToZapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= LevelFatal:
+		return zapcore.FatalLevel
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// attrToField converts a slog.Attr to a zap.Field, reporting false for empty attributes
+func attrToField(attr slog.Attr) (zap.Field, bool) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return zap.Skip(), false
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return zap.String(attr.Key, attr.Value.String()), true
+	case slog.KindInt64:
+		return zap.Int64(attr.Key, attr.Value.Int64()), true
+	case slog.KindUint64:
+		return zap.Uint64(attr.Key, attr.Value.Uint64()), true
+	case slog.KindFloat64:
+		return zap.Float64(attr.Key, attr.Value.Float64()), true
+	case slog.KindBool:
+		return zap.Bool(attr.Key, attr.Value.Bool()), true
+	case slog.KindDuration:
+		return zap.Duration(attr.Key, attr.Value.Duration()), true
+	case slog.KindTime:
+		return zap.Time(attr.Key, attr.Value.Time()), true
+	case slog.KindGroup:
+		group := attrGroup(attr.Value.Group())
+		if attr.Key == "" {
+			return zap.Inline(group), true
+		}
+		return zap.Object(attr.Key, group), true
+	default:
+		if err, ok := attr.Value.Any().(error); ok {
+			return errorField(attr.Key, err), true
+		}
+		return zap.Any(attr.Key, attr.Value.Any()), true
+	}
+}
+
+// attrGroup marshals a slog group as a nested zap object
+type attrGroup []slog.Attr
+
+func (g attrGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, attr := range g {
+		if field, ok := attrToField(attr); ok {
+			field.AddTo(enc)
+		}
+	}
+	return nil
+}
+
+// fieldsToAttrs converts zap fields to slog attributes. Fields that expand to
+// several keys (such as inline objects) produce one attribute per key
+func fieldsToAttrs(fields []zap.Field) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		attrs = append(attrs, fieldToAttrs(field)...)
+	}
+	return attrs
+}
+
+func fieldToAttrs(field zap.Field) []slog.Attr {
+	switch field.Type {
+	case zapcore.SkipType, zapcore.NamespaceType:
+		return nil
+	case zapcore.StringType:
+		return []slog.Attr{slog.String(field.Key, field.String)}
+	case zapcore.BoolType:
+		return []slog.Attr{slog.Bool(field.Key, field.Integer == 1)}
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return []slog.Attr{slog.Int64(field.Key, field.Integer)}
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type:
+		return []slog.Attr{slog.Uint64(field.Key, uint64(field.Integer))}
+	case zapcore.DurationType:
+		return []slog.Attr{slog.Duration(field.Key, time.Duration(field.Integer))}
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok {
+			return []slog.Attr{slog.Any(field.Key, err)}
+		}
+	case zapcore.StringerType:
+		if s, ok := field.Interface.(fmt.Stringer); ok {
+			return []slog.Attr{slog.String(field.Key, s.String())}
+		}
+	case zapcore.ReflectType:
+		return []slog.Attr{slog.Any(field.Key, field.Interface)}
+	}
+
+	// Fall back to zap's own encoding for the remaining field types
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+
+	keys := make([]string, 0, len(enc.Fields))
+	for key := range enc.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, enc.Fields[key]))
+	}
+	return attrs
+}
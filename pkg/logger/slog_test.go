@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"bytes"
+	"common/pkg/correlation"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandlerWritesToZapCore(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := &zapLogger{log: zap.New(core)}
+
+	log := slog.New(l.Slog()).With("service", "users")
+	log.Debug("dropped")
+	log.Info("created", "id", 42, slog.Group("http", "method", "POST"))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	if fields["service"] != "users" {
+		t.Errorf("service = %v, want users", fields["service"])
+	}
+	if fields["id"] != int64(42) {
+		t.Errorf("id = %v, want 42", fields["id"])
+	}
+	group, ok := fields["http"].(map[string]interface{})
+	if !ok || group["method"] != "POST" {
+		t.Errorf("http = %v, want map with method POST", fields["http"])
+	}
+}
+
+func TestNewSlogConvertsFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlog(slog.NewJSONHandler(&buf, nil))
+
+	l.With(zap.String("request_id", "abc")).Error("failed",
+		"attempt", 3,
+		"error", errors.New("boom"),
+	)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"level":      "ERROR",
+		"msg":        "failed",
+		"request_id": "abc",
+		"attempt":    float64(3),
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+
+	errObj, ok := record["error"].(map[string]interface{})
+	if !ok || errObj["message"] != "boom" {
+		t.Errorf("error = %v, want object with message boom", record["error"])
+	}
+}
+
+func TestCorrelationFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := &zapLogger{log: zap.New(core)}
+	ctx := correlation.NewContext(context.Background(), correlation.IDs{RequestID: "req-1", CorrelationID: "corr-1"})
+
+	slog.New(l.Slog()).InfoContext(ctx, "from slog")
+	WithContext(ctx, l).Info("from logger")
+	WithContext(context.Background(), l).Info("without ids")
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	for _, entry := range entries[:2] {
+		fields := entry.ContextMap()
+		if fields["request_id"] != "req-1" || fields["correlation_id"] != "corr-1" {
+			t.Errorf("%s: fields = %v", entry.Message, fields)
+		}
+	}
+	if _, ok := entries[2].ContextMap()["request_id"]; ok {
+		t.Error("entry without ids has a request_id")
+	}
+}
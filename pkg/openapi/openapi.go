@@ -0,0 +1,82 @@
+// Package openapi models OpenAPI 3.1 documents and derives JSON Schemas from
+// Go types, including the constraints expressed in validate tags
+package openapi
+
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string              `json:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema is the subset of JSON Schema 2020-12 produced by Reflector
+type Schema struct {
+	Ref         string      `json:"$ref,omitempty"`
+	Type        interface{} `json:"type,omitempty"` // a type name, or a list with "null" for pointers
+	Format      string      `json:"format,omitempty"`
+	Description string      `json:"description,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+
+	Enum             []interface{} `json:"enum,omitempty"`
+	Pattern          string        `json:"pattern,omitempty"`
+	Minimum          *float64      `json:"minimum,omitempty"`
+	Maximum          *float64      `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64      `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64      `json:"exclusiveMaximum,omitempty"`
+	MinLength        *int          `json:"minLength,omitempty"`
+	MaxLength        *int          `json:"maxLength,omitempty"`
+	MinItems         *int          `json:"minItems,omitempty"`
+	MaxItems         *int          `json:"maxItems,omitempty"`
+	ContentEncoding  string        `json:"contentEncoding,omitempty"`
+}
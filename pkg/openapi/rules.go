@@ -0,0 +1,139 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// formats maps validate tags to JSON Schema formats
+var formats = map[string]string{
+	"email":    "email",
+	"uuid":     "uuid",
+	"uuid4":    "uuid",
+	"url":      "uri",
+	"uri":      "uri",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"hostname": "hostname",
+}
+
+// patterns maps validate tags to the regular expressions pkg/validator uses
+var patterns = map[string]string{
+	"numeric":      "^[0-9]+$",
+	"alpha":        "^[a-zA-Z]+$",
+	"alphanum":     "^[a-zA-Z0-9]+$",
+	"alphanumeric": "^[a-zA-Z0-9]+$",
+}
+
+// applyRules translates the validate tag of a field into constraints on s.
+// Length rules apply to strings and collections, bound rules to numbers
+func applyRules(s *Schema, t reflect.Type, validate string) {
+	if validate == "" || s.Ref != "" {
+		return
+	}
+
+	kind := indirect(t).Kind()
+	for _, rule := range strings.Split(validate, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		if format, ok := formats[name]; ok {
+			s.Format = format
+			continue
+		}
+		if pattern, ok := patterns[name]; ok {
+			s.Pattern = pattern
+			continue
+		}
+
+		switch name {
+		case "min", "gte":
+			setBound(s, kind, param, &s.Minimum, &s.MinLength, &s.MinItems)
+		case "max", "lte":
+			setBound(s, kind, param, &s.Maximum, &s.MaxLength, &s.MaxItems)
+		case "gt":
+			if isNumber(kind) {
+				s.ExclusiveMinimum = parseFloat(param)
+			} else if n, err := strconv.Atoi(param); err == nil {
+				setBound(s, kind, strconv.Itoa(n+1), &s.Minimum, &s.MinLength, &s.MinItems)
+			}
+		case "lt":
+			if isNumber(kind) {
+				s.ExclusiveMaximum = parseFloat(param)
+			} else if n, err := strconv.Atoi(param); err == nil {
+				setBound(s, kind, strconv.Itoa(n-1), &s.Maximum, &s.MaxLength, &s.MaxItems)
+			}
+		case "len":
+			setBound(s, kind, param, &s.Minimum, &s.MinLength, &s.MinItems)
+			setBound(s, kind, param, &s.Maximum, &s.MaxLength, &s.MaxItems)
+		case "oneof":
+			s.Enum = nil
+			for _, value := range strings.Fields(param) {
+				s.Enum = append(s.Enum, enumValue(kind, value))
+			}
+		}
+	}
+}
+
+func setBound(s *Schema, kind reflect.Kind, param string, number **float64, length, items **int) {
+	switch {
+	case isNumber(kind):
+		*number = parseFloat(param)
+	case kind == reflect.String:
+		if n, err := strconv.Atoi(param); err == nil {
+			*length = integer(n)
+		}
+	case kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map:
+		if n, err := strconv.Atoi(param); err == nil {
+			*items = integer(n)
+		}
+	}
+}
+
+func enumValue(kind reflect.Kind, value string) interface{} {
+	if isNumber(kind) {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func parseFloat(param string) *float64 {
+	f, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+// splitDive separates the rules for a collection's items, which follow
+// "dive", from the rules for the collection itself
+func splitDive(validate string) (items, collection string) {
+	rules := strings.Split(validate, ",")
+	for i, rule := range rules {
+		if rule == "dive" {
+			return strings.Join(rules[i+1:], ","), strings.Join(rules[:i], ",")
+		}
+	}
+	return "", validate
+}
+
+func hasRule(validate, rule string) bool {
+	for _, r := range strings.Split(validate, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const componentsPrefix = "#/components/schemas/"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	byteSliceType     = reflect.TypeOf([]byte(nil))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Reflector builds schemas from Go types. Named struct types become
+// components referenced with $ref, so a type used by several operations is
+// described once
+type Reflector struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func NewReflector() *Reflector {
+	return &Reflector{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Components returns the named schemas collected so far
+func (r *Reflector) Components() map[string]*Schema {
+	return r.schemas
+}
+
+// Schema describes t
+func (r *Reflector) Schema(t reflect.Type) *Schema {
+	return r.schema(t, "")
+}
+
+// Parameters lists the path (uri tag) and query (form tag) parameters of a
+// request struct
+func (r *Reflector) Parameters(t reflect.Type) []Parameter {
+	t = indirect(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []Parameter
+	for _, field := range fields(t) {
+		if name := tagName(field, "uri"); name != "" {
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: r.fieldSchema(field)})
+		} else if name := tagName(field, "form"); name != "" {
+			params = append(params, Parameter{
+				Name:     name,
+				In:       "query",
+				Required: hasRule(field.Tag.Get("validate"), "required"),
+				Schema:   r.fieldSchema(field),
+			})
+		}
+	}
+	return params
+}
+
+// Body describes the JSON body of a request struct: every field that is not
+// a path or query parameter. It returns nil when there are no such fields
+func (r *Reflector) Body(t reflect.Type) *Schema {
+	t = indirect(t)
+	if t.Kind() != reflect.Struct {
+		return r.Schema(t)
+	}
+
+	all := fields(t)
+	var body []reflect.StructField
+	for _, field := range all {
+		if tagName(field, "uri") == "" && tagName(field, "form") == "" && jsonName(field) != "" {
+			body = append(body, field)
+		}
+	}
+	switch {
+	case len(body) == 0:
+		return nil
+	case len(body) == len(all):
+		return r.Schema(t)
+	default:
+		return r.object(body)
+	}
+}
+
+func (r *Reflector) schema(t reflect.Type, validate string) *Schema {
+	if t.Kind() == reflect.Pointer {
+		s := r.schema(t.Elem(), validate)
+		if name, ok := s.Type.(string); ok {
+			s.Type = []string{name, "null"}
+		}
+		return s
+	}
+
+	var s *Schema
+	switch {
+	case t == timeType:
+		s = &Schema{Type: "string", Format: "date-time"}
+	case t == byteSliceType:
+		s = &Schema{Type: "string", ContentEncoding: "base64"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		// custom encodings such as uuid.UUID are strings in practice
+		s = &Schema{Type: "string"}
+		if t.PkgPath() == "github.com/google/uuid" {
+			s.Format = "uuid"
+		}
+	default:
+		switch t.Kind() {
+		case reflect.Bool:
+			s = &Schema{Type: "boolean"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			s = &Schema{Type: "integer"}
+			if t.Kind() == reflect.Int64 {
+				s.Format = "int64"
+			} else if t.Kind() == reflect.Int32 {
+				s.Format = "int32"
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			s = &Schema{Type: "integer", Minimum: float(0)}
+		case reflect.Float32, reflect.Float64:
+			s = &Schema{Type: "number"}
+		case reflect.String:
+			s = &Schema{Type: "string"}
+		case reflect.Slice, reflect.Array:
+			items, rules := splitDive(validate)
+			validate = rules
+			s = &Schema{Type: "array", Items: r.schema(t.Elem(), items)}
+		case reflect.Map:
+			s = &Schema{Type: "object", AdditionalProperties: r.schema(t.Elem(), "")}
+		case reflect.Struct:
+			s = r.structRef(t)
+		default:
+			// interfaces and anything else accept any value
+			s = &Schema{}
+		}
+	}
+
+	applyRules(s, t, validate)
+	return s
+}
+
+// structRef registers a named struct as a component and returns a reference
+// to it. Anonymous structs are described inline
+func (r *Reflector) structRef(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return r.object(fields(t))
+	}
+
+	name, ok := r.names[t]
+	if !ok {
+		name = r.componentName(t)
+		r.names[t] = name
+		// register before describing the fields so recursive types terminate
+		r.schemas[name] = &Schema{}
+		*r.schemas[name] = *r.object(fields(t))
+	}
+	return &Schema{Ref: componentsPrefix + name}
+}
+
+func (r *Reflector) componentName(t reflect.Type) string {
+	name := sanitizeName(t.Name())
+	if _, taken := r.schemas[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	name = sanitizeName(pkg) + "." + name
+	for i := 2; ; i++ {
+		candidate := name + strconv.Itoa(i)
+		if _, taken := r.schemas[candidate]; !taken {
+			return candidate
+		}
+	}
+}
+
+func (r *Reflector) object(fields []reflect.StructField) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, field := range fields {
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+		s.Properties[name] = r.fieldSchema(field)
+		if _, rules := splitDive(field.Tag.Get("validate")); hasRule(rules, "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+func (r *Reflector) fieldSchema(field reflect.StructField) *Schema {
+	s := r.schema(field.Type, field.Tag.Get("validate"))
+	if description := field.Tag.Get("description"); description != "" {
+		if s.Ref != "" {
+			// $ref siblings are allowed in 3.1, but keep the component intact
+			return &Schema{Ref: s.Ref, Description: description}
+		}
+		s.Description = description
+	}
+	return s
+}
+
+// fields lists the exported fields of t, flattening embedded structs the way
+// encoding/json does
+func fields(t reflect.Type) []reflect.StructField {
+	var out []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			if embedded := indirect(field.Type); embedded.Kind() == reflect.Struct {
+				out = append(out, fields(embedded)...)
+				continue
+			}
+		}
+		if field.IsExported() {
+			out = append(out, field)
+		}
+	}
+	return out
+}
+
+func jsonName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+func tagName(field reflect.StructField, key string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+func float(v float64) *float64 { return &v }
+func integer(v int) *int       { return &v }
@@ -0,0 +1,121 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type createUserRequest struct {
+	OrgID   string            `uri:"org_id" validate:"required,uuid"`
+	DryRun  bool              `form:"dry_run"`
+	Name    string            `json:"name" validate:"required,min=2,max=50" description:"Display name"`
+	Email   string            `json:"email" validate:"required,email"`
+	Age     int               `json:"age" validate:"gte=18,lt=130"`
+	Role    string            `json:"role" validate:"oneof=admin member"`
+	Tags    []string          `json:"tags" validate:"max=5,dive,alphanum,min=1"`
+	Address *address          `json:"address"`
+	Born    time.Time         `json:"born"`
+	Labels  map[string]string `json:"labels"`
+	Secret  string            `json:"-"`
+}
+
+func TestParameters(t *testing.T) {
+	params := NewReflector().Parameters(reflect.TypeOf(createUserRequest{}))
+	if len(params) != 2 {
+		t.Fatalf("got %d parameters, want 2", len(params))
+	}
+
+	tests := []struct {
+		index    int
+		name, in string
+		required bool
+		format   string
+	}{
+		{0, "org_id", "path", true, "uuid"},
+		{1, "dry_run", "query", false, ""},
+	}
+	for _, tt := range tests {
+		p := params[tt.index]
+		if p.Name != tt.name || p.In != tt.in || p.Required != tt.required || p.Schema.Format != tt.format {
+			t.Errorf("parameter %d = %+v (schema %+v)", tt.index, p, p.Schema)
+		}
+	}
+}
+
+func TestBody(t *testing.T) {
+	r := NewReflector()
+	body := r.Body(reflect.TypeOf(createUserRequest{}))
+	if body == nil || body.Ref != "" {
+		t.Fatalf("mixed request should have an inline body, got %+v", body)
+	}
+	if _, ok := body.Properties["org_id"]; ok {
+		t.Error("path parameter leaked into body")
+	}
+	if _, ok := body.Properties["Secret"]; ok {
+		t.Error(`json:"-" field leaked into body`)
+	}
+	if !reflect.DeepEqual(body.Required, []string{"name", "email"}) {
+		t.Errorf("required = %v", body.Required)
+	}
+
+	props := body.Properties
+	tests := []struct {
+		name  string
+		check bool
+	}{
+		{"name length", *props["name"].MinLength == 2 && *props["name"].MaxLength == 50},
+		{"name description", props["name"].Description == "Display name"},
+		{"email format", props["email"].Format == "email"},
+		{"age bounds", *props["age"].Minimum == 18 && *props["age"].ExclusiveMaximum == 130},
+		{"role enum", reflect.DeepEqual(props["role"].Enum, []interface{}{"admin", "member"})},
+		{"tags items", *props["tags"].MaxItems == 5 && props["tags"].Items.Pattern == "^[a-zA-Z0-9]+$" && *props["tags"].Items.MinLength == 1},
+		{"address ref", props["address"].Ref == componentsPrefix+"address"},
+		{"born format", props["born"].Format == "date-time"},
+		{"labels map", props["labels"].AdditionalProperties.Type == "string"},
+	}
+	for _, tt := range tests {
+		if !tt.check {
+			t.Errorf("%s: unexpected schema", tt.name)
+		}
+	}
+
+	component, ok := r.Components()["address"]
+	if !ok || !reflect.DeepEqual(component.Required, []string{"city"}) {
+		t.Errorf("address component = %+v", component)
+	}
+}
+
+func TestBodyWithoutBodyFields(t *testing.T) {
+	type getRequest struct {
+		ID string `uri:"id"`
+	}
+	if body := NewReflector().Body(reflect.TypeOf(getRequest{})); body != nil {
+		t.Errorf("body = %+v, want nil", body)
+	}
+}
+
+func TestRecursiveType(t *testing.T) {
+	type node struct {
+		Children []node `json:"children"`
+	}
+	r := NewReflector()
+	s := r.Schema(reflect.TypeOf(node{}))
+	if s.Ref != componentsPrefix+"node" {
+		t.Fatalf("ref = %q", s.Ref)
+	}
+	if items := r.Components()["node"].Properties["children"].Items; items.Ref != s.Ref {
+		t.Errorf("children items = %+v", items)
+	}
+}
+
+func TestPointerIsNullable(t *testing.T) {
+	s := NewReflector().Schema(reflect.TypeOf((*int)(nil)))
+	if !reflect.DeepEqual(s.Type, []string{"integer", "null"}) {
+		t.Errorf("type = %v", s.Type)
+	}
+}
@@ -0,0 +1,150 @@
+package payloadcrypto
+
+import (
+	"common/pkg/redis"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// memoryKeyProvider keeps keys in process, for tests and single-instance
+// setups
+type memoryKeyProvider struct {
+	mu   sync.RWMutex
+	keys map[string]Key
+}
+
+// NewMemoryKeyProvider creates a provider holding keys
+func NewMemoryKeyProvider(keys ...Key) *memoryKeyProvider {
+	p := &memoryKeyProvider{keys: make(map[string]Key)}
+	for _, key := range keys {
+		p.keys[key.ID] = key
+	}
+	return p
+}
+
+func (p *memoryKeyProvider) Key(ctx context.Context, id string) (*Key, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return &key, nil
+}
+
+// Put creates or replaces the key with the same ID
+func (p *memoryKeyProvider) Put(ctx context.Context, key Key) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[key.ID] = key
+	return nil
+}
+
+// redisKeyProvider keeps each key as JSON under <prefix>:<id>. Keys with an
+// expiry are removed by Redis once they expire, which suits session keys
+type redisKeyProvider struct {
+	redis  redis.Redis
+	prefix string
+}
+
+// NewRedisKeyProvider creates a provider that namespaces its keys with prefix
+func NewRedisKeyProvider(r redis.Redis, prefix string) *redisKeyProvider {
+	if prefix == "" {
+		prefix = "payloadcrypto:key"
+	}
+	return &redisKeyProvider{redis: r, prefix: prefix}
+}
+
+func (p *redisKeyProvider) Key(ctx context.Context, id string) (*Key, error) {
+	data, err := p.redis.Get(p.prefix + ":" + id).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var key Key
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Put creates or replaces the key with the same ID
+func (p *redisKeyProvider) Put(ctx context.Context, key Key) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	var ttl time.Duration
+	if !key.ExpiresAt.IsZero() {
+		if ttl = time.Until(key.ExpiresAt); ttl <= 0 {
+			return nil
+		}
+	}
+	return p.redis.Set(p.prefix+":"+key.ID, data, ttl).Err()
+}
+
+// PostgresSchema creates the table used by the Postgres provider; add it to
+// the service's migrations. Secrets should be protected at rest, for example
+// with column or disk encryption
+const PostgresSchema = `
+CREATE TABLE IF NOT EXISTS payload_keys (
+	id         TEXT PRIMARY KEY,
+	owner      TEXT NOT NULL,
+	secret     BYTEA NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS payload_keys_owner ON payload_keys (owner);
+`
+
+// postgresKeyProvider reads keys from the payload_keys table
+type postgresKeyProvider struct {
+	db *sql.DB
+}
+
+// NewPostgresKeyProvider uses db, typically the *sql.DB embedded in database.DB
+func NewPostgresKeyProvider(db *sql.DB) *postgresKeyProvider {
+	return &postgresKeyProvider{db: db}
+}
+
+func (p *postgresKeyProvider) Key(ctx context.Context, id string) (*Key, error) {
+	var key Key
+	var expiresAt sql.NullTime
+	err := p.db.QueryRowContext(ctx,
+		`SELECT id, owner, secret, created_at, expires_at FROM payload_keys WHERE id = $1`, id,
+	).Scan(&key.ID, &key.Owner, &key.Secret, &key.CreatedAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	key.ExpiresAt = expiresAt.Time
+	return &key, nil
+}
+
+// Put creates or replaces the key with the same ID
+func (p *postgresKeyProvider) Put(ctx context.Context, key Key) error {
+	var expiresAt sql.NullTime
+	if !key.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: key.ExpiresAt, Valid: true}
+	}
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO payload_keys (id, owner, secret, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET owner = EXCLUDED.owner, secret = EXCLUDED.secret, expires_at = EXCLUDED.expires_at`,
+		key.ID, key.Owner, key.Secret, key.CreatedAt, expiresAt,
+	)
+	return err
+}
@@ -0,0 +1,84 @@
+package payloadcrypto
+
+import (
+	"common/pkg/redis"
+	"context"
+	"sync"
+	"time"
+)
+
+// NonceStore records used nonces. Implementations must be safe for
+// concurrent use
+type NonceStore interface {
+	// Claim records nonce for ttl, or returns ErrReplay if it is already held
+	Claim(ctx context.Context, nonce string, ttl time.Duration) error
+}
+
+// memoryNonceStore keeps nonces in process, for tests and single-instance
+// setups
+type memoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	claims int
+	now    func() time.Time
+}
+
+// NewMemoryNonceStore creates an in-process nonce store
+func NewMemoryNonceStore() *memoryNonceStore {
+	return &memoryNonceStore{nonces: make(map[string]time.Time), now: time.Now}
+}
+
+func (s *memoryNonceStore) Claim(ctx context.Context, nonce string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if expiresAt, ok := s.nonces[nonce]; ok && now.Before(expiresAt) {
+		return ErrReplay
+	}
+	s.nonces[nonce] = now.Add(ttl)
+
+	// sweep expired nonces now and then instead of on every claim
+	s.claims++
+	if s.claims%1024 == 0 {
+		for n, expiresAt := range s.nonces {
+			if !now.Before(expiresAt) {
+				delete(s.nonces, n)
+			}
+		}
+	}
+	return nil
+}
+
+const claimNonceScript = `
+if redis.call("SET", KEYS[1], "1", "NX", "PX", ARGV[1]) then
+	return 1
+end
+return 0
+`
+
+// redisNonceStore keeps each nonce under <prefix>:<nonce> until it expires,
+// so replays are caught across instances
+type redisNonceStore struct {
+	redis  redis.Redis
+	prefix string
+}
+
+// NewRedisNonceStore creates a store that namespaces its keys with prefix
+func NewRedisNonceStore(r redis.Redis, prefix string) *redisNonceStore {
+	if prefix == "" {
+		prefix = "payloadcrypto:nonce"
+	}
+	return &redisNonceStore{redis: r, prefix: prefix}
+}
+
+func (s *redisNonceStore) Claim(ctx context.Context, nonce string, ttl time.Duration) error {
+	claimed, err := s.redis.Eval(claimNonceScript, []string{s.prefix + ":" + nonce}, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if claimed == 0 {
+		return ErrReplay
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Package payloadcrypto encrypts request and response payloads end to end
+// with keys that belong to a user or session. Every envelope carries the key
+// ID, a random nonce and a timestamp, all covered by an HMAC, so a payload
+// cannot be altered, moved to another key or replayed outside its window.
+//
+// An envelope is sealed with two keys derived from the shared secret:
+//
+//	encryption key = HMAC-SHA256(secret, "payloadcrypto/encrypt")
+//	mac key        = HMAC-SHA256(secret, "payloadcrypto/mac")
+//	data           = hex(gcm nonce || AES-256-GCM(plaintext))
+//	mac            = hex(HMAC-SHA256(mac key, key_id "\n" nonce "\n" timestamp "\n" data))
+package payloadcrypto
+
+import (
+	"common/pkg/utils/encryption"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// DefaultWindow is how far an envelope timestamp may drift from the
+// server clock
+const DefaultWindow = 5 * time.Minute
+
+// SecretSize is the length of key secrets in bytes
+const SecretSize = 32
+
+var (
+	ErrKeyNotFound = errors.New("payloadcrypto: key not found")
+	ErrInvalidKey  = errors.New("payloadcrypto: key is expired or not owned by the caller")
+	ErrMalformed   = errors.New("payloadcrypto: malformed envelope")
+	ErrIntegrity   = errors.New("payloadcrypto: integrity check failed")
+	ErrStale       = errors.New("payloadcrypto: timestamp outside the allowed window")
+	ErrReplay      = errors.New("payloadcrypto: nonce already used")
+)
+
+// Key is a shared secret owned by a user or a session
+type Key struct {
+	ID string `json:"id"`
+	// Owner is the user ID or session ID allowed to use the key
+	Owner     string    `json:"owner"`
+	Secret    []byte    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is zero for keys that do not expire
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Active reports whether the key may be used at now
+func (k Key) Active(now time.Time) bool {
+	return k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt)
+}
+
+// OwnedBy reports whether the key belongs to any of owners
+func (k Key) OwnedBy(owners ...string) bool {
+	for _, owner := range owners {
+		if owner != "" && owner == k.Owner {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyProvider looks up keys by ID. Implementations must be safe for
+// concurrent use
+type KeyProvider interface {
+	// Key returns the key with the given ID, or ErrKeyNotFound
+	Key(ctx context.Context, id string) (*Key, error)
+}
+
+// NewKey creates a key with a random secret for owner. A zero ttl creates a
+// key that does not expire
+func NewKey(owner string, ttl time.Duration) (Key, error) {
+	secret := make([]byte, SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, err
+	}
+	id, err := randomHex(16)
+	if err != nil {
+		return Key{}, err
+	}
+
+	now := time.Now().UTC()
+	key := Key{ID: id, Owner: owner, Secret: secret, CreatedAt: now}
+	if ttl > 0 {
+		key.ExpiresAt = now.Add(ttl)
+	}
+	return key, nil
+}
+
+// Envelope is an encrypted payload with the values needed to verify it
+type Envelope struct {
+	KeyID     string
+	Nonce     string
+	Timestamp int64
+	Data      string
+	MAC       string
+}
+
+// Codec seals and opens envelopes, enforcing the timestamp window and
+// single use of nonces
+type Codec struct {
+	keys   KeyProvider
+	nonces NonceStore
+	window time.Duration
+	now    func() time.Time
+}
+
+// NewCodec creates a codec. A zero window uses DefaultWindow
+func NewCodec(keys KeyProvider, nonces NonceStore, window time.Duration) *Codec {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Codec{keys: keys, nonces: nonces, window: window, now: time.Now}
+}
+
+// Key returns the active key with the given ID if it belongs to one of
+// owners
+func (c *Codec) Key(ctx context.Context, id string, owners ...string) (*Key, error) {
+	if id == "" {
+		return nil, ErrKeyNotFound
+	}
+	key, err := c.keys.Key(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !key.Active(c.now()) || !key.OwnedBy(owners...) {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// Seal encrypts plaintext with key under a fresh nonce and timestamp
+func (c *Codec) Seal(key *Key, plaintext []byte) (*Envelope, error) {
+	nonce, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	data, err := encryption.Encrypt(plaintext, derive(key.Secret, "encrypt"))
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Envelope{KeyID: key.ID, Nonce: nonce, Timestamp: c.now().Unix(), Data: data}
+	env.MAC = hex.EncodeToString(mac(key.Secret, env))
+	return env, nil
+}
+
+// Open verifies env and returns its plaintext. The nonce is only recorded
+// once the MAC is valid, so forged envelopes cannot use up nonces
+func (c *Codec) Open(ctx context.Context, key *Key, env *Envelope) ([]byte, error) {
+	if env.KeyID != key.ID || env.Nonce == "" || env.Data == "" {
+		return nil, ErrMalformed
+	}
+
+	age := c.now().Sub(time.Unix(env.Timestamp, 0))
+	if age > c.window || age < -c.window {
+		return nil, ErrStale
+	}
+
+	expected, err := hex.DecodeString(env.MAC)
+	if err != nil || !hmac.Equal(expected, mac(key.Secret, env)) {
+		return nil, ErrIntegrity
+	}
+
+	// a nonce must stay reserved for as long as its timestamp is accepted
+	if err := c.nonces.Claim(ctx, key.ID+":"+env.Nonce, 2*c.window); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := encryption.Decrypt(env.Data, derive(key.Secret, "encrypt"))
+	if err != nil {
+		return nil, ErrIntegrity
+	}
+	return []byte(plaintext), nil
+}
+
+func mac(secret []byte, env *Envelope) []byte {
+	h := hmac.New(sha256.New, derive(secret, "mac"))
+	h.Write([]byte(env.KeyID + "\n" + env.Nonce + "\n" + strconv.FormatInt(env.Timestamp, 10) + "\n" + env.Data))
+	return h.Sum(nil)
+}
+
+// derive separates the encryption and MAC keys so the secret is never used
+// for both
+func derive(secret []byte, purpose string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte("payloadcrypto/" + purpose))
+	return h.Sum(nil)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
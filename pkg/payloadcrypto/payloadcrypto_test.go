@@ -0,0 +1,128 @@
+package payloadcrypto
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	key, err := NewKey("user-1", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codec := NewCodec(NewMemoryKeyProvider(key), NewMemoryNonceStore(), time.Minute)
+
+	env, err := codec.Seal(&key, []byte(`{"amount":10}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := codec.Open(ctx, &key, env)
+	if err != nil || string(plaintext) != `{"amount":10}` {
+		t.Fatalf("Open = %q, %v", plaintext, err)
+	}
+	if _, err := codec.Open(ctx, &key, env); !errors.Is(err, ErrReplay) {
+		t.Errorf("replayed envelope: err = %v, want ErrReplay", err)
+	}
+}
+
+func TestCodecOpenRejects(t *testing.T) {
+	key, _ := NewKey("user-1", 0)
+	other, _ := NewKey("user-1", 0)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		tamper func(env *Envelope)
+		want   error
+	}{
+		{"tampered data", func(env *Envelope) { env.Data = flipHex(env.Data) }, ErrIntegrity},
+		{"tampered nonce", func(env *Envelope) { env.Nonce = flipHex(env.Nonce) }, ErrIntegrity},
+		{"tampered timestamp", func(env *Envelope) { env.Timestamp-- }, ErrIntegrity},
+		{"missing mac", func(env *Envelope) { env.MAC = "" }, ErrIntegrity},
+		{"other key id", func(env *Envelope) { env.KeyID = other.ID }, ErrMalformed},
+		{"too old", func(env *Envelope) { env.Timestamp = now.Add(-2 * time.Minute).Unix() }, ErrStale},
+		{"from the future", func(env *Envelope) { env.Timestamp = now.Add(2 * time.Minute).Unix() }, ErrStale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := NewCodec(NewMemoryKeyProvider(key), NewMemoryNonceStore(), time.Minute)
+			codec.now = func() time.Time { return now }
+			env, err := codec.Seal(&key, []byte("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.tamper(env)
+			if _, err := codec.Open(context.Background(), &key, env); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// a secret other than the sealing one fails the MAC
+	codec := NewCodec(NewMemoryKeyProvider(key), NewMemoryNonceStore(), time.Minute)
+	env, _ := codec.Seal(&key, []byte("payload"))
+	forged := key
+	forged.Secret = other.Secret
+	if _, err := codec.Open(context.Background(), &forged, env); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("wrong secret: err = %v, want ErrIntegrity", err)
+	}
+}
+
+func TestCodecKey(t *testing.T) {
+	ctx := context.Background()
+	active, _ := NewKey("user-1", time.Hour)
+	session, _ := NewKey("session-1", 0)
+	expired, _ := NewKey("user-1", time.Hour)
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	codec := NewCodec(NewMemoryKeyProvider(active, session, expired), NewMemoryNonceStore(), 0)
+
+	tests := []struct {
+		name   string
+		id     string
+		owners []string
+		want   error
+	}{
+		{"user key", active.ID, []string{"user-1"}, nil},
+		{"session key", session.ID, []string{"user-1", "session-1"}, nil},
+		{"other owner", active.ID, []string{"user-2"}, ErrInvalidKey},
+		{"empty owner", session.ID, []string{"user-2", ""}, ErrInvalidKey},
+		{"expired", expired.ID, []string{"user-1"}, ErrInvalidKey},
+		{"unknown", "missing", []string{"user-1"}, ErrKeyNotFound},
+		{"no id", "", []string{"user-1"}, ErrKeyNotFound},
+	}
+	for _, tt := range tests {
+		if _, err := codec.Key(ctx, tt.id, tt.owners...); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryNonceStore()
+	store.now = func() time.Time { return now }
+
+	if err := store.Claim(ctx, "n1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Claim(ctx, "n1", time.Minute); !errors.Is(err, ErrReplay) {
+		t.Errorf("second claim: err = %v, want ErrReplay", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := store.Claim(ctx, "n1", time.Minute); err != nil {
+		t.Errorf("claim after expiry: %v", err)
+	}
+}
+
+// flipHex changes the first hex digit of s
+func flipHex(s string) string {
+	if s[0] == '0' {
+		return "1" + s[1:]
+	}
+	return "0" + s[1:]
+}
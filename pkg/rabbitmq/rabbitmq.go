@@ -1,6 +1,8 @@
 package rabbitmq
 
 import (
+	"common/pkg/correlation"
+	"context"
 	"fmt"
 	"log"
 
@@ -14,10 +16,40 @@ type RabbitMQService interface {
 	Close() error
 }
 
+// ContextPublisher is implemented by services that can publish message with
+// the correlation IDs in ctx added to its headers
+type ContextPublisher interface {
+	PublishContext(ctx context.Context, queueName string, message Message) error
+}
+
+// Pinger is implemented by services that can report whether the broker
+// connection is still open
+type Pinger interface {
+	Ping() error
+}
+
 type Message struct {
 	Body string
+	// Headers are sent as AMQP message headers; only string values are
+	// read back by Consume
+	Headers map[string]string
+}
+
+// Context returns parent carrying the correlation IDs in the message
+// headers, or parent unchanged when there are none
+func (m Message) Context(parent context.Context) context.Context {
+	carrier := correlation.MapCarrier(m.Headers)
+	if !correlation.Valid(carrier.Get(correlation.HeaderRequestID)) {
+		return parent
+	}
+	return correlation.NewContext(parent, correlation.Extract(carrier, nil))
 }
 
+var (
+	_ ContextPublisher = (*rabbitMQService)(nil)
+	_ Pinger           = (*rabbitMQService)(nil)
+)
+
 type rabbitMQService struct {
 	connection *amqp.Connection
 	channel    *amqp.Channel
@@ -41,6 +73,18 @@ func New(amqpURL string) (RabbitMQService, error) {
 	}, nil
 }
 
+func (r *rabbitMQService) PublishContext(ctx context.Context, queueName string, message Message) error {
+	if _, ok := correlation.FromContext(ctx); ok {
+		headers := make(map[string]string, len(message.Headers)+2)
+		for key, value := range message.Headers {
+			headers[key] = value
+		}
+		correlation.Inject(ctx, correlation.MapCarrier(headers))
+		message.Headers = headers
+	}
+	return r.Publish(queueName, message)
+}
+
 func (r *rabbitMQService) Publish(queueName string, message Message) error {
 	if _, err := r.channel.QueueDeclare(
 		queueName,
@@ -60,8 +104,10 @@ func (r *rabbitMQService) Publish(queueName string, message Message) error {
 		false,     // mandatory
 		false,     // immediate
 		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(message.Body),
+			ContentType:   "text/plain",
+			Headers:       headerTable(message.Headers),
+			CorrelationId: message.Headers[correlation.HeaderCorrelationID],
+			Body:          []byte(message.Body),
 		}); err != nil {
 		return err
 	}
@@ -128,7 +174,7 @@ func (r *rabbitMQService) Consume(queueName string, handler func(message Message
 	// Process messages in a goroutine
 	go func() {
 		for msg := range msgs {
-			err := handler(Message{Body: string(msg.Body)})
+			err := handler(Message{Body: string(msg.Body), Headers: headerMap(msg.Headers)})
 			if err != nil {
 				log.Println("Error handling message:", err)
 			} else {
@@ -143,6 +189,30 @@ func (r *rabbitMQService) Consume(queueName string, handler func(message Message
 	return nil
 }
 
+func headerTable(headers map[string]string) amqp.Table {
+	if len(headers) == 0 {
+		return nil
+	}
+	table := make(amqp.Table, len(headers))
+	for key, value := range headers {
+		table[key] = value
+	}
+	return table
+}
+
+func headerMap(table amqp.Table) map[string]string {
+	if len(table) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(table))
+	for key, value := range table {
+		if s, ok := value.(string); ok {
+			headers[key] = s
+		}
+	}
+	return headers
+}
+
 func (r *rabbitMQService) CreateQueue(queueName string) error {
 	_, err := r.channel.QueueDeclare(
 		queueName, // name
@@ -165,3 +235,11 @@ func (r *rabbitMQService) Close() error {
 	}
 	return r.connection.Close()
 }
+
+// Ping reports an error when the broker connection has been closed
+func (r *rabbitMQService) Ping() error {
+	if r.connection.IsClosed() {
+		return amqp.ErrClosed
+	}
+	return nil
+}
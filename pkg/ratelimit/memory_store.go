@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are removed from a memory store
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	capacity float64
+	rate     float64
+}
+
+// memoryStore keeps buckets in process memory. Limits are per instance
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *memoryStore) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	if err := rule.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(rule.Requests)
+	rate := rule.ratePerSecond()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+	b.capacity = capacity
+	b.rate = rate
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	return newResult(allowed, b.tokens, rule), nil
+}
+
+// sweep drops buckets that have refilled completely, since a new bucket
+// starts full and behaves identically
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.rate >= b.capacity {
+			delete(s.buckets, key)
+		}
+	}
+}
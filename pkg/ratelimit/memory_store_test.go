@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	rule := Rule{Requests: 2, Duration: time.Second}
+
+	for i, want := range []int{1, 0} {
+		result, err := store.Allow(ctx, "client", rule)
+		if err != nil {
+			t.Fatalf("allow: %v", err)
+		}
+		if !result.Allowed || result.Remaining != want {
+			t.Errorf("request %d: allowed=%v remaining=%d, want allowed with %d remaining", i+1, result.Allowed, result.Remaining, want)
+		}
+	}
+
+	result, _ := store.Allow(ctx, "client", rule)
+	if result.Allowed {
+		t.Fatalf("third request in the same instant was allowed")
+	}
+	if result.RetryAfter != 500*time.Millisecond {
+		t.Errorf("retry after = %s, want 500ms", result.RetryAfter)
+	}
+
+	if other, _ := store.Allow(ctx, "other-client", rule); !other.Allowed {
+		t.Errorf("buckets are not isolated per key")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if result, _ := store.Allow(ctx, "client", rule); !result.Allowed {
+		t.Errorf("request after refill was rejected")
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	if _, err := NewMemoryStore().Allow(context.Background(), "k", Rule{Requests: 0, Duration: time.Second}); err != ErrInvalidRule {
+		t.Errorf("allow with zero requests = %v, want ErrInvalidRule", err)
+	}
+}
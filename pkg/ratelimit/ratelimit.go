@@ -0,0 +1,70 @@
+// Package ratelimit implements token bucket rate limiting with in-memory and Redis stores
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrInvalidRule = errors.New("rate limit rule must have positive requests and duration")
+
+// Rule allows Requests per Duration, with bursts of up to Requests
+type Rule struct {
+	Requests int           `json:"requests" yaml:"requests"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+}
+
+// Validate checks that the rule describes a usable rate
+func (r Rule) Validate() error {
+	if r.Requests <= 0 || r.Duration <= 0 {
+		return ErrInvalidRule
+	}
+	return nil
+}
+
+// ratePerSecond is the bucket refill rate
+func (r Rule) ratePerSecond() float64 {
+	return float64(r.Requests) / r.Duration.Seconds()
+}
+
+// Result describes the outcome of a single Allow call
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+
+	// ResetAfter is how long until the bucket is full again
+	ResetAfter time.Duration
+	// RetryAfter is how long until the next request would be allowed; zero when Allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets and takes a token from the bucket for key
+type Store interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// newResult derives the response fields from the tokens left after the request
+func newResult(allowed bool, tokens float64, rule Rule) Result {
+	rate := rule.ratePerSecond()
+
+	result := Result{
+		Allowed:    allowed,
+		Limit:      rule.Requests,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: secondsToDuration((float64(rule.Requests) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"common/pkg/redis"
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// tokenBucketScript refills and takes from a bucket stored as a hash. It uses
+// the Redis server clock so that every instance sees the same time
+const tokenBucketScript = `
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1]) or capacity
+local ts = tonumber(data[2]) or now
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], ttl)
+
+return {allowed, tostring(tokens)}
+`
+
+// redisStore keeps buckets in Redis so limits are shared across instances
+type redisStore struct {
+	redis  redis.Redis
+	prefix string
+}
+
+// NewRedisStore creates a store that namespaces its keys with prefix
+func NewRedisStore(r redis.Redis, prefix string) *redisStore {
+	if prefix == "" {
+		prefix = "ratelimit"
+	}
+	return &redisStore{redis: r, prefix: prefix}
+}
+
+func (s *redisStore) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	if err := rule.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	ratePerMs := rule.ratePerSecond() / 1000
+	ttl := rule.Duration.Milliseconds() + 1000
+
+	values, err := s.redis.Eval(tokenBucketScript, []string{s.prefix + ":" + key}, rule.Requests, ratePerMs, ttl).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit script: %w", err)
+	}
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("rate limit script: unexpected reply %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensStr, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit script: invalid token count %q", tokensStr)
+	}
+
+	return newResult(allowed == 1, tokens, rule), nil
+}
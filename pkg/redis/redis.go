@@ -19,6 +19,8 @@ type Redis interface {
 	SAdd(key string, members ...interface{}) *redis.IntCmd
 	SMembers(key string) *redis.StringSliceCmd
 	SRem(key string, members ...interface{}) *redis.IntCmd
+	Eval(script string, keys []string, args ...interface{}) *redis.Cmd
+	Ping(ctx context.Context) *redis.StatusCmd
 }
 
 type redisService struct {
@@ -127,6 +129,15 @@ func (r *redisService) SetJson(key string, value interface{}) {
 	r.client.Set(context.Background(), key, json, 0)
 }
 
+// Eval runs a Lua script atomically on the server
+func (r *redisService) Eval(script string, keys []string, args ...interface{}) *redis.Cmd {
+	return r.client.Eval(context.Background(), script, keys, args...)
+}
+
+func (r *redisService) Ping(ctx context.Context) *redis.StatusCmd {
+	return r.client.Ping(ctx)
+}
+
 func (r *redisService) Publish(channel string, message string) *redis.IntCmd {
 	return r.client.Publish(context.Background(), channel, message)
 }
@@ -1,6 +1,9 @@
 package scheduler
 
 import (
+	"common/pkg/logger"
+	"common/pkg/logger/adapter"
+
 	"github.com/hibiken/asynq"
 )
 
@@ -8,12 +11,39 @@ type AsynqTaskScheduler struct {
 	scheduler *asynq.Scheduler
 }
 
-func NewAsynqScheduler(redisAddr, redisPassword string) *AsynqTaskScheduler {
+// Option configures the scheduler built by NewAsynqScheduler
+type Option func(*options)
+
+type options struct {
+	logger logger.Logger
+}
+
+// WithLogger routes asynq's own output through the given logger instead of
+// the standard library logger
+func WithLogger(l logger.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// NewAsynqScheduler creates a scheduler
+func NewAsynqScheduler(redisAddr, redisPassword string, opts ...Option) *AsynqTaskScheduler {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	redisConnection := asynq.RedisClientOpt{
 		Addr:     redisAddr,
 		Password: redisPassword,
 	}
-	scheduler := asynq.NewScheduler(redisConnection, &asynq.SchedulerOpts{})
+
+	schedulerOpts := &asynq.SchedulerOpts{}
+	if o.logger != nil {
+		schedulerOpts.Logger = adapter.NewAsynqLogger(o.logger)
+	}
+
+	scheduler := asynq.NewScheduler(redisConnection, schedulerOpts)
 	return &AsynqTaskScheduler{scheduler: scheduler}
 }
 
@@ -30,6 +60,11 @@ func (s *AsynqTaskScheduler) Run() error {
 	return s.scheduler.Run()
 }
 
+// Start runs the scheduler in the background without handling signals
+func (s *AsynqTaskScheduler) Start() error {
+	return s.scheduler.Start()
+}
+
 func (s *AsynqTaskScheduler) Shutdown() {
 	s.scheduler.Shutdown()
 }
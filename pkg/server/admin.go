@@ -0,0 +1,232 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version and Commit override the values read from the binary's build info,
+// for builds without VCS stamping:
+//
+//	go build -ldflags "-X common/pkg/server.Version=1.4.2 -X common/pkg/server.Commit=$(git rev-parse HEAD)"
+var (
+	Version string
+	Commit  string
+)
+
+// AdminConfig enables a second listener for operations endpoints. When
+// enabled, /health and /metrics move from the public router to it, next to
+// /debug/pprof, /info/build, /info/runtime and /info/config
+type AdminConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Address is "host:port" or "unix:/path/to.sock", for example ":9090"
+	Address string `json:"address" yaml:"address"`
+	// Token must be sent as "Authorization: Bearer <token>". Health endpoints
+	// stay open so that orchestrator probes work without it
+	Token string `json:"token" yaml:"token"`
+	// PprofEnabled mounts net/http/pprof under /debug/pprof
+	PprofEnabled bool `json:"pprof_enabled" yaml:"pprof_enabled"`
+}
+
+// redactedKeys are substrings of config keys whose values /info/config hides
+var redactedKeys = []string{"token", "password", "secret", "private"}
+
+const redacted = "[REDACTED]"
+
+func validateAdmin(cfg AdminConfig) error {
+	if cfg.Address == "" {
+		return errors.New("admin: address is required")
+	}
+	if err := validateListenAddress(cfg.Address); err != nil {
+		return fmt.Errorf("admin: %w", err)
+	}
+	if cfg.Token == "" {
+		return errors.New("admin: token is required")
+	}
+	return nil
+}
+
+// newAdminServer builds the admin router and its http.Server
+func newAdminServer(cfg Config) (*gin.Engine, *http.Server) {
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	return router, &http.Server{
+		Addr:         cfg.Admin.Address,
+		Handler:      router,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: 0, // profiles stream for as long as ?seconds= asks
+	}
+}
+
+func (s *server) setupAdmin() {
+	started := time.Now()
+	auth := s.adminAuth()
+
+	if s.cfg.Admin.PprofEnabled {
+		debugGroup := s.admin.Group("/debug/pprof", auth)
+		{
+			debugGroup.GET("/", gin.WrapF(pprof.Index))
+			debugGroup.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+			debugGroup.GET("/profile", gin.WrapF(pprof.Profile))
+			debugGroup.POST("/symbol", gin.WrapF(pprof.Symbol))
+			debugGroup.GET("/symbol", gin.WrapF(pprof.Symbol))
+			debugGroup.GET("/trace", gin.WrapF(pprof.Trace))
+			debugGroup.GET("/:profile", func(c *gin.Context) {
+				pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+			})
+		}
+	}
+
+	info := s.admin.Group("/info", auth)
+	{
+		info.GET("/build", func(c *gin.Context) {
+			c.JSON(http.StatusOK, buildInfo())
+		})
+		info.GET("/runtime", func(c *gin.Context) {
+			c.JSON(http.StatusOK, runtimeStats(started))
+		})
+		info.GET("/config", func(c *gin.Context) {
+			config, err := redactConfig(s.cfg)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, config)
+		})
+	}
+}
+
+// adminAuth checks the bearer token in constant time
+func (s *server) adminAuth() gin.HandlerFunc {
+	token := []byte(s.cfg.Admin.Token)
+	return func(c *gin.Context) {
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), token) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+	Module    string `json:"module,omitempty"`
+}
+
+func buildInfo() BuildInfo {
+	info := BuildInfo{Version: "unknown", GoVersion: runtime.Version()}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.GoVersion = bi.GoVersion
+		info.Module = bi.Main.Path
+		if bi.Main.Version != "" {
+			info.Version = bi.Main.Version
+		}
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				info.BuildTime = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if Version != "" {
+		info.Version = Version
+	}
+	if Commit != "" {
+		info.Commit = Commit
+	}
+	return info
+}
+
+type RuntimeStats struct {
+	Uptime       string `json:"uptime"`
+	Goroutines   int    `json:"goroutines"`
+	NumCPU       int    `json:"num_cpu"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	HeapAlloc    uint64 `json:"heap_alloc_bytes"`
+	HeapInuse    uint64 `json:"heap_inuse_bytes"`
+	Sys          uint64 `json:"sys_bytes"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"gc_pause_total_ns"`
+}
+
+func runtimeStats(started time.Time) RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return RuntimeStats{
+		Uptime:       time.Since(started).Round(time.Second).String(),
+		Goroutines:   runtime.NumGoroutine(),
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		HeapAlloc:    mem.HeapAlloc,
+		HeapInuse:    mem.HeapInuse,
+		Sys:          mem.Sys,
+		NumGC:        mem.NumGC,
+		PauseTotalNs: mem.PauseTotalNs,
+	}
+}
+
+// redactConfig renders cfg as JSON with secret values replaced
+func redactConfig(cfg Config) (map[string]interface{}, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	redactValue(out)
+	return out, nil
+}
+
+func redactValue(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if isSecretKey(key) {
+				if s, ok := inner.(string); !ok || s != "" {
+					v[key] = redacted
+				}
+				continue
+			}
+			redactValue(inner)
+		}
+	case []interface{}:
+		for _, inner := range v {
+			redactValue(inner)
+		}
+	}
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range redactedKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
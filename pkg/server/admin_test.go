@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminListener(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.Admin = AdminConfig{Enabled: true, Address: "127.0.0.1:9090", Token: "s3cret", PprofEnabled: true}
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := srv.(*server)
+
+	do := func(handler http.Handler, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		path    string
+		token   string
+		want    int
+	}{
+		{"probe without token", s.admin, "/health/ready", "", http.StatusOK},
+		{"metrics without token", s.admin, "/metrics", "", http.StatusUnauthorized},
+		{"metrics with wrong token", s.admin, "/metrics", "nope", http.StatusUnauthorized},
+		{"metrics with token", s.admin, "/metrics", "s3cret", http.StatusOK},
+		{"pprof with token", s.admin, "/debug/pprof/", "s3cret", http.StatusOK},
+		{"heap profile", s.admin, "/debug/pprof/heap", "s3cret", http.StatusOK},
+		{"runtime stats", s.admin, "/info/runtime", "s3cret", http.StatusOK},
+		{"metrics moved off public router", s.router, "/metrics", "", http.StatusNotFound},
+		{"health moved off public router", s.router, "/health/ready", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(tt.handler, tt.path, tt.token); w.Code != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, w.Code, tt.want)
+			}
+		})
+	}
+
+	var build BuildInfo
+	json.Unmarshal(do(s.admin, "/info/build", "s3cret").Body.Bytes(), &build)
+	if build.GoVersion != runtime.Version() {
+		t.Errorf("build info go version = %q, want %q", build.GoVersion, runtime.Version())
+	}
+
+	var config struct {
+		Admin AdminConfig `json:"admin"`
+	}
+	json.Unmarshal(do(s.admin, "/info/config", "s3cret").Body.Bytes(), &config)
+	if config.Admin.Token != redacted || config.Admin.Address != "127.0.0.1:9090" {
+		t.Errorf("config admin section = %+v, want token redacted", config.Admin)
+	}
+}
+
+func TestValidateAdmin(t *testing.T) {
+	tests := []struct {
+		name    string
+		admin   AdminConfig
+		wantErr bool
+	}{
+		{"valid", AdminConfig{Enabled: true, Address: ":9090", Token: "t"}, false},
+		{"missing token", AdminConfig{Enabled: true, Address: ":9090"}, true},
+		{"missing address", AdminConfig{Enabled: true, Token: "t"}, true},
+		{"disabled", AdminConfig{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Admin = tt.admin
+			if err := validateConfig(cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package server
+
+import (
+	apperrors "common/pkg/errors"
+	"common/pkg/openapi"
+	"common/pkg/utils/response"
+	"common/pkg/validator"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const DefaultOpenAPIPath = "/openapi.json"
+
+type OpenAPIConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Path serves the document; DefaultOpenAPIPath when empty
+	Path        string `json:"path" yaml:"path"`
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description" yaml:"description"`
+	// Version defaults to the build version reported by /info/build
+	Version string `json:"version" yaml:"version"`
+}
+
+// TypedHandler handles a request bound into Req and returns the data for the
+// response envelope. Errors are mapped to responses by status: an
+// *errors.AppError uses its StatusCode and Message, validator.ValidationErrors
+// answer 400 and anything else 500
+type TypedHandler[Req, Resp any] func(c *gin.Context, req Req) (Resp, error)
+
+// RouteOptions document a typed route and choose its success response
+type RouteOptions struct {
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	// Status is the success status code; 200 when zero. 204 sends no body
+	Status int
+	// Message is the envelope message on success; "Success" when empty
+	Message string
+}
+
+// API registers typed routes on a router group and records them for the
+// OpenAPI document
+type API struct {
+	group     *gin.RouterGroup
+	registry  *apiRegistry
+	validator validator.Validator
+}
+
+type apiRoute struct {
+	method string
+	path   string
+	req    reflect.Type
+	resp   reflect.Type
+	opts   RouteOptions
+}
+
+type apiRegistry struct {
+	mu     sync.RWMutex
+	routes []apiRoute
+}
+
+func newAPI(group *gin.RouterGroup) *API {
+	return &API{group: group, registry: &apiRegistry{}, validator: validator.New()}
+}
+
+// Group returns an API for a sub-group that shares this API's document
+func (a *API) Group(relativePath string, handlers ...gin.HandlerFunc) *API {
+	return &API{group: a.group.Group(relativePath, handlers...), registry: a.registry, validator: a.validator}
+}
+
+// Handle registers a typed route. Req is a struct or a pointer to one; its
+// fields are bound from the path (uri tag), the query string (form tag) and
+// the JSON body, then checked with pkg/validator using validate tags. The
+// path wins over the query string, which wins over the body:
+//
+//	type GetUserRequest struct {
+//		ID     string `uri:"id" validate:"required,uuid"`
+//		Expand bool   `form:"expand"`
+//	}
+//
+//	server.Handle(srv.API(), http.MethodGet, "/users/:id", getUser)
+func Handle[Req, Resp any](api *API, method, relativePath string, fn TypedHandler[Req, Resp], opts ...RouteOptions) {
+	var o RouteOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Status == 0 {
+		o.Status = http.StatusOK
+	}
+	if o.Message == "" {
+		o.Message = "Success"
+	}
+
+	reqType := reflect.TypeOf((*Req)(nil)).Elem()
+	api.group.Handle(method, relativePath, typedHandler(api.validator, newBinding(reqType), fn, o))
+
+	api.registry.mu.Lock()
+	defer api.registry.mu.Unlock()
+	api.registry.routes = append(api.registry.routes, apiRoute{
+		method: method,
+		path:   joinPaths(api.group.BasePath(), relativePath),
+		req:    reqType,
+		resp:   reflect.TypeOf((*Resp)(nil)).Elem(),
+		opts:   o,
+	})
+}
+
+// binding records which sources a request type reads from, so requests only
+// pay for the binders they need
+type binding struct {
+	isStruct, uri, query, body bool
+	// elem is the pointed-to type when the request type is a pointer
+	elem reflect.Type
+}
+
+func newBinding(t reflect.Type) binding {
+	var elem reflect.Type
+	if t.Kind() == reflect.Pointer {
+		elem = t.Elem()
+		if elem.Kind() == reflect.Pointer {
+			panic("server: request type " + t.String() + " must not be a pointer to a pointer")
+		}
+		t = elem
+	}
+	if t.Kind() != reflect.Struct {
+		return binding{body: true, elem: elem}
+	}
+
+	b := binding{isStruct: true, elem: elem}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case field.Tag.Get("uri") != "":
+			b.uri = true
+		case field.Tag.Get("form") != "":
+			b.query = true
+		case field.Tag.Get("json") != "-":
+			b.body = true
+		}
+	}
+	return b
+}
+
+func typedHandler[Req, Resp any](v validator.Validator, b binding, fn TypedHandler[Req, Resp], o RouteOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req Req
+		// bind and validate through a single pointer, allocating the value
+		// when Req is itself a pointer
+		var target interface{} = &req
+		if b.elem != nil {
+			req = reflect.New(b.elem).Interface().(Req)
+			target = req
+		}
+		if err := bindRequest(c, b, target); err != nil {
+			resp := response.Error(http.StatusBadRequest, "Invalid request", err.Error())
+			c.AbortWithStatusJSON(resp.Status, resp)
+			return
+		}
+		if b.isStruct {
+			if err := v.ValidateStruct(target); err != nil {
+				writeError(c, err)
+				return
+			}
+		}
+
+		data, err := fn(c, req)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		if o.Status == http.StatusNoContent {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(o.Status, response.APIResponse{Data: data, Message: o.Message, Status: o.Status})
+	}
+}
+
+// bindRequest binds the JSON body, then the query string, then the path, so
+// a later source wins: the body cannot override query or path fields
+func bindRequest(c *gin.Context, b binding, req interface{}) error {
+	if b.body && c.Request.Body != nil && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	}
+	if b.query {
+		if err := c.ShouldBindQuery(req); err != nil {
+			return err
+		}
+	}
+	if b.uri {
+		if err := c.ShouldBindUri(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeError(c *gin.Context, err error) {
+	c.Error(err)
+
+	var validationErrors validator.ValidationErrors
+	var appErr *apperrors.AppError
+	var resp response.APIResponse
+	switch {
+	case errors.As(err, &validationErrors):
+		resp = response.Error(http.StatusBadRequest, "Validation failed", validationErrors)
+	case errors.As(err, &appErr) && appErr.StatusCode != 0:
+		resp = response.Error(appErr.StatusCode, appErr.Message, nil)
+	default:
+		resp = response.InternalServerError("Internal server error")
+	}
+	c.AbortWithStatusJSON(resp.Status, resp)
+}
+
+// setupOpenAPI serves the document on the public router so clients can
+// fetch it alongside the API it describes. It is rebuilt on each request, so
+// routes registered after New are included
+func (s *server) setupOpenAPI() {
+	cfg := s.cfg.OpenAPI
+	if cfg.Path == "" {
+		cfg.Path = DefaultOpenAPIPath
+	}
+	info := openapi.Info{Title: cfg.Title, Version: cfg.Version, Description: cfg.Description}
+	if info.Title == "" {
+		info.Title = "API"
+	}
+	if info.Version == "" {
+		info.Version = buildInfo().Version
+	}
+
+	s.router.GET(cfg.Path, func(c *gin.Context) {
+		c.JSON(http.StatusOK, s.api.registry.document(info))
+	})
+}
+
+// document builds the OpenAPI description of every registered route
+func (r *apiRegistry) document(info openapi.Info) *openapi.Document {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reflector := openapi.NewReflector()
+	errorSchema := errorEnvelope(reflector)
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info:    info,
+		Paths:   make(map[string]*openapi.PathItem),
+	}
+
+	for _, route := range r.routes {
+		specPath, pathParams := openAPIPath(route.path)
+		op := &openapi.Operation{
+			OperationID: route.opts.OperationID,
+			Summary:     route.opts.Summary,
+			Description: route.opts.Description,
+			Tags:        route.opts.Tags,
+			Deprecated:  route.opts.Deprecated,
+			Parameters:  reflector.Parameters(route.req),
+			Responses:   make(map[string]openapi.Response),
+		}
+		op.Parameters = addMissingPathParams(op.Parameters, pathParams)
+
+		switch route.method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			if body := reflector.Body(route.req); body != nil {
+				op.RequestBody = &openapi.RequestBody{
+					Required: true,
+					Content:  map[string]openapi.MediaType{"application/json": {Schema: body}},
+				}
+			}
+		}
+
+		status := strconv.Itoa(route.opts.Status)
+		if route.opts.Status == http.StatusNoContent {
+			op.Responses[status] = openapi.Response{Description: http.StatusText(http.StatusNoContent)}
+		} else {
+			op.Responses[status] = openapi.Response{
+				Description: http.StatusText(route.opts.Status),
+				Content: map[string]openapi.MediaType{
+					"application/json": {Schema: successEnvelope(reflector.Schema(route.resp))},
+				},
+			}
+		}
+		op.Responses["400"] = openapi.Response{
+			Description: "Invalid request or validation failed",
+			Content:     map[string]openapi.MediaType{"application/json": {Schema: errorSchema}},
+		}
+		op.Responses["default"] = openapi.Response{
+			Description: "Error",
+			Content:     map[string]openapi.MediaType{"application/json": {Schema: errorSchema}},
+		}
+
+		item, ok := doc.Paths[specPath]
+		if !ok {
+			item = &openapi.PathItem{}
+			doc.Paths[specPath] = item
+		}
+		(*item)[strings.ToLower(route.method)] = op
+	}
+
+	doc.Components.Schemas = reflector.Components()
+	return doc
+}
+
+func successEnvelope(data *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"data":    data,
+			"message": {Type: "string"},
+			"status":  {Type: "integer"},
+		},
+		Required: []string{"data", "message", "status"},
+	}
+}
+
+// errorEnvelope registers the error response shape, whose errors field holds
+// validation failures
+func errorEnvelope(reflector *openapi.Reflector) *openapi.Schema {
+	type ErrorResponse struct {
+		Data    interface{}                `json:"data"`
+		Message string                     `json:"message" validate:"required"`
+		Status  int                        `json:"status" validate:"required"`
+		Errors  validator.ValidationErrors `json:"errors,omitempty"`
+	}
+	return reflector.Schema(reflect.TypeOf(ErrorResponse{}))
+}
+
+// openAPIPath converts gin's /users/:id and /files/*path into OpenAPI
+// templates and returns the parameter names
+func openAPIPath(ginPath string) (string, []string) {
+	segments := strings.Split(ginPath, "/")
+	var params []string
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// addMissingPathParams documents path parameters the request type does not bind
+func addMissingPathParams(params []openapi.Parameter, names []string) []openapi.Parameter {
+	for _, name := range names {
+		found := false
+		for _, p := range params {
+			if p.In == "path" && p.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			params = append(params, openapi.Parameter{Name: name, In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}})
+		}
+	}
+	return params
+}
+
+func joinPaths(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+	joined := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		joined += "/"
+	}
+	return joined
+}
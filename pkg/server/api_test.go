@@ -0,0 +1,184 @@
+package server
+
+import (
+	apperrors "common/pkg/errors"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type createItemRequest struct {
+	ListID string `uri:"list_id" validate:"required"`
+	Notify bool   `form:"notify"`
+	Name   string `json:"name" validate:"required,min=3"`
+	Count  int    `json:"count" validate:"gte=1,lte=10"`
+}
+
+type itemResponse struct {
+	ListID string `json:"list_id"`
+	Name   string `json:"name"`
+	Count  int    `json:"count"`
+	Notify bool   `json:"notify"`
+}
+
+func newAPITestServer(t *testing.T) Server {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.MetricsEnabled = false
+	cfg.OpenAPI = OpenAPIConfig{Enabled: true, Title: "Items", Version: "1.2.3"}
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lists := srv.API().Group("/lists")
+	Handle(lists, http.MethodPost, "/:list_id/items", func(c *gin.Context, req createItemRequest) (itemResponse, error) {
+		if req.Name == "forbidden" {
+			return itemResponse{}, apperrors.NewAppError(errors.New("denied"), "Not allowed", http.StatusForbidden)
+		}
+		if req.Name == "explode" {
+			return itemResponse{}, errors.New("database unavailable")
+		}
+		return itemResponse{ListID: req.ListID, Name: req.Name, Count: req.Count, Notify: req.Notify}, nil
+	}, RouteOptions{Summary: "Create an item", Status: http.StatusCreated, Message: "Item created"})
+	Handle(lists, http.MethodPut, "/:list_id/items", func(c *gin.Context, req *createItemRequest) (itemResponse, error) {
+		return itemResponse{ListID: req.ListID, Name: req.Name, Count: req.Count, Notify: req.Notify}, nil
+	})
+	Handle(lists, http.MethodDelete, "/:list_id/items/:id", func(c *gin.Context, req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	}, RouteOptions{Status: http.StatusNoContent})
+	return srv
+}
+
+func TestHandle(t *testing.T) {
+	srv := newAPITestServer(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"binds path, query and body", http.MethodPost, "/lists/l1/items?notify=true", `{"name":"milk","count":2}`,
+			http.StatusCreated, `{"data":{"list_id":"l1","name":"milk","count":2,"notify":true},"message":"Item created","status":201}`},
+		{"query wins over body", http.MethodPost, "/lists/l1/items?notify=true", `{"name":"milk","count":2,"Notify":false,"ListID":"l2"}`,
+			http.StatusCreated, `{"data":{"list_id":"l1","name":"milk","count":2,"notify":true}`},
+		{"validation failure", http.MethodPost, "/lists/l1/items", `{"name":"mi","count":2}`,
+			http.StatusBadRequest, `"message":"Validation failed"`},
+		{"numeric bound", http.MethodPost, "/lists/l1/items", `{"name":"milk","count":11}`,
+			http.StatusBadRequest, `"message":"Invalid value for Count"`},
+		{"malformed body", http.MethodPost, "/lists/l1/items", `{"name":`,
+			http.StatusBadRequest, `"message":"Invalid request"`},
+		{"app error", http.MethodPost, "/lists/l1/items", `{"name":"forbidden","count":1}`,
+			http.StatusForbidden, `"message":"Not allowed"`},
+		{"unexpected error", http.MethodPost, "/lists/l1/items", `{"name":"explode","count":1}`,
+			http.StatusInternalServerError, `"message":"Internal server error"`},
+		{"pointer request", http.MethodPut, "/lists/l1/items?notify=true", `{"name":"milk","count":2}`,
+			http.StatusOK, `{"data":{"list_id":"l1","name":"milk","count":2,"notify":true},"message":"Success","status":200}`},
+		{"pointer request validation failure", http.MethodPut, "/lists/l1/items", `{"name":"mi","count":2}`,
+			http.StatusBadRequest, `"message":"Validation failed"`},
+		{"no content", http.MethodDelete, "/lists/l1/items/i1", "",
+			http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			srv.Router().ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	srv := newAPITestServer(t)
+
+	w := httptest.NewRecorder()
+	srv.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, DefaultOpenAPIPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			Summary    string `json:"summary"`
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+			RequestBody *struct {
+				Content map[string]struct {
+					Schema struct {
+						Properties map[string]struct {
+							MinLength *int     `json:"minLength"`
+							Minimum   *float64 `json:"minimum"`
+							Maximum   *float64 `json:"maximum"`
+						} `json:"properties"`
+						Required []string `json:"required"`
+					} `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != "Items" || doc.Info.Version != "1.2.3" {
+		t.Errorf("header = %s %+v", doc.OpenAPI, doc.Info)
+	}
+
+	create, ok := doc.Paths["/lists/{list_id}/items"]["post"]
+	if !ok {
+		t.Fatalf("create operation missing from %v", doc.Paths)
+	}
+	if create.Summary != "Create an item" {
+		t.Errorf("summary = %q", create.Summary)
+	}
+	if len(create.Parameters) != 2 || create.Parameters[0].In != "path" || create.Parameters[1].In != "query" {
+		t.Errorf("parameters = %+v", create.Parameters)
+	}
+	if _, ok := create.Responses["201"]; !ok {
+		t.Errorf("responses = %v", create.Responses)
+	}
+	body := create.RequestBody.Content["application/json"].Schema
+	if *body.Properties["name"].MinLength != 3 || *body.Properties["count"].Minimum != 1 || *body.Properties["count"].Maximum != 10 {
+		t.Errorf("body constraints = %+v", body.Properties)
+	}
+	if _, ok := body.Properties["list_id"]; ok {
+		t.Error("path parameter documented in body")
+	}
+
+	remove, ok := doc.Paths["/lists/{list_id}/items/{id}"]["delete"]
+	if !ok {
+		t.Fatal("delete operation missing")
+	}
+	if len(remove.Parameters) != 2 || remove.RequestBody != nil {
+		t.Errorf("delete operation = %+v", remove)
+	}
+	if _, ok := remove.Responses["204"]; !ok {
+		t.Errorf("responses = %v", remove.Responses)
+	}
+}
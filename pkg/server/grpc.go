@@ -0,0 +1,173 @@
+package server
+
+import (
+	"common/interceptors"
+	"common/pkg/jwt"
+	"common/pkg/session"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// DefaultGRPCAddress is the conventional gRPC port, clear of the HTTP and
+// admin listeners
+const DefaultGRPCAddress = ":50051"
+
+type GRPCConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Address is the "host:port" or "unix:/path" of the gRPC listener;
+	// DefaultGRPCAddress when empty. Must be empty with SharePort
+	Address string `json:"address" yaml:"address"`
+	// SharePort serves gRPC on the HTTP listener, routing HTTP/2 requests
+	// with an application/grpc content type to the gRPC server. Without TLS
+	// the listener accepts h2c so plaintext gRPC clients can connect
+	SharePort bool `json:"share_port" yaml:"share_port"`
+	// Reflection registers the server reflection service for tools like grpcurl
+	Reflection bool `json:"reflection" yaml:"reflection"`
+	// MaxRecvMsgSize in bytes; gRPC's 4MB default when zero
+	MaxRecvMsgSize int `json:"max_recv_msg_size" yaml:"max_recv_msg_size"`
+
+	// JWT enables bearer token auth on every method except PublicMethods,
+	// health and reflection
+	JWT           jwt.JWT  `json:"-" yaml:"-"`
+	PublicMethods []string `json:"public_methods" yaml:"public_methods"`
+	// Denylist rejects revoked access tokens; see interceptors.AuthConfig
+	Denylist session.Denylist `json:"-" yaml:"-"`
+
+	// UnaryInterceptors and StreamInterceptors run after the built-in ones
+	UnaryInterceptors  []grpc.UnaryServerInterceptor  `json:"-" yaml:"-"`
+	StreamInterceptors []grpc.StreamServerInterceptor `json:"-" yaml:"-"`
+	ServerOptions      []grpc.ServerOption            `json:"-" yaml:"-"`
+}
+
+func validateGRPC(cfg GRPCConfig) error {
+	if cfg.MaxRecvMsgSize < 0 {
+		return errors.New("grpc: max receive message size must not be negative")
+	}
+	if cfg.SharePort {
+		if cfg.Address != "" {
+			return errors.New("grpc: address must be empty when sharing the HTTP port")
+		}
+		return nil
+	}
+	if cfg.Address == "" {
+		return nil
+	}
+	if err := validateListenAddress(cfg.Address); err != nil {
+		return fmt.Errorf("grpc: %w", err)
+	}
+	return nil
+}
+
+func grpcAddress(cfg GRPCConfig) string {
+	if cfg.Address != "" {
+		return cfg.Address
+	}
+	return DefaultGRPCAddress
+}
+
+// setupGRPC builds the gRPC server with interceptors in the same order as
+// DefaultMiddleware: request ID, tracing, metrics, logging, recovery, auth
+func (s *server) setupGRPC() {
+	cfg := s.cfg.GRPC
+
+	unary := []grpc.UnaryServerInterceptor{
+		interceptors.UnaryRequestIDInterceptor(),
+		interceptors.UnaryTracingInterceptor(otel.Tracer("common/pkg/server")),
+	}
+	stream := []grpc.StreamServerInterceptor{
+		interceptors.StreamRequestIDInterceptor(),
+		interceptors.StreamTracingInterceptor(otel.Tracer("common/pkg/server")),
+	}
+	if s.cfg.MetricsEnabled {
+		metrics := interceptors.NewMetrics(s.cfg.Metrics)
+		unary = append(unary, metrics.UnaryServerInterceptor())
+		stream = append(stream, metrics.StreamServerInterceptor())
+	}
+	unary = append(unary,
+		interceptors.UnaryLoggingInterceptor(s.logger),
+		interceptors.UnaryRecoveryInterceptor(s.logger),
+	)
+	stream = append(stream,
+		interceptors.StreamLoggingInterceptor(s.logger),
+		interceptors.StreamRecoveryInterceptor(s.logger),
+	)
+	if cfg.JWT != nil {
+		auth := interceptors.AuthConfig{PublicMethods: cfg.PublicMethods, Denylist: cfg.Denylist}
+		unary = append(unary, interceptors.UnaryAuthInterceptor(s.logger, cfg.JWT, auth))
+		stream = append(stream, interceptors.StreamAuthInterceptor(s.logger, cfg.JWT, auth))
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(append(unary, cfg.UnaryInterceptors...)...),
+		grpc.ChainStreamInterceptor(append(stream, cfg.StreamInterceptors...)...),
+	}
+	if cfg.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize))
+	}
+	// on a shared port the HTTP server terminates TLS
+	if s.srv.TLSConfig != nil && !cfg.SharePort {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.srv.TLSConfig)))
+	}
+
+	s.grpc = grpc.NewServer(append(opts, cfg.ServerOptions...)...)
+	s.grpcHealth = health.NewServer()
+	healthpb.RegisterHealthServer(s.grpc, s.grpcHealth)
+	if cfg.Reflection {
+		reflection.Register(s.grpc)
+	}
+}
+
+// grpcHandler routes gRPC requests to the gRPC server and everything else to
+// the HTTP handler
+func grpcHandler(grpcServer *grpc.Server, httpHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		httpHandler.ServeHTTP(w, r)
+	})
+}
+
+// listenGRPC opens the dedicated gRPC listener; nil when gRPC is disabled or
+// shares the HTTP port
+func (s *server) listenGRPC() (net.Listener, error) {
+	if s.grpc == nil || s.cfg.GRPC.SharePort {
+		return nil, nil
+	}
+	return listenOn(grpcAddress(s.cfg.GRPC))
+}
+
+// stopGRPC waits for in-flight calls to finish, cancelling them when ctx is
+// done first
+func (s *server) stopGRPC(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (s *server) GRPC() *grpc.Server {
+	return s.grpc
+}
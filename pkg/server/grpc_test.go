@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func checkGRPCHealth(t *testing.T, target string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("health check: %v", err)
+	}
+	return resp.GetStatus()
+}
+
+func TestGRPCSharedPort(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.MetricsEnabled = false
+	cfg.Address = "127.0.0.1:0"
+	cfg.GRPC = GRPCConfig{Enabled: true, SharePort: true, Reflection: true}
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := serve(t, srv)
+
+	if got := checkGRPCHealth(t, "passthrough:///"+listener.Addr().String()); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("grpc health = %v, want SERVING", got)
+	}
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/health/live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("http status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestGRPCDedicatedListener(t *testing.T) {
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "grpc.sock")
+
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.MetricsEnabled = false
+	cfg.Address = "unix:" + filepath.Join(dir, "http.sock")
+	cfg.GRPC = GRPCConfig{Enabled: true, Address: "unix:" + socket}
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Start(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("grpc listener did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := checkGRPCHealth(t, "unix://"+socket); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("grpc health = %v, want SERVING", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("start returned %v", err)
+	}
+	if _, err := net.Dial("unix", socket); err == nil {
+		t.Error("grpc listener still accepting after shutdown")
+	}
+}
+
+func TestValidateGRPC(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     GRPCConfig
+		wantErr bool
+	}{
+		{"default address", GRPCConfig{Enabled: true}, false},
+		{"dedicated address", GRPCConfig{Enabled: true, Address: "0.0.0.0:50051"}, false},
+		{"shared port", GRPCConfig{Enabled: true, SharePort: true}, false},
+		{"shared port with address", GRPCConfig{Enabled: true, SharePort: true, Address: ":50051"}, true},
+		{"invalid address", GRPCConfig{Enabled: true, Address: "localhost"}, true},
+		{"negative message size", GRPCConfig{Enabled: true, MaxRecvMsgSize: -1}, true},
+		{"same address as admin", GRPCConfig{Enabled: true, Address: "127.0.0.2:9090"}, true},
+		{"wildcard host on the admin port", GRPCConfig{Enabled: true, Address: ":9090"}, true},
+		{"same port as admin on a wildcard host", GRPCConfig{Enabled: true, Address: "0.0.0.0:9090"}, true},
+		{"same port as admin on another host", GRPCConfig{Enabled: true, Address: "10.0.0.1:9090"}, false},
+		{"same address as http", GRPCConfig{Enabled: true, Address: ":8080"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Admin = AdminConfig{Enabled: true, Address: "127.0.0.2:9090", Token: "t"}
+			cfg.GRPC = tt.cfg
+			if err := validateConfig(cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
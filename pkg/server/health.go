@@ -0,0 +1,233 @@
+package server
+
+import (
+	"common/pkg/consul"
+	database "common/pkg/db"
+	"common/pkg/rabbitmq"
+	"common/pkg/redis"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultCheckTimeout = 2 * time.Second
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+)
+
+// CheckFunc reports whether a dependency is usable
+type CheckFunc func(ctx context.Context) error
+
+type CheckOptions struct {
+	// Timeout bounds a single run of the check; DefaultCheckTimeout when zero
+	Timeout time.Duration
+	// CacheTTL reuses the last result for this long, so frequent probes do
+	// not hammer the dependency. Zero runs the check on every probe
+	CacheTTL time.Duration
+	// NonCritical checks are reported but never fail readiness
+	NonCritical bool
+}
+
+// CheckResult is the outcome of one check in a readiness report
+type CheckResult struct {
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached,omitempty"`
+}
+
+// HealthReport is the body served by /health/ready. Status is "down" when a
+// critical check failed and "degraded" when only non-critical checks failed
+type HealthReport struct {
+	Status       string                 `json:"status"`
+	ShuttingDown bool                   `json:"shutting_down,omitempty"`
+	Checks       map[string]CheckResult `json:"checks"`
+	Timestamp    time.Time              `json:"timestamp"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+	opts CheckOptions
+
+	mu   sync.Mutex
+	last CheckResult
+}
+
+// HealthChecker is a registry of named readiness checks
+type HealthChecker struct {
+	mu     sync.RWMutex
+	checks map[string]*check
+
+	shuttingDown atomic.Bool
+}
+
+func NewHealthChecker() *HealthChecker {
+	return &HealthChecker{checks: make(map[string]*check)}
+}
+
+// Register adds a check, replacing any check with the same name
+func (h *HealthChecker) Register(name string, fn CheckFunc, opts ...CheckOptions) {
+	var o CheckOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultCheckTimeout
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = &check{name: name, fn: fn, opts: o}
+}
+
+// SetShuttingDown makes every report fail without running the checks, so
+// load balancers stop routing to the instance before it drains
+func (h *HealthChecker) SetShuttingDown(shuttingDown bool) {
+	h.shuttingDown.Store(shuttingDown)
+}
+
+// Check runs every registered check concurrently and aggregates the results
+func (h *HealthChecker) Check(ctx context.Context) HealthReport {
+	if h.shuttingDown.Load() {
+		return HealthReport{
+			Status:       StatusDown,
+			ShuttingDown: true,
+			Checks:       map[string]CheckResult{},
+			Timestamp:    time.Now().UTC(),
+		}
+	}
+
+	h.mu.RLock()
+	checks := make([]*check, 0, len(h.checks))
+	for _, c := range h.checks {
+		checks = append(checks, c)
+	}
+	h.mu.RUnlock()
+	sort.Slice(checks, func(i, j int) bool { return checks[i].name < checks[j].name })
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := HealthReport{
+		Status:    StatusUp,
+		Checks:    make(map[string]CheckResult, len(checks)),
+		Timestamp: time.Now().UTC(),
+	}
+	for i, c := range checks {
+		result := results[i]
+		report.Checks[c.name] = result
+		if result.Status == StatusUp {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run executes the check, or returns the cached result when it is still
+// fresh. Concurrent probes wait for a single in-flight run
+func (c *check) run(ctx context.Context) CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.opts.CacheTTL > 0 && !c.last.CheckedAt.IsZero() && time.Since(c.last.CheckedAt) < c.opts.CacheTTL {
+		cached := c.last
+		cached.Cached = true
+		return cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	// the check runs in its own goroutine so a check that ignores ctx still
+	// times out
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s", c.opts.Timeout)
+	}
+
+	result := CheckResult{
+		Status:    StatusUp,
+		Critical:  !c.opts.NonCritical,
+		Duration:  time.Since(start).String(),
+		CheckedAt: start.UTC(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	c.last = result
+	return result
+}
+
+// DatabaseCheck pings the Postgres connection pool
+func DatabaseCheck(db *database.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// RedisCheck pings the Redis server
+func RedisCheck(r redis.Redis) CheckFunc {
+	return func(ctx context.Context) error {
+		return r.Ping(ctx).Err()
+	}
+}
+
+// RabbitMQCheck verifies the broker connection is still open. The service
+// must implement rabbitmq.Pinger, otherwise the check always fails
+func RabbitMQCheck(mq rabbitmq.RabbitMQService) CheckFunc {
+	return func(ctx context.Context) error {
+		pinger, ok := mq.(rabbitmq.Pinger)
+		if !ok {
+			return fmt.Errorf("rabbitmq service %T does not implement Ping", mq)
+		}
+		return pinger.Ping()
+	}
+}
+
+// ConsulCheck verifies the Consul agent is reachable and has a leader. The
+// client must implement consul.Pinger, otherwise the check always fails
+func ConsulCheck(c consul.ConsulClient) CheckFunc {
+	return func(ctx context.Context) error {
+		pinger, ok := c.(consul.Pinger)
+		if !ok {
+			return fmt.Errorf("consul client %T does not implement Ping", c)
+		}
+		return pinger.Ping(ctx)
+	}
+}
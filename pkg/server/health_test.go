@@ -0,0 +1,169 @@
+package server
+
+import (
+	"common/pkg/consul"
+	"common/pkg/rabbitmq"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthCheckerStatus(t *testing.T) {
+	failing := func(ctx context.Context) error { return errors.New("connection refused") }
+	passing := func(ctx context.Context) error { return nil }
+
+	tests := []struct {
+		name   string
+		setup  func(h *HealthChecker)
+		status string
+	}{
+		{"no checks", func(h *HealthChecker) {}, StatusUp},
+		{"all passing", func(h *HealthChecker) {
+			h.Register("postgres", passing)
+			h.Register("redis", passing)
+		}, StatusUp},
+		{"non-critical failing", func(h *HealthChecker) {
+			h.Register("postgres", passing)
+			h.Register("consul", failing, CheckOptions{NonCritical: true})
+		}, StatusDegraded},
+		{"critical failing", func(h *HealthChecker) {
+			h.Register("postgres", failing)
+			h.Register("consul", failing, CheckOptions{NonCritical: true})
+		}, StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthChecker()
+			tt.setup(h)
+			if report := h.Check(context.Background()); report.Status != tt.status {
+				t.Errorf("status = %q, want %q (%+v)", report.Status, tt.status, report.Checks)
+			}
+		})
+	}
+}
+
+func TestHealthCheckerTimeout(t *testing.T) {
+	h := NewHealthChecker()
+	h.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, CheckOptions{Timeout: 10 * time.Millisecond})
+
+	start := time.Now()
+	report := h.Check(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("check took %s, timeout not enforced", elapsed)
+	}
+	if result := report.Checks["slow"]; result.Status != StatusDown || result.Error == "" {
+		t.Errorf("slow check = %+v, want down with error", result)
+	}
+}
+
+func TestHealthCheckerCache(t *testing.T) {
+	var calls atomic.Int32
+	h := NewHealthChecker()
+	h.Register("redis", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}, CheckOptions{CacheTTL: time.Minute})
+
+	h.Check(context.Background())
+	report := h.Check(context.Background())
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("check ran %d times, want 1", n)
+	}
+	if !report.Checks["redis"].Cached {
+		t.Errorf("second result should be cached")
+	}
+}
+
+func TestReadinessEndpoint(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.MetricsEnabled = false
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.RegisterCheck("postgres", func(ctx context.Context) error { return errors.New("down") })
+
+	w := httptest.NewRecorder()
+	srv.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("ready status = %d, want 503", w.Code)
+	}
+	var report HealthReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Checks["postgres"].Error != "down" {
+		t.Errorf("report = %+v", report)
+	}
+
+	w = httptest.NewRecorder()
+	srv.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("live status = %d, want 200", w.Code)
+	}
+}
+
+type fakeRabbitMQ struct {
+	rabbitmq.RabbitMQService
+}
+
+type pingingRabbitMQ struct {
+	fakeRabbitMQ
+	err error
+}
+
+func (p pingingRabbitMQ) Ping() error { return p.err }
+
+type fakeConsul struct {
+	consul.ConsulClient
+}
+
+type pingingConsul struct {
+	fakeConsul
+	err error
+}
+
+func (p pingingConsul) Ping(ctx context.Context) error { return p.err }
+
+func TestDependencyChecksRequirePing(t *testing.T) {
+	closed := errors.New("connection closed")
+
+	tests := []struct {
+		name    string
+		check   CheckFunc
+		wantErr error
+		anyErr  bool
+	}{
+		{"rabbitmq up", RabbitMQCheck(pingingRabbitMQ{}), nil, false},
+		{"rabbitmq down", RabbitMQCheck(pingingRabbitMQ{err: closed}), closed, true},
+		{"rabbitmq without ping", RabbitMQCheck(fakeRabbitMQ{}), nil, true},
+		{"consul up", ConsulCheck(pingingConsul{}), nil, false},
+		{"consul down", ConsulCheck(pingingConsul{err: closed}), closed, true},
+		{"consul without ping", ConsulCheck(fakeConsul{}), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check(context.Background())
+			if (err != nil) != tt.anyErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.anyErr)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("check() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const DefaultHookTimeout = 10 * time.Second
+
+// HookFunc starts or stops a dependency of the server
+type HookFunc func(ctx context.Context) error
+
+// Hook ties a component to the server lifecycle. OnStart hooks run in the
+// order they were appended before the server accepts traffic; OnStop hooks run
+// in reverse order after it has drained. Either function may be nil
+type Hook struct {
+	Name    string
+	OnStart HookFunc
+	OnStop  HookFunc
+	// Timeout bounds each of OnStart and OnStop; the lifecycle default when zero
+	Timeout time.Duration
+}
+
+// Lifecycle runs start and stop hooks. For example:
+//
+//	lc := srv.Lifecycle()
+//	lc.OnStop("postgres", func(ctx context.Context) error { return db.Close() })
+//	lc.OnStop("tracer", provider.Shutdown)
+//	lc.Append(server.Hook{
+//		Name:    "worker",
+//		OnStart: func(ctx context.Context) error { return worker.Start() },
+//		OnStop:  func(ctx context.Context) error { worker.Shutdown(); return nil },
+//	})
+type Lifecycle struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	hooks   []Hook
+	started int
+}
+
+func NewLifecycle(timeout time.Duration) *Lifecycle {
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	return &Lifecycle{timeout: timeout}
+}
+
+func (l *Lifecycle) Append(hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+}
+
+func (l *Lifecycle) OnStart(name string, fn HookFunc) {
+	l.Append(Hook{Name: name, OnStart: fn})
+}
+
+func (l *Lifecycle) OnStop(name string, fn HookFunc) {
+	l.Append(Hook{Name: name, OnStop: fn})
+}
+
+// Start runs the start hooks in order. When one fails, the hooks that
+// already started are stopped again and the combined error is returned
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.started < len(l.hooks) {
+		hook := l.hooks[l.started]
+		if hook.OnStart != nil {
+			if err := l.run(ctx, hook, hook.OnStart); err != nil {
+				err = fmt.Errorf("start hook %q: %w", hook.Name, err)
+				return errors.Join(err, l.stop(ctx))
+			}
+		}
+		l.started++
+	}
+	return nil
+}
+
+// Stop runs the stop hooks of started components in reverse order. Every
+// hook runs even when an earlier one fails; all failures are returned joined
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stop(ctx)
+}
+
+func (l *Lifecycle) stop(ctx context.Context) error {
+	var errs []error
+	for ; l.started > 0; l.started-- {
+		hook := l.hooks[l.started-1]
+		if hook.OnStop == nil {
+			continue
+		}
+		if err := l.run(ctx, hook, hook.OnStop); err != nil {
+			errs = append(errs, fmt.Errorf("stop hook %q: %w", hook.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run calls fn with the hook's timeout. It returns when the timeout expires
+// even if fn ignores its context, so one stuck hook cannot block the rest
+func (l *Lifecycle) run(ctx context.Context, hook Hook, fn HookFunc) error {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = l.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s: %w", timeout, ctx.Err())
+	}
+}
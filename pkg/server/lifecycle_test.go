@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLifecycleOrder(t *testing.T) {
+	var calls []string
+	record := func(name string, err error) HookFunc {
+		return func(ctx context.Context) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	lc := NewLifecycle(time.Second)
+	lc.Append(Hook{Name: "db", OnStart: record("start db", nil), OnStop: record("stop db", nil)})
+	lc.OnStop("tracer", record("stop tracer", errors.New("flush failed")))
+	lc.Append(Hook{Name: "worker", OnStart: record("start worker", nil), OnStop: record("stop worker", errors.New("busy"))})
+
+	if err := lc.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err := lc.Stop(context.Background())
+
+	want := []string{"start db", "start worker", "stop worker", "stop tracer", "stop db"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	if err == nil || !strings.Contains(err.Error(), `"worker": busy`) || !strings.Contains(err.Error(), `"tracer": flush failed`) {
+		t.Errorf("Stop() error = %v, want both failures", err)
+	}
+
+	calls = nil
+	if err := lc.Stop(context.Background()); err != nil || len(calls) != 0 {
+		t.Errorf("second Stop ran hooks again: %v, %v", calls, err)
+	}
+}
+
+func TestLifecycleStartFailureRollsBack(t *testing.T) {
+	var stopped []string
+	lc := NewLifecycle(time.Second)
+	lc.Append(Hook{Name: "db", OnStop: func(ctx context.Context) error {
+		stopped = append(stopped, "db")
+		return nil
+	}})
+	lc.Append(Hook{Name: "broker", OnStart: func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}, OnStop: func(ctx context.Context) error {
+		stopped = append(stopped, "broker")
+		return nil
+	}})
+
+	if err := lc.Start(context.Background()); err == nil {
+		t.Fatal("Start() should fail")
+	}
+	if !reflect.DeepEqual(stopped, []string{"db"}) {
+		t.Errorf("stopped = %v, want only the started db", stopped)
+	}
+}
+
+func TestLifecycleHookTimeout(t *testing.T) {
+	lc := NewLifecycle(time.Second)
+	lc.Append(Hook{Name: "stuck", Timeout: 10 * time.Millisecond, OnStop: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}})
+	lc.Start(context.Background())
+
+	start := time.Now()
+	err := lc.Stop(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Stop() took %s, timeout not enforced", elapsed)
+	}
+}
+
+func TestShutdownFailsReadinessBeforeDraining(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.MetricsEnabled = false
+	cfg.PreStopDelay = 100 * time.Millisecond
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopped := make(chan struct{})
+	srv.Lifecycle().OnStop("db", func(ctx context.Context) error {
+		close(stopped)
+		return nil
+	})
+	srv.Lifecycle().Start(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Shutdown(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	w := httptest.NewRecorder()
+	srv.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("readiness during pre-stop = %d, want 503", w.Code)
+	}
+	select {
+	case <-stopped:
+		t.Error("stop hooks ran before the pre-stop delay elapsed")
+	default:
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	<-stopped
+}
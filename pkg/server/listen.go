@@ -0,0 +1,111 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const unixPrefix = "unix:"
+
+func listenAddress(cfg Config) string {
+	if cfg.Address != "" {
+		return cfg.Address
+	}
+	return fmt.Sprintf(":%d", cfg.Port)
+}
+
+func validateAddress(cfg Config) error {
+	if cfg.Address == "" {
+		if cfg.Port < 1 || cfg.Port > 65535 {
+			return errors.New("port must be between 1 and 65535")
+		}
+		return nil
+	}
+	return validateListenAddress(cfg.Address)
+}
+
+// validateListenAddress accepts "host:port" and "unix:/path/to.sock"
+func validateListenAddress(address string) error {
+	if path, ok := strings.CutPrefix(address, unixPrefix); ok {
+		if path == "" {
+			return errors.New("unix socket path must not be empty")
+		}
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	return nil
+}
+
+// sameListener reports whether two valid listen addresses would bind the
+// same socket: the same Unix path, or the same port where one host is the
+// other or a wildcard
+func sameListener(a, b string) bool {
+	if strings.HasPrefix(a, unixPrefix) || strings.HasPrefix(b, unixPrefix) {
+		return a == b
+	}
+	hostA, portA, errA := net.SplitHostPort(a)
+	hostB, portB, errB := net.SplitHostPort(b)
+	if errA != nil || errB != nil || portA != portB || portA == "0" {
+		return false
+	}
+	return hostA == hostB || isWildcardHost(hostA) || isWildcardHost(hostB)
+}
+
+func isWildcardHost(host string) bool {
+	return host == "" || host == "0.0.0.0" || host == "::"
+}
+
+// listen opens the TCP or Unix socket listener for srv, wrapped in TLS when
+// srv has a TLS config
+func listen(srv *http.Server) (net.Listener, error) {
+	listener, err := listenOn(srv.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if srv.TLSConfig != nil {
+		listener = tls.NewListener(listener, srv.TLSConfig)
+	}
+	return listener, nil
+}
+
+// listenOn opens a TCP listener for "host:port" or a Unix socket listener
+// for "unix:/path/to.sock"
+func listenOn(address string) (net.Listener, error) {
+	network := "tcp"
+	if path, ok := strings.CutPrefix(address, unixPrefix); ok {
+		network, address = "unix", path
+		// a socket left behind by a previous process would make Listen fail
+		if info, err := os.Stat(path); err == nil && info.Mode().Type() == fs.ModeSocket {
+			if err := os.Remove(path); err != nil {
+				return nil, fmt.Errorf("remove stale socket: %w", err)
+			}
+		}
+	}
+
+	return net.Listen(network, address)
+}
+
+func serverURL(srv *http.Server, listener net.Listener) string {
+	addr := listener.Addr()
+	if addr.Network() == "unix" {
+		return unixPrefix + addr.String()
+	}
+
+	scheme := "http"
+	if srv.TLSConfig != nil {
+		scheme = "https"
+	}
+	host := "localhost"
+	if _, port, err := net.SplitHostPort(addr.String()); err == nil {
+		host = net.JoinHostPort(host, port)
+	}
+	return scheme + "://" + host
+}
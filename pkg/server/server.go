@@ -1,55 +1,187 @@
 package server
 
 import (
+	"common/middlewares"
+	"common/pkg/correlation"
+	"common/pkg/logger"
+	"common/pkg/ratelimit"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/gin-contrib/cors"
-	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
 )
 
 type Server interface {
 	Start(ctx context.Context) error
 	Shutdown(ctx context.Context) error
 	Router() *gin.Engine
+	// RegisterCheck adds a readiness check reported by /health/ready
+	RegisterCheck(name string, fn CheckFunc, opts ...CheckOptions)
+	// Lifecycle holds the hooks run by Start and Shutdown
+	Lifecycle() *Lifecycle
+	// API registers typed handlers with Handle and describes them in the
+	// OpenAPI document
+	API() *API
+	// GRPC is the server to register gRPC services on; nil unless enabled
+	GRPC() *grpc.Server
 }
 
 type Config struct {
-	Port            int           `json:"port" yaml:"port"`
+	Port int `json:"port" yaml:"port"`
+	// Address overrides Port with a "host:port" to listen on, or
+	// "unix:/path/to.sock" for a Unix domain socket
+	Address string `json:"address" yaml:"address"`
+	// TLS serves HTTPS, and HTTP/2 through ALPN, with optional client certificates
+	TLS TLSConfig `json:"tls" yaml:"tls"`
+	// H2C serves HTTP/2 without TLS, for internal traffic behind a mesh or proxy
+	H2C bool `json:"h2c" yaml:"h2c"`
+	// TrustedProxies are the IPs or CIDRs of the proxies whose forwarding
+	// headers name the client. None are trusted when empty, so the client IP
+	// used by rate limiting, idempotency keys and logs is the peer address and
+	// cannot be spoofed with X-Forwarded-For
+	TrustedProxies []string `json:"trusted_proxies" yaml:"trusted_proxies"`
+	// RemoteIPHeaders are read from trusted proxies, in order; gin's
+	// X-Forwarded-For and X-Real-IP when empty
+	RemoteIPHeaders []string `json:"remote_ip_headers" yaml:"remote_ip_headers"`
+
 	ReadTimeout     time.Duration `json:"read_timeout" yaml:"read_timeout"`
 	WriteTimeout    time.Duration `json:"write_timeout" yaml:"write_timeout"`
 	ShutdownTimeout time.Duration `json:"shutdown_timeout" yaml:"shutdown_timeout"`
-	Mode            string        `json:"mode" yaml:"mode"`
-	MetricsEnabled  bool          `json:"metrics_enabled" yaml:"metrics_enabled"`
-	CorsEnabled     bool          `json:"cors_enabled" yaml:"cors_enabled"`
-	RateLimit       RateLimit     `json:"rate_limit" yaml:"rate_limit"`
+	// PreStopDelay is how long readiness fails before the server stops
+	// accepting connections, giving load balancers time to deregister it
+	PreStopDelay time.Duration `json:"pre_stop_delay" yaml:"pre_stop_delay"`
+	// HookTimeout bounds lifecycle hooks that do not set their own timeout
+	HookTimeout time.Duration `json:"hook_timeout" yaml:"hook_timeout"`
+
+	Mode           string                    `json:"mode" yaml:"mode"`
+	MetricsEnabled bool                      `json:"metrics_enabled" yaml:"metrics_enabled"`
+	Metrics        middlewares.MetricsConfig `json:"metrics" yaml:"metrics"`
+	CorsEnabled    bool                      `json:"cors_enabled" yaml:"cors_enabled"`
+	CORS           middlewares.CORSConfig    `json:"cors" yaml:"cors"`
+	RateLimit      RateLimit                 `json:"rate_limit" yaml:"rate_limit"`
+	Admin          AdminConfig               `json:"admin" yaml:"admin"`
+	OpenAPI        OpenAPIConfig             `json:"openapi" yaml:"openapi"`
+	GRPC           GRPCConfig                `json:"grpc" yaml:"grpc"`
+
+	// SecurityHeadersEnabled sends HSTS, CSP and the other browser security
+	// headers; SecurityHeaders can give route groups such as an admin
+	// frontend a policy of their own
+	SecurityHeadersEnabled bool                              `json:"security_headers_enabled" yaml:"security_headers_enabled"`
+	SecurityHeaders        middlewares.SecurityHeadersConfig `json:"security_headers" yaml:"security_headers"`
+
+	// Middleware is the ordered global middleware stack; DefaultMiddleware
+	// when empty. Entries name built-in middleware (Middleware* constants) or
+	// keys of CustomMiddleware. Built-ins whose feature is disabled are skipped
+	Middleware       []string                   `json:"middleware" yaml:"middleware"`
+	CustomMiddleware map[string]gin.HandlerFunc `json:"-" yaml:"-"`
+	// MaxBodyBytes limits request bodies; zero disables the limit
+	MaxBodyBytes int64 `json:"max_body_bytes" yaml:"max_body_bytes"`
+	// RequestTimeout sets a deadline on each request's context; zero disables it
+	RequestTimeout time.Duration                 `json:"request_timeout" yaml:"request_timeout"`
+	Compression    middlewares.CompressionConfig `json:"compression" yaml:"compression"`
+
+	// Logger receives panics and middleware errors; slog's default logger when nil
+	Logger logger.Logger `json:"-" yaml:"-"`
+}
+
+// Built-in middleware names for Config.Middleware
+const (
+	MiddlewareRequestID       = "request_id"
+	MiddlewareSecurityHeaders = "security_headers"
+	MiddlewareMetrics         = "metrics"
+	MiddlewareRecovery        = "recovery"
+	MiddlewareClientCert      = "client_cert"
+	MiddlewareCORS            = "cors"
+	MiddlewareRateLimit       = "rate_limit"
+	MiddlewareBodyLimit       = "body_limit"
+	MiddlewareTimeout         = "timeout"
+	MiddlewareCompression     = "compression"
+)
+
+// DefaultMiddleware runs metrics outside recovery so that recovered panics
+// are counted as 500s, and compression last so it wraps the handler output.
+// Security headers are set early so rejected requests carry them too
+var DefaultMiddleware = []string{
+	MiddlewareRequestID,
+	MiddlewareSecurityHeaders,
+	MiddlewareMetrics,
+	MiddlewareRecovery,
+	MiddlewareClientCert,
+	MiddlewareCORS,
+	MiddlewareRateLimit,
+	MiddlewareBodyLimit,
+	MiddlewareTimeout,
+	MiddlewareCompression,
 }
 
 type RateLimit struct {
 	Enable   bool          `json:"enable" yaml:"enable"`
 	Requests int           `json:"requests" yaml:"requests"`
 	Duration time.Duration `json:"duration" yaml:"duration"`
+
+	// KeyBy selects what requests are counted against: "ip" (default) or "api_key".
+	// "user" is rejected: the server-wide limiter runs before AuthMiddleware,
+	// so no request has a user yet. Use middlewares.RateLimitMiddleware with
+	// middlewares.RateLimitByUser on the authenticated group for per-user limits
+	KeyBy        string `json:"key_by" yaml:"key_by"`
+	APIKeyHeader string `json:"api_key_header" yaml:"api_key_header"`
+
+	// Routes overrides the limit per route template, optionally prefixed by method ("POST /users")
+	Routes map[string]ratelimit.Rule `json:"routes" yaml:"routes"`
+
+	// Store holds the buckets; an in-memory store is used when nil. Use
+	// ratelimit.NewRedisStore to share limits across instances
+	Store ratelimit.Store `json:"-" yaml:"-"`
 }
 
+const (
+	RateLimitKeyByIP = "ip"
+	// RateLimitKeyByUser is not valid for the server-wide limiter; see RateLimit.KeyBy
+	RateLimitKeyByUser   = "user"
+	RateLimitKeyByAPIKey = "api_key"
+)
+
 func DefaultConfig() Config {
 	return Config{
 		Port:            8080,
 		ReadTimeout:     10 * time.Second,
 		WriteTimeout:    10 * time.Second,
 		ShutdownTimeout: 5 * time.Second,
+		HookTimeout:     DefaultHookTimeout,
+		MaxBodyBytes:    10 << 20,
 		Mode:            gin.DebugMode,
 		// Mode:            gin.TestMode,
 		// Mode:            gin.ReleaseMode,
 		MetricsEnabled: true,
 		CorsEnabled:    true,
+		CORS: middlewares.CORSConfig{
+			CORSPolicy: middlewares.CORSPolicy{
+				AllowOrigins:     []string{"http://localhost:3000"},
+				AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+				AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+				ExposeHeaders:    []string{correlation.HeaderRequestID, correlation.HeaderCorrelationID},
+				AllowCredentials: true,
+			},
+		},
+		SecurityHeadersEnabled: true,
+		SecurityHeaders: middlewares.SecurityHeadersConfig{
+			SecurityHeadersPolicy: middlewares.DefaultSecurityHeadersPolicy(),
+		},
 		RateLimit: RateLimit{
 			Enable:   true,
 			Requests: 100,
@@ -62,6 +194,21 @@ type server struct {
 	cfg    Config
 	router *gin.Engine
 	srv    *http.Server
+	health *HealthChecker
+	logger logger.Logger
+	api    *API
+
+	// admin and adminSrv are nil unless the admin listener is enabled
+	admin    *gin.Engine
+	adminSrv *http.Server
+
+	// grpc and grpcHealth are nil unless gRPC is enabled
+	grpc       *grpc.Server
+	grpcHealth *health.Server
+
+	lifecycle    *Lifecycle
+	shutdownOnce sync.Once
+	shutdownErr  error
 }
 
 func New(config ...Config) (Server, error) {
@@ -78,28 +225,67 @@ func New(config ...Config) (Server, error) {
 	}
 
 	gin.SetMode(cfg.Mode)
-	router := gin.Default()
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid config: trusted proxies: %w", err)
+	}
+	if len(cfg.RemoteIPHeaders) > 0 {
+		router.RemoteIPHeaders = cfg.RemoteIPHeaders
+	}
 
 	s := &server{
-		cfg:    cfg,
-		router: router,
+		cfg:       cfg,
+		router:    router,
+		health:    NewHealthChecker(),
+		logger:    cfg.Logger,
+		lifecycle: NewLifecycle(cfg.HookTimeout),
 		srv: &http.Server{
-			Addr:         fmt.Sprintf(":%d", cfg.Port),
+			Addr:         listenAddress(cfg),
 			Handler:      router,
 			ReadTimeout:  cfg.ReadTimeout,
 			WriteTimeout: cfg.WriteTimeout,
 		},
 	}
 
-	s.setupMiddleware()
+	if cfg.Admin.Enabled {
+		s.admin, s.adminSrv = newAdminServer(cfg)
+	}
+
+	if s.logger == nil {
+		s.logger = logger.NewSlog(slog.Default().Handler())
+	}
+
+	if cfg.TLS.Enabled {
+		reloader, err := newCertReloader(cfg.TLS, s.logger)
+		if err != nil {
+			return nil, err
+		}
+		s.srv.TLSConfig = reloader.TLSConfig()
+	}
+
+	if cfg.GRPC.Enabled {
+		s.setupGRPC()
+		if cfg.GRPC.SharePort {
+			s.srv.Handler = grpcHandler(s.grpc, router)
+		}
+	}
+	// gRPC clients without TLS speak HTTP/2 with prior knowledge
+	if cfg.H2C || (cfg.GRPC.Enabled && cfg.GRPC.SharePort && !cfg.TLS.Enabled) {
+		s.srv.Handler = h2c.NewHandler(s.srv.Handler, &http2.Server{})
+	}
+
+	if err := s.setupMiddleware(); err != nil {
+		return nil, err
+	}
+	s.api = newAPI(&router.RouterGroup)
 	s.setupRoutes()
 
 	return s, nil
 }
 
 func validateConfig(cfg Config) error {
-	if cfg.Port < 1 || cfg.Port > 65535 {
-		return errors.New("port must be between 1 and 65535")
+	if err := validateAddress(cfg); err != nil {
+		return err
 	}
 	if cfg.ReadTimeout <= 0 {
 		return errors.New("read timeout must be positive")
@@ -107,51 +293,279 @@ func validateConfig(cfg Config) error {
 	if cfg.WriteTimeout <= 0 {
 		return errors.New("write timeout must be positive")
 	}
+	if cfg.Admin.Enabled {
+		if err := validateAdmin(cfg.Admin); err != nil {
+			return err
+		}
+	}
+	if err := validateMiddleware(cfg); err != nil {
+		return err
+	}
+	if cfg.PreStopDelay < 0 {
+		return errors.New("pre-stop delay must not be negative")
+	}
+	if cfg.TLS.Enabled {
+		if cfg.H2C {
+			return errors.New("h2c cannot be combined with tls, which negotiates HTTP/2 itself")
+		}
+		if err := validateTLS(cfg.TLS); err != nil {
+			return err
+		}
+	}
+	if cfg.GRPC.Enabled {
+		if err := validateGRPC(cfg.GRPC); err != nil {
+			return err
+		}
+		if !cfg.GRPC.SharePort {
+			address := grpcAddress(cfg.GRPC)
+			if sameListener(address, listenAddress(cfg)) {
+				return fmt.Errorf("grpc: address %q clashes with the HTTP listener; use share_port to serve both on one port", address)
+			}
+			if cfg.Admin.Enabled && sameListener(address, cfg.Admin.Address) {
+				return fmt.Errorf("grpc: address %q clashes with the admin listener", address)
+			}
+		}
+	}
+	if cfg.SecurityHeadersEnabled {
+		if err := cfg.SecurityHeaders.Validate(); err != nil {
+			return err
+		}
+	}
+	if cfg.CorsEnabled {
+		if err := cfg.CORS.Validate(); err != nil {
+			return err
+		}
+	}
+	if cfg.RateLimit.Enable {
+		if err := validateRateLimit(cfg.RateLimit); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (s *server) setupMiddleware() {
-	if s.cfg.CorsEnabled {
-		s.router.Use(cors.New(cors.Config{
-			AllowOrigins:     []string{"http://localhost:3000", "https://example.com"},
-			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-			ExposeHeaders:    []string{"X-Request-ID"},
-			AllowCredentials: true,
-		}))
+func validateMiddleware(cfg Config) error {
+	if cfg.MaxBodyBytes < 0 {
+		return errors.New("max body bytes must not be negative")
 	}
-	// if s.cfg.RateLimit.Enable {
-	// 	s.router.Use(s.rateLimitMiddleware())
-	// }
-	s.router.Use(requestid.New())
-	// if s.cfg.MetricsEnabled {
-	// 	s.router.Use(s.metricsMiddleware())
-	// }
+	if cfg.RequestTimeout < 0 {
+		return errors.New("request timeout must not be negative")
+	}
+
+	seen := make(map[string]bool, len(cfg.Middleware))
+	for _, name := range cfg.Middleware {
+		if seen[name] {
+			return fmt.Errorf("middleware %q listed twice", name)
+		}
+		seen[name] = true
+
+		if _, ok := cfg.CustomMiddleware[name]; ok {
+			continue
+		}
+		switch name {
+		case MiddlewareRequestID, MiddlewareSecurityHeaders, MiddlewareMetrics, MiddlewareRecovery, MiddlewareClientCert,
+			MiddlewareCORS, MiddlewareRateLimit, MiddlewareBodyLimit, MiddlewareTimeout, MiddlewareCompression:
+		default:
+			return fmt.Errorf("unknown middleware %q", name)
+		}
+	}
+	return nil
 }
 
+func validateRateLimit(cfg RateLimit) error {
+	if err := (ratelimit.Rule{Requests: cfg.Requests, Duration: cfg.Duration}).Validate(); err != nil {
+		return fmt.Errorf("rate limit: %w", err)
+	}
+	for route, rule := range cfg.Routes {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rate limit for %q: %w", route, err)
+		}
+	}
+	switch cfg.KeyBy {
+	case "", RateLimitKeyByIP, RateLimitKeyByAPIKey:
+		return nil
+	case RateLimitKeyByUser:
+		return errors.New("rate limit: key_by \"user\" is not supported server-wide because it runs before authentication; " +
+			"use middlewares.RateLimitMiddleware with middlewares.RateLimitByUser on the authenticated route group")
+	default:
+		return fmt.Errorf("rate limit: unknown key_by %q", cfg.KeyBy)
+	}
+}
+
+// setupMiddleware installs the configured stack in order
+func (s *server) setupMiddleware() error {
+	stack := s.cfg.Middleware
+	if len(stack) == 0 {
+		stack = DefaultMiddleware
+	}
+
+	for _, name := range stack {
+		handler, err := s.middleware(name)
+		if err != nil {
+			return err
+		}
+		if handler != nil {
+			s.router.Use(handler)
+		}
+	}
+	return nil
+}
+
+// middleware builds the named middleware, or returns nil when its feature is
+// disabled in the config
+func (s *server) middleware(name string) (gin.HandlerFunc, error) {
+	if custom, ok := s.cfg.CustomMiddleware[name]; ok {
+		return custom, nil
+	}
+
+	switch name {
+	case MiddlewareRequestID:
+		return middlewares.RequestIDMiddleware(nil), nil
+	case MiddlewareSecurityHeaders:
+		if s.cfg.SecurityHeadersEnabled {
+			return middlewares.SecurityHeadersMiddleware(s.cfg.SecurityHeaders), nil
+		}
+	case MiddlewareMetrics:
+		if s.cfg.MetricsEnabled {
+			return middlewares.MetricsMiddleware(s.cfg.Metrics), nil
+		}
+	case MiddlewareRecovery:
+		return middlewares.RecoveryMiddleware(s.logger), nil
+	case MiddlewareClientCert:
+		if s.cfg.TLS.Enabled && s.cfg.TLS.ClientAuth != "" && s.cfg.TLS.ClientAuth != ClientAuthNone {
+			return middlewares.ClientCertMiddleware(), nil
+		}
+	case MiddlewareCORS:
+		if s.cfg.CorsEnabled {
+			return middlewares.CORSMiddleware(s.cfg.CORS), nil
+		}
+	case MiddlewareRateLimit:
+		if s.cfg.RateLimit.Enable {
+			return s.rateLimitMiddleware(), nil
+		}
+	case MiddlewareBodyLimit:
+		if s.cfg.MaxBodyBytes > 0 {
+			return middlewares.BodyLimitMiddleware(s.cfg.MaxBodyBytes), nil
+		}
+	case MiddlewareTimeout:
+		if s.cfg.RequestTimeout > 0 {
+			return middlewares.TimeoutMiddleware(s.logger, s.cfg.RequestTimeout), nil
+		}
+	case MiddlewareCompression:
+		if s.cfg.Compression.Enabled {
+			return middlewares.CompressionMiddleware(s.cfg.Compression), nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown middleware %q", name)
+	}
+	return nil, nil
+}
+
+func (s *server) rateLimitMiddleware() gin.HandlerFunc {
+	cfg := s.cfg.RateLimit
+
+	store := cfg.Store
+	if store == nil {
+		store = ratelimit.NewMemoryStore()
+	}
+
+	var keyFunc middlewares.RateLimitKeyFunc
+	switch cfg.KeyBy {
+	case RateLimitKeyByAPIKey:
+		keyFunc = middlewares.RateLimitByAPIKey(cfg.APIKeyHeader)
+	default:
+		keyFunc = middlewares.RateLimitByIP
+	}
+
+	return middlewares.RateLimitMiddleware(s.logger, store, middlewares.RateLimitConfig{
+		Rule:    ratelimit.Rule{Requests: cfg.Requests, Duration: cfg.Duration},
+		Routes:  cfg.Routes,
+		KeyFunc: keyFunc,
+	})
+}
+
+// setupRoutes mounts the operations endpoints on the admin router when the
+// admin listener is enabled, and on the public router otherwise
 func (s *server) setupRoutes() {
-	s.setupHealthCheck()
+	if s.cfg.OpenAPI.Enabled {
+		s.setupOpenAPI()
+	}
+
+	if s.admin != nil {
+		s.setupAdmin()
+		s.setupHealthCheck(s.admin)
+		if s.cfg.MetricsEnabled {
+			s.setupMetrics(s.admin.Group("", s.adminAuth()))
+		}
+		return
+	}
+
+	s.setupHealthCheck(s.router)
 	if s.cfg.MetricsEnabled {
-		s.setupMetrics()
+		s.setupMetrics(s.router)
 	}
 }
 
+// Start runs the start hooks, serves until a signal arrives or ctx is done,
+// then shuts down gracefully
 func (s *server) Start(ctx context.Context) error {
-	errCh := make(chan error, 1)
+	if err := s.lifecycle.Start(ctx); err != nil {
+		return err
+	}
+
+	listener, err := listen(s.srv)
+	if err != nil {
+		return errors.Join(fmt.Errorf("server error: %w", err), s.lifecycle.Stop(context.Background()))
+	}
+
+	var adminListener net.Listener
+	if s.adminSrv != nil {
+		if adminListener, err = listen(s.adminSrv); err != nil {
+			listener.Close()
+			return errors.Join(fmt.Errorf("admin server error: %w", err), s.lifecycle.Stop(context.Background()))
+		}
+	}
+
+	grpcListener, err := s.listenGRPC()
+	if err != nil {
+		listener.Close()
+		if adminListener != nil {
+			adminListener.Close()
+		}
+		return errors.Join(fmt.Errorf("grpc server error: %w", err), s.lifecycle.Stop(context.Background()))
+	}
+
+	errCh := make(chan error, 3)
 	go func() {
-		serverURL := fmt.Sprintf("http://localhost:%d", s.cfg.Port)
-		fmt.Printf("Server is running on %s\n", serverURL)
-		if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Printf("Server is running on %s\n", serverURL(s.srv, listener))
+		if err := s.srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- err
 		}
 	}()
+	if adminListener != nil {
+		go func() {
+			fmt.Printf("Admin server is running on %s\n", serverURL(s.adminSrv, adminListener))
+			if err := s.adminSrv.Serve(adminListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- fmt.Errorf("admin: %w", err)
+			}
+		}()
+	}
+	if grpcListener != nil {
+		go func() {
+			fmt.Printf("gRPC server is running on %s\n", grpcListener.Addr())
+			if err := s.grpc.Serve(grpcListener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				errCh <- fmt.Errorf("grpc: %w", err)
+			}
+		}()
+	}
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
 
 	select {
 	case err := <-errCh:
-		return fmt.Errorf("server error: %w", err)
+		return errors.Join(fmt.Errorf("server error: %w", err), s.Shutdown(context.Background()))
 	case <-sigCh:
 		return s.Shutdown(ctx)
 	case <-ctx.Done():
@@ -159,19 +573,75 @@ func (s *server) Start(ctx context.Context) error {
 	}
 }
 
+// Shutdown fails readiness, waits PreStopDelay, drains in-flight HTTP
+// requests and gRPC calls within ShutdownTimeout and then runs the stop
+// hooks. It is safe to call more than once; later calls return the first result
 func (s *server) Shutdown(ctx context.Context) error {
-	shutdownCtx, cancel := context.WithTimeout(ctx, s.cfg.ShutdownTimeout)
-	defer cancel()
+	s.shutdownOnce.Do(func() {
+		s.health.SetShuttingDown(true)
+		if s.grpcHealth != nil {
+			s.grpcHealth.Shutdown()
+		}
+
+		var errs []error
+		if s.cfg.PreStopDelay > 0 {
+			select {
+			case <-time.After(s.cfg.PreStopDelay):
+			case <-ctx.Done():
+				errs = append(errs, fmt.Errorf("pre-stop delay: %w", ctx.Err()))
+			}
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, s.cfg.ShutdownTimeout)
+		defer cancel()
+		grpcErr := make(chan error, 1)
+		if s.grpc != nil {
+			go func() { grpcErr <- s.stopGRPC(shutdownCtx) }()
+		} else {
+			grpcErr <- nil
+		}
+		if err := s.srv.Shutdown(shutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("drain http server: %w", err))
+		}
+		if err := <-grpcErr; err != nil {
+			errs = append(errs, fmt.Errorf("drain grpc server: %w", err))
+		}
+
+		if err := s.lifecycle.Stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+
+		// the admin listener goes last so metrics stay scrapable while draining
+		if s.adminSrv != nil {
+			adminCtx, cancel := context.WithTimeout(ctx, s.cfg.ShutdownTimeout)
+			defer cancel()
+			if err := s.adminSrv.Shutdown(adminCtx); err != nil {
+				errs = append(errs, fmt.Errorf("drain admin server: %w", err))
+			}
+		}
+		s.shutdownErr = errors.Join(errs...)
+	})
+	return s.shutdownErr
+}
+
+func (s *server) Lifecycle() *Lifecycle {
+	return s.lifecycle
+}
 
-	return s.srv.Shutdown(shutdownCtx)
+func (s *server) API() *API {
+	return s.api
 }
 
 func (s *server) Router() *gin.Engine {
 	return s.router
 }
 
-func (s *server) setupHealthCheck() {
-	health := s.router.Group("/health")
+func (s *server) RegisterCheck(name string, fn CheckFunc, opts ...CheckOptions) {
+	s.health.Register(name, fn, opts...)
+}
+
+func (s *server) setupHealthCheck(router gin.IRouter) {
+	health := router.Group("/health")
 	{
 		health.GET("", s.handleServerHeath())
 		health.GET("/live", s.handleLiveness())
@@ -185,9 +655,17 @@ func (s *server) handleLiveness() gin.HandlerFunc {
 	}
 }
 
+// handleReadiness runs the registered checks and answers 503 when a critical
+// one fails, so the pod is taken out of load balancing
 func (s *server) handleReadiness() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Status(http.StatusOK)
+		report := s.health.Check(c.Request.Context())
+
+		status := http.StatusOK
+		if report.Status == StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
 	}
 }
 
@@ -199,6 +677,18 @@ func (s *server) handleServerHeath() gin.HandlerFunc {
 	}
 }
 
-func (s *server) setupMetrics() {
-	s.router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+func (s *server) setupMetrics(router gin.IRouter) {
+	// OpenMetrics output is required for the trace ID exemplars to be exposed
+	registerer, gatherer := prometheus.DefaultRegisterer, prometheus.DefaultGatherer
+	if s.cfg.Metrics.Registerer != nil {
+		registerer = s.cfg.Metrics.Registerer
+		if g, ok := registerer.(prometheus.Gatherer); ok {
+			gatherer = g
+		}
+	}
+	handler := promhttp.InstrumentMetricHandler(
+		registerer,
+		promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)
+	router.GET("/metrics", gin.WrapH(handler))
 }
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"common/middlewares"
+	"common/pkg/logger/loggertest"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMiddlewareStack(t *testing.T) {
+	log, recorder := loggertest.New()
+
+	var order []string
+	mark := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			order = append(order, name)
+			c.Next()
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.MetricsEnabled = false
+	cfg.Logger = log
+	cfg.Middleware = []string{MiddlewareRequestID, "first", MiddlewareRecovery, "second", MiddlewareBodyLimit}
+	cfg.CustomMiddleware = map[string]gin.HandlerFunc{"first": mark("first"), "second": mark("second")}
+	cfg.MaxBodyBytes = 4
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Router().GET("/panic", func(c *gin.Context) { panic("boom") })
+	srv.Router().POST("/upload", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	srv.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("panic status = %d, want 500", w.Code)
+	}
+	if strings.Join(order, ",") != "first,second" {
+		t.Errorf("custom middleware order = %v", order)
+	}
+	entries := recorder.FilterMessage("panic recovered").All()
+	if len(entries) != 1 || entries[0].ContextMap()["request_id"] == "" {
+		t.Errorf("panic not logged with request ID: %+v", entries)
+	}
+
+	w = httptest.NewRecorder()
+	srv.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("too large")))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("upload status = %d, want 413", w.Code)
+	}
+}
+
+func TestValidateMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		stack   []string
+		wantErr bool
+	}{
+		{"default", nil, false},
+		{"built-ins", []string{MiddlewareRecovery, MiddlewareCompression}, false},
+		{"unknown", []string{"gzip"}, true},
+		{"duplicate", []string{MiddlewareRecovery, MiddlewareRecovery}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Middleware = tt.stack
+			if err := validateConfig(cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultSecurityHeaders(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.MetricsEnabled = false
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Router().GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	srv.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	for _, name := range []string{"Strict-Transport-Security", "Content-Security-Policy", "X-Content-Type-Options", "X-Frame-Options", "Referrer-Policy", "Permissions-Policy"} {
+		if w.Header().Get(name) == "" {
+			t.Errorf("%s missing from default response headers", name)
+		}
+	}
+}
+
+func TestValidateRateLimitKeyBy(t *testing.T) {
+	tests := []struct {
+		keyBy   string
+		wantErr bool
+	}{
+		{"", false},
+		{RateLimitKeyByIP, false},
+		{RateLimitKeyByAPIKey, false},
+		{RateLimitKeyByUser, true},
+		{"session", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.keyBy, func(t *testing.T) {
+			err := validateRateLimit(RateLimit{Enable: true, Requests: 10, Duration: time.Second, KeyBy: tt.keyBy})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRateLimit(%q) error = %v, wantErr %v", tt.keyBy, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMetricsEndpointServesConfiguredRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.Metrics = middlewares.MetricsConfig{Namespace: "custom", Registerer: registry}
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Router().GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	srv.Router().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	w := httptest.NewRecorder()
+	srv.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(w.Body.String(), "custom_http_requests_total") {
+		t.Errorf("/metrics does not serve the configured registry:\n%s", w.Body.String())
+	}
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		wantLimited    bool
+	}{
+		{"no trusted proxies", nil, true},
+		{"trusted proxy", []string{"10.0.0.1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Mode = gin.TestMode
+			cfg.MetricsEnabled = false
+			cfg.TrustedProxies = tt.trustedProxies
+			cfg.RateLimit = RateLimit{Enable: true, Requests: 1, Duration: time.Minute}
+			srv, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv.Router().GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			limited := false
+			for i := 0; i < 3; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/users", nil)
+				req.RemoteAddr = "10.0.0.1:1234"
+				req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i+1))
+				w := httptest.NewRecorder()
+				srv.Router().ServeHTTP(w, req)
+				if w.Code == http.StatusTooManyRequests {
+					limited = true
+				}
+			}
+			if limited != tt.wantLimited {
+				t.Errorf("limited = %v, want %v", limited, tt.wantLimited)
+			}
+		})
+	}
+}
+
+func TestInvalidTrustedProxies(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.TrustedProxies = []string{"not-an-ip"}
+	if _, err := New(cfg); err == nil {
+		t.Error("New accepted an invalid trusted proxy")
+	}
+}
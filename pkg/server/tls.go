@@ -0,0 +1,224 @@
+package server
+
+import (
+	"common/pkg/logger"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const DefaultTLSReloadInterval = 30 * time.Second
+
+// Client certificate policies for TLSConfig.ClientAuth
+const (
+	ClientAuthNone             = "none"
+	ClientAuthRequest          = "request"
+	ClientAuthVerifyIfGiven    = "verify_if_given"
+	ClientAuthRequireAndVerify = "require_and_verify"
+)
+
+type TLSConfig struct {
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+	CertFile string `json:"cert_file" yaml:"cert_file"`
+	KeyFile  string `json:"key_file" yaml:"key_file"`
+
+	// ClientCAFile holds the CAs client certificates are verified against.
+	// It is required for the verify_if_given and require_and_verify policies
+	ClientCAFile string `json:"client_ca_file" yaml:"client_ca_file"`
+	// ClientAuth is one of none (default), request, verify_if_given and require_and_verify
+	ClientAuth string `json:"client_auth" yaml:"client_auth"`
+
+	// MinVersion is "1.2" (default) or "1.3"
+	MinVersion string `json:"min_version" yaml:"min_version"`
+
+	// ReloadInterval is how often the certificate files are checked for
+	// changes; DefaultTLSReloadInterval when zero, negative disables reload
+	ReloadInterval time.Duration `json:"reload_interval" yaml:"reload_interval"`
+}
+
+func validateTLS(cfg TLSConfig) error {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return errors.New("tls: cert_file and key_file are required")
+	}
+	if _, err := parseClientAuth(cfg.ClientAuth); err != nil {
+		return err
+	}
+	switch cfg.ClientAuth {
+	case ClientAuthVerifyIfGiven, ClientAuthRequireAndVerify:
+		if cfg.ClientCAFile == "" {
+			return fmt.Errorf("tls: client_ca_file is required for client_auth %q", cfg.ClientAuth)
+		}
+	}
+	if _, err := parseTLSVersion(cfg.MinVersion); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseClientAuth(policy string) (tls.ClientAuthType, error) {
+	switch policy {
+	case "", ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequireAndVerify:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("tls: unknown client_auth %q", policy)
+	}
+}
+
+func parseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("tls: unsupported min_version %q", version)
+	}
+}
+
+// certReloader serves the certificate and client CAs from disk and picks up
+// renewed files without a restart
+type certReloader struct {
+	cfg    TLSConfig
+	base   *tls.Config
+	logger logger.Logger
+
+	mu      sync.RWMutex
+	current *tls.Config
+	modTime map[string]time.Time
+	checked time.Time
+}
+
+func newCertReloader(cfg TLSConfig, logger logger.Logger) (*certReloader, error) {
+	clientAuth, err := parseClientAuth(cfg.ClientAuth)
+	if err != nil {
+		return nil, err
+	}
+	minVersion, err := parseTLSVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ReloadInterval == 0 {
+		cfg.ReloadInterval = DefaultTLSReloadInterval
+	}
+
+	r := &certReloader{
+		cfg:    cfg,
+		logger: logger,
+		base: &tls.Config{
+			MinVersion: minVersion,
+			ClientAuth: clientAuth,
+			NextProtos: []string{"h2", "http/1.1"},
+		},
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig returns the config to hand to http.Server; each handshake gets
+// the most recently loaded certificate
+func (r *certReloader) TLSConfig() *tls.Config {
+	config := r.base.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		return r.config(), nil
+	}
+	return config
+}
+
+func (r *certReloader) config() *tls.Config {
+	r.mu.RLock()
+	current, checked := r.current, r.checked
+	r.mu.RUnlock()
+
+	if r.cfg.ReloadInterval < 0 || time.Since(checked) < r.cfg.ReloadInterval {
+		return current
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) < r.cfg.ReloadInterval {
+		return r.current
+	}
+	r.checked = time.Now()
+	if r.changed() {
+		// a failed reload keeps serving the previous certificate
+		if err := r.loadLocked(); err != nil {
+			r.logger.Error("tls reload failed, keeping previous certificate", "error", err)
+		}
+	}
+	return r.current
+}
+
+func (r *certReloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+func (r *certReloader) changed() bool {
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTime[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *certReloader) load() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checked = time.Now()
+	return r.loadLocked()
+}
+
+func (r *certReloader) loadLocked() error {
+	modTime := make(map[string]time.Time, 3)
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		modTime[file] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tls: load key pair: %w", err)
+	}
+
+	config := r.base.Clone()
+	config.Certificates = []tls.Certificate{cert}
+
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("tls: read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates found in %s", r.cfg.ClientCAFile)
+		}
+		config.ClientCAs = pool
+	}
+
+	r.current = config
+	r.modTime = modTime
+	return nil
+}
@@ -0,0 +1,251 @@
+package server
+
+import (
+	"common/middlewares"
+	"common/pkg/logger/loggertest"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, serial int64, cn string, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"platform"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if isCA {
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if keyFile != "" {
+		if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func serve(t *testing.T, srv Server) net.Listener {
+	t.Helper()
+	s := srv.(*server)
+	listener, err := listen(s.srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.srv.Serve(listener)
+	t.Cleanup(func() { s.srv.Shutdown(context.Background()) })
+	return listener
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, 1, "test-ca", nil, true)
+	ca.write(t, filepath.Join(dir, "ca.pem"), "")
+	newTestCert(t, 2, "localhost", ca, false).write(t, filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	client := newTestCert(t, 3, "billing-service", ca, false)
+
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.MetricsEnabled = false
+	cfg.Address = "127.0.0.1:0"
+	cfg.TLS = TLSConfig{
+		Enabled:        true,
+		CertFile:       filepath.Join(dir, "cert.pem"),
+		KeyFile:        filepath.Join(dir, "key.pem"),
+		ClientCAFile:   filepath.Join(dir, "ca.pem"),
+		ClientAuth:     ClientAuthRequireAndVerify,
+		ReloadInterval: time.Nanosecond,
+	}
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Router().GET("/whoami", func(c *gin.Context) {
+		identity, _ := middlewares.GetClientIdentity(c)
+		c.String(http.StatusOK, identity.CommonName)
+	})
+	listener := serve(t, srv)
+	url := "https://" + listener.Addr().String() + "/whoami"
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	httpClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs},
+			ForceAttemptHTTP2: true,
+		}}
+	}
+
+	resp, err := httpClient(client.tlsCertificate()).Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "billing-service" {
+		t.Errorf("identity = %q, want billing-service", body)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Errorf("protocol = %s, want HTTP/2", resp.Proto)
+	}
+
+	if _, err := httpClient().Get(url); err == nil {
+		t.Error("request without a client certificate should fail")
+	}
+
+	// rotate the server certificate and check the next handshake serves it
+	time.Sleep(10 * time.Millisecond)
+	newTestCert(t, 42, "localhost", ca, false).write(t, filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	future := time.Now().Add(time.Second)
+	os.Chtimes(filepath.Join(dir, "cert.pem"), future, future)
+
+	resp, err = httpClient(client.tlsCertificate()).Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if serial := resp.TLS.PeerCertificates[0].SerialNumber.Int64(); serial != 42 {
+		t.Errorf("served certificate serial = %d, want reloaded 42", serial)
+	}
+}
+
+func TestCertReloadFailureKeepsCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	newTestCert(t, 7, "localhost", nil, false).write(t, certFile, keyFile)
+
+	log, recorder := loggertest.New()
+	reloader, err := newCertReloader(TLSConfig{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Nanosecond}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Second)
+	os.Chtimes(certFile, future, future)
+	time.Sleep(time.Millisecond)
+
+	config := reloader.config()
+	if len(config.Certificates) != 1 {
+		t.Fatalf("serving %d certificates after a failed reload, want the previous one", len(config.Certificates))
+	}
+	recorder.AssertLogged(t, loggertest.ErrorLevel, "tls reload failed, keeping previous certificate", nil)
+}
+
+func TestUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "api.sock")
+
+	cfg := DefaultConfig()
+	cfg.Mode = gin.TestMode
+	cfg.MetricsEnabled = false
+	cfg.Address = "unix:" + socket
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve(t, srv)
+
+	httpClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	resp, err := httpClient.Get("http://unix/health/live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestValidateListenConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr bool
+	}{
+		{"default", func(cfg *Config) {}, false},
+		{"host and port", func(cfg *Config) { cfg.Address = "0.0.0.0:9000" }, false},
+		{"unix socket", func(cfg *Config) { cfg.Address = "unix:/tmp/api.sock" }, false},
+		{"empty socket path", func(cfg *Config) { cfg.Address = "unix:" }, true},
+		{"missing port", func(cfg *Config) { cfg.Address = "localhost" }, true},
+		{"tls without files", func(cfg *Config) { cfg.TLS.Enabled = true }, true},
+		{"mtls without CA", func(cfg *Config) {
+			cfg.TLS = TLSConfig{Enabled: true, CertFile: "c", KeyFile: "k", ClientAuth: ClientAuthRequireAndVerify}
+		}, true},
+		{"tls with h2c", func(cfg *Config) {
+			cfg.TLS = TLSConfig{Enabled: true, CertFile: "c", KeyFile: "k"}
+			cfg.H2C = true
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(&cfg)
+			if err := validateConfig(cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
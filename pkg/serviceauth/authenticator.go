@@ -0,0 +1,115 @@
+package serviceauth
+
+import (
+	"common/pkg/auth"
+	"common/pkg/jwt"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TokenType marks service tokens so they cannot be mistaken for user tokens
+// signed with the same secret; auth.FromClaims rejects it
+const TokenType = auth.ServiceTokenType
+
+// Authenticator validates API keys and issues and validates service tokens
+// for one service, which is the audience of every token it issues
+type Authenticator struct {
+	store     Store
+	jwt       jwt.JWT
+	serviceID string
+}
+
+// NewAuthenticator creates the authenticator for the service serviceID.
+// Token lifetime is the TokenDuration of the jwt.JWT config
+func NewAuthenticator(store Store, jwt jwt.JWT, serviceID string) (*Authenticator, error) {
+	if store == nil || jwt == nil {
+		return nil, errors.New("serviceauth: store and jwt are required")
+	}
+	if !serviceIDPattern.MatchString(serviceID) {
+		return nil, fmt.Errorf("serviceauth: invalid service id %q", serviceID)
+	}
+	return &Authenticator{store: store, jwt: jwt, serviceID: serviceID}, nil
+}
+
+// AuthenticateKey returns the identity of the service owning plaintext, or
+// ErrInvalidKey when the key is unknown or outside its validity window
+func (a *Authenticator) AuthenticateKey(ctx context.Context, plaintext string) (*Identity, error) {
+	if !strings.HasPrefix(plaintext, KeyPrefix) {
+		return nil, ErrInvalidKey
+	}
+	key, err := a.store.Lookup(ctx, HashKey(plaintext))
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !key.Active(time.Now()) {
+		return nil, ErrInvalidKey
+	}
+	return &Identity{ServiceID: key.ServiceID, KeyID: key.ID, Scopes: key.Scopes}, nil
+}
+
+// IssueToken signs a service token for identity, scoped to this service
+func (a *Authenticator) IssueToken(identity *Identity) (string, error) {
+	return a.jwt.GenerateToken(map[string]interface{}{
+		"token_type": TokenType,
+		"service_id": identity.ServiceID,
+		"key_id":     identity.KeyID,
+		"scope":      strings.Join(identity.Scopes, " "),
+		"aud":        a.serviceID,
+		"iss":        a.serviceID,
+		"iat":        time.Now().Unix(),
+	})
+}
+
+// ValidateToken checks the signature, expiry, type and audience of a service
+// token and returns the identity it carries
+func (a *Authenticator) ValidateToken(token string) (*Identity, error) {
+	parsed, err := a.jwt.ValidateToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	claims, err := a.jwt.GetClaims(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims["token_type"] != TokenType {
+		return nil, fmt.Errorf("%w: not a service token", ErrInvalidToken)
+	}
+	if !hasAudience(claims["aud"], a.serviceID) {
+		return nil, fmt.Errorf("%w: audience is not %s", ErrInvalidToken, a.serviceID)
+	}
+	serviceID, _ := claims["service_id"].(string)
+	if serviceID == "" {
+		return nil, fmt.Errorf("%w: missing service_id", ErrInvalidToken)
+	}
+
+	identity := &Identity{ServiceID: serviceID}
+	identity.KeyID, _ = claims["key_id"].(string)
+	if scope, ok := claims["scope"].(string); ok {
+		identity.Scopes = strings.Fields(scope)
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		identity.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	return identity, nil
+}
+
+func hasAudience(aud interface{}, serviceID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == serviceID
+	case []interface{}:
+		for _, item := range v {
+			if item == serviceID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package serviceauth
+
+import (
+	"common/pkg/jwt"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestAuthenticator(t *testing.T, serviceID string, keys ...APIKey) *Authenticator {
+	t.Helper()
+	tokens := jwt.New(jwt.JWTConfig{SecretKey: []byte("shared-secret"), TokenDuration: time.Hour})
+	a, err := NewAuthenticator(NewMemoryStore(keys...), tokens, serviceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestAuthenticateKey(t *testing.T) {
+	plaintext, key, err := NewAPIKey("payment-service", []string{"transaction:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiredPlaintext, expired, err := NewAPIKey("payment-service", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	a := newTestAuthenticator(t, "ledger", key, expired)
+
+	identity, err := a.AuthenticateKey(context.Background(), plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.ServiceID != "payment-service" || identity.KeyID != key.ID || !identity.HasScope("transaction:read") {
+		t.Errorf("identity = %+v", identity)
+	}
+
+	for name, candidate := range map[string]string{
+		"expired": expiredPlaintext,
+		"unknown": "pk_payment-service_unknown",
+		"format":  "not-a-key",
+	} {
+		if _, err := a.AuthenticateKey(context.Background(), candidate); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%s key: err = %v, want ErrInvalidKey", name, err)
+		}
+	}
+}
+
+func TestServiceToken(t *testing.T) {
+	ledger := newTestAuthenticator(t, "ledger")
+	billing := newTestAuthenticator(t, "billing")
+
+	token, err := ledger.IssueToken(&Identity{ServiceID: "payment-service", KeyID: "k1", Scopes: []string{"transaction:*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := ledger.ValidateToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.ServiceID != "payment-service" || !identity.HasScope("transaction:write") || identity.ExpiresAt.IsZero() {
+		t.Errorf("identity = %+v", identity)
+	}
+
+	// a token for the ledger must not be accepted by billing, even with a shared secret
+	if _, err := billing.ValidateToken(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("wrong audience: err = %v, want ErrInvalidToken", err)
+	}
+
+	userToken, err := jwt.New(jwt.JWTConfig{SecretKey: []byte("shared-secret"), TokenDuration: time.Hour}).
+		GenerateToken(map[string]interface{}{"user_id": "u1", "aud": "ledger"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ledger.ValidateToken(userToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("user token: err = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,87 @@
+package serviceauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// fileStore keeps keys in a JSON file, for deployments that ship key hashes
+// with their configuration. Writes replace the file atomically
+type fileStore struct {
+	*memoryStore
+	path    string
+	writeMu sync.Mutex
+}
+
+type keyFile struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// NewFileStore loads the keys in path, a JSON document of the form
+// {"keys": [...]}. A missing file is treated as empty and created on the
+// first Put
+func NewFileStore(path string) (*fileStore, error) {
+	s := &fileStore{memoryStore: NewMemoryStore(), path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads the file, picking up keys added by another process
+func (s *fileStore) Reload() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("serviceauth: read key file: %w", err)
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("serviceauth: parse key file: %w", err)
+	}
+
+	loaded := NewMemoryStore(file.Keys...)
+	s.mu.Lock()
+	s.byID, s.byHash = loaded.byID, loaded.byHash
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *fileStore) Put(ctx context.Context, key APIKey) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.memoryStore.Put(ctx, key); err != nil {
+		return err
+	}
+
+	keys := s.all()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	data, err := json.MarshalIndent(keyFile{Keys: keys}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("serviceauth: write key file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("serviceauth: write key file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("serviceauth: write key file: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
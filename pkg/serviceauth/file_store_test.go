@@ -0,0 +1,47 @@
+package serviceauth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, key, err := NewAPIKey("orders", []string{"orders:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), plaintext) || !strings.Contains(string(data), key.Hash) {
+		t.Errorf("file must hold the hash only:\n%s", data)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := reopened.Lookup(ctx, HashKey(plaintext))
+	if err != nil || found.ID != key.ID {
+		t.Fatalf("lookup after reopen = %+v, %v", found, err)
+	}
+	if _, err := reopened.Lookup(ctx, HashKey("pk_orders_other")); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("unknown key: err = %v, want ErrKeyNotFound", err)
+	}
+}
@@ -0,0 +1,44 @@
+package serviceauth
+
+import (
+	"common/pkg/authz"
+	"context"
+	"time"
+)
+
+// Identity is an authenticated calling service
+type Identity struct {
+	ServiceID string
+	// KeyID is the API key the identity was established with
+	KeyID  string
+	Scopes []string
+	// ExpiresAt is when the service token expires; zero for API key requests
+	ExpiresAt time.Time
+}
+
+// HasScope reports whether the service was granted scope, directly or
+// through a wildcard such as "transaction:*"
+func (i *Identity) HasScope(scope string) bool {
+	for _, granted := range i.Scopes {
+		if authz.MatchPermission(granted, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying identity
+func NewContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext returns the identity stored by NewContext, or ErrNoIdentity
+func FromContext(ctx context.Context) (*Identity, error) {
+	identity, ok := ctx.Value(contextKey{}).(*Identity)
+	if !ok || identity == nil {
+		return nil, ErrNoIdentity
+	}
+	return identity, nil
+}
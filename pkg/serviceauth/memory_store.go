@@ -0,0 +1,73 @@
+package serviceauth
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryStore keeps keys in process, for tests and single-instance setups
+type memoryStore struct {
+	mu     sync.RWMutex
+	byID   map[string]APIKey
+	byHash map[string]string
+}
+
+// NewMemoryStore creates a store holding keys
+func NewMemoryStore(keys ...APIKey) *memoryStore {
+	s := &memoryStore{byID: make(map[string]APIKey), byHash: make(map[string]string)}
+	for _, key := range keys {
+		s.put(key)
+	}
+	return s
+}
+
+func (s *memoryStore) Lookup(ctx context.Context, hash string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.byHash[hash]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	key := s.byID[id]
+	return &key, nil
+}
+
+func (s *memoryStore) Keys(ctx context.Context, serviceID string) ([]APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []APIKey
+	for _, key := range s.byID {
+		if key.ServiceID == serviceID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *memoryStore) Put(ctx context.Context, key APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(key)
+	return nil
+}
+
+func (s *memoryStore) put(key APIKey) {
+	if previous, ok := s.byID[key.ID]; ok {
+		delete(s.byHash, previous.Hash)
+	}
+	s.byID[key.ID] = key
+	s.byHash[key.Hash] = key.ID
+}
+
+func (s *memoryStore) all() []APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]APIKey, 0, len(s.byID))
+	for _, key := range s.byID {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,106 @@
+package serviceauth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// PostgresSchema creates the table used by the Postgres store; add it to
+// the service's migrations
+const PostgresSchema = `
+CREATE TABLE IF NOT EXISTS service_api_keys (
+	id         TEXT PRIMARY KEY,
+	service_id TEXT NOT NULL,
+	hash       TEXT NOT NULL UNIQUE,
+	scopes     JSONB NOT NULL DEFAULT '[]',
+	created_at TIMESTAMPTZ NOT NULL,
+	not_before TIMESTAMPTZ,
+	expires_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS service_api_keys_service_id ON service_api_keys (service_id);
+`
+
+const keyColumns = `id, service_id, hash, scopes, created_at, not_before, expires_at`
+
+// postgresStore keeps keys in the service_api_keys table
+type postgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore uses db, typically the *sql.DB embedded in database.DB
+func NewPostgresStore(db *sql.DB) *postgresStore {
+	return &postgresStore{db: db}
+}
+
+func (s *postgresStore) Lookup(ctx context.Context, hash string) (*APIKey, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM service_api_keys WHERE hash = $1`, hash)
+	key, err := scanKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *postgresStore) Keys(ctx context.Context, serviceID string) ([]APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+keyColumns+` FROM service_api_keys WHERE service_id = $1`, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *postgresStore) Put(ctx context.Context, key APIKey) error {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO service_api_keys (`+keyColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			service_id = EXCLUDED.service_id,
+			hash = EXCLUDED.hash,
+			scopes = EXCLUDED.scopes,
+			not_before = EXCLUDED.not_before,
+			expires_at = EXCLUDED.expires_at`,
+		key.ID, key.ServiceID, key.Hash, scopes, key.CreatedAt, nullTime(key.NotBefore), nullTime(key.ExpiresAt))
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanKey(row scanner) (APIKey, error) {
+	var key APIKey
+	var scopes []byte
+	var notBefore, expiresAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.ServiceID, &key.Hash, &scopes, &key.CreatedAt, &notBefore, &expiresAt); err != nil {
+		return APIKey{}, err
+	}
+	if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+		return APIKey{}, err
+	}
+	key.NotBefore, key.ExpiresAt = notBefore.Time, expiresAt.Time
+	return key, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,84 @@
+package serviceauth
+
+import (
+	"common/pkg/redis"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// redisStore keeps each key as JSON under <prefix>:hash:<hash>, with a set
+// of hashes per service under <prefix>:service:<id>. Keys with an expiry
+// are removed by Redis once they expire
+type redisStore struct {
+	redis  redis.Redis
+	prefix string
+}
+
+// NewRedisStore creates a store that namespaces its keys with prefix
+func NewRedisStore(r redis.Redis, prefix string) *redisStore {
+	if prefix == "" {
+		prefix = "serviceauth"
+	}
+	return &redisStore{redis: r, prefix: prefix}
+}
+
+func (s *redisStore) Lookup(ctx context.Context, hash string) (*APIKey, error) {
+	data, err := s.redis.Get(s.prefix + ":hash:" + hash).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var key APIKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *redisStore) Keys(ctx context.Context, serviceID string) ([]APIKey, error) {
+	setKey := s.prefix + ":service:" + serviceID
+	hashes, err := s.redis.SMembers(setKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []APIKey
+	for _, hash := range hashes {
+		key, err := s.Lookup(ctx, hash)
+		if errors.Is(err, ErrKeyNotFound) {
+			// expired by Redis; drop it from the index
+			s.redis.SRem(setKey, hash)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+func (s *redisStore) Put(ctx context.Context, key APIKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	var ttl time.Duration
+	if !key.ExpiresAt.IsZero() {
+		if ttl = time.Until(key.ExpiresAt); ttl <= 0 {
+			ttl = time.Second
+		}
+	}
+	if err := s.redis.Set(s.prefix+":hash:"+key.Hash, data, ttl).Err(); err != nil {
+		return err
+	}
+	return s.redis.SAdd(s.prefix+":service:"+key.ServiceID, key.Hash).Err()
+}
@@ -0,0 +1,134 @@
+// Package serviceauth authenticates calls between services as described in
+// docs/internal-microservices-communication.md: a calling service presents
+// an API key once, receives a short-lived service JWT, and sends the JWT on
+// later requests. API keys are stored only as SHA-256 hashes, and a service
+// may hold several keys at once so keys can be rotated without downtime
+package serviceauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// KeyPrefix marks API keys so they are recognisable in configuration and
+// secret scanners
+const KeyPrefix = "pk_"
+
+var (
+	ErrKeyNotFound  = errors.New("serviceauth: api key not found")
+	ErrInvalidKey   = errors.New("serviceauth: invalid or inactive api key")
+	ErrInvalidToken = errors.New("serviceauth: invalid service token")
+	ErrNoIdentity   = errors.New("serviceauth: no service identity in context")
+)
+
+var serviceIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// APIKey is a stored key. The plaintext is only known to the calling service
+type APIKey struct {
+	ID        string    `json:"id"`
+	ServiceID string    `json:"service_id"`
+	Hash      string    `json:"hash"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	// NotBefore and ExpiresAt bound the validity; zero means unbounded
+	NotBefore time.Time `json:"not_before,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Active reports whether the key is valid at now
+func (k APIKey) Active(now time.Time) bool {
+	if !k.NotBefore.IsZero() && now.Before(k.NotBefore) {
+		return false
+	}
+	return k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt)
+}
+
+// Store holds hashed API keys. Implementations must be safe for concurrent use
+type Store interface {
+	// Lookup returns the key with the given hash, or ErrKeyNotFound
+	Lookup(ctx context.Context, hash string) (*APIKey, error)
+	// Keys lists every key of a service, active or not
+	Keys(ctx context.Context, serviceID string) ([]APIKey, error)
+	// Put creates or replaces the key with the same ID
+	Put(ctx context.Context, key APIKey) error
+}
+
+// HashKey returns the stored form of a plaintext key. Keys carry 256 bits
+// of randomness, so an unsalted hash cannot be brute-forced
+func HashKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIKey generates a key for serviceID in the documented
+// pk_<service>_<random> format. The plaintext must be handed to the calling
+// service and is not recoverable from the returned APIKey
+func NewAPIKey(serviceID string, scopes []string) (string, APIKey, error) {
+	if !serviceIDPattern.MatchString(serviceID) {
+		return "", APIKey{}, fmt.Errorf("serviceauth: invalid service id %q", serviceID)
+	}
+
+	secret := make([]byte, 32)
+	id := make([]byte, 8)
+	if _, err := rand.Read(secret); err != nil {
+		return "", APIKey{}, err
+	}
+	if _, err := rand.Read(id); err != nil {
+		return "", APIKey{}, err
+	}
+
+	plaintext := KeyPrefix + serviceID + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return plaintext, APIKey{
+		ID:        hex.EncodeToString(id),
+		ServiceID: serviceID,
+		Hash:      HashKey(plaintext),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Rotate issues a new key for serviceID with the scopes of its newest key
+// and makes every active key expire after overlap, so callers can switch to
+// the new key while the old one still works
+func Rotate(ctx context.Context, store Store, serviceID string, overlap time.Duration) (string, APIKey, error) {
+	keys, err := store.Keys(ctx, serviceID)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+
+	now := time.Now().UTC()
+	var scopes []string
+	var newest time.Time
+	for _, key := range keys {
+		if newest.IsZero() || key.CreatedAt.After(newest) {
+			newest, scopes = key.CreatedAt, key.Scopes
+		}
+	}
+
+	plaintext, created, err := NewAPIKey(serviceID, scopes)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	if err := store.Put(ctx, created); err != nil {
+		return "", APIKey{}, err
+	}
+
+	deadline := now.Add(overlap)
+	for _, key := range keys {
+		if !key.Active(now) || (!key.ExpiresAt.IsZero() && key.ExpiresAt.Before(deadline)) {
+			continue
+		}
+		key.ExpiresAt = deadline
+		if err := store.Put(ctx, key); err != nil {
+			return "", APIKey{}, fmt.Errorf("serviceauth: expire key %s: %w", key.ID, err)
+		}
+	}
+	return plaintext, created, nil
+}
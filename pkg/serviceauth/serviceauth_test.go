@@ -0,0 +1,73 @@
+package serviceauth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	plaintext, key, err := NewAPIKey("payment-service", []string{"transaction:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(plaintext, "pk_payment-service_") || len(plaintext) < len("pk_payment-service_")+32 {
+		t.Errorf("plaintext = %q", plaintext)
+	}
+	if key.Hash != HashKey(plaintext) || strings.Contains(key.Hash, plaintext) {
+		t.Error("stored key must hold the hash, not the plaintext")
+	}
+
+	if _, _, err := NewAPIKey("Payment Service", nil); err == nil {
+		t.Error("invalid service id accepted")
+	}
+}
+
+func TestAPIKeyActive(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		key  APIKey
+		want bool
+	}{
+		{"unbounded", APIKey{}, true},
+		{"not yet valid", APIKey{NotBefore: now.Add(time.Minute)}, false},
+		{"expired", APIKey{ExpiresAt: now.Add(-time.Minute)}, false},
+		{"within window", APIKey{NotBefore: now.Add(-time.Minute), ExpiresAt: now.Add(time.Minute)}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.key.Active(now); got != tt.want {
+			t.Errorf("%s: Active = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	oldPlaintext, oldKey, err := NewAPIKey("orders", []string{"orders:*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryStore(oldKey)
+
+	newPlaintext, newKey, err := Rotate(ctx, store, "orders", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newPlaintext == oldPlaintext || newKey.Scopes[0] != "orders:*" {
+		t.Errorf("new key = %+v", newKey)
+	}
+
+	old, err := store.Lookup(ctx, oldKey.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// both keys work during the overlap, and the old one stops after it
+	if !old.Active(time.Now()) || !newKey.Active(time.Now()) {
+		t.Error("keys must overlap")
+	}
+	if old.Active(time.Now().Add(2 * time.Hour)) {
+		t.Errorf("old key still active after the overlap: expires %v", old.ExpiresAt)
+	}
+}
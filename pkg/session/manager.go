@@ -0,0 +1,310 @@
+package session
+
+import (
+	"common/pkg/jwt"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+
+	// TokenType is the token_type of the pair, as in OAuth 2.0 responses
+	TokenType = "Bearer"
+
+	minSecretSize = 32
+)
+
+type Config struct {
+	// Secret signs refresh tokens and must be at least 32 bytes
+	Secret []byte
+	// RefreshTTL is how long a session lasts without being refreshed;
+	// DefaultRefreshTTL when zero
+	RefreshTTL time.Duration
+	// MaxLifetime ends a session this long after login however often it is
+	// refreshed; unbounded when zero
+	MaxLifetime time.Duration
+}
+
+// TokenPair is returned on login and on every refresh
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	SessionID        string    `json:"session_id"`
+}
+
+// Manager issues, refreshes and revokes sessions. Access token lifetime is
+// the TokenDuration of the jwt.JWT config
+type Manager struct {
+	jwt      jwt.JWT
+	store    Store
+	denylist Denylist
+	config   Config
+	now      func() time.Time
+}
+
+func NewManager(j jwt.JWT, store Store, denylist Denylist, config Config) (*Manager, error) {
+	if j == nil || store == nil || denylist == nil {
+		return nil, errors.New("session: jwt, store and denylist are required")
+	}
+	if len(config.Secret) < minSecretSize {
+		return nil, fmt.Errorf("session: secret must be at least %d bytes", minSecretSize)
+	}
+	if config.RefreshTTL < 0 || config.MaxLifetime < 0 {
+		return nil, errors.New("session: refresh ttl and max lifetime must not be negative")
+	}
+	if config.RefreshTTL == 0 {
+		config.RefreshTTL = DefaultRefreshTTL
+	}
+	return &Manager{jwt: j, store: store, denylist: denylist, config: config, now: time.Now}, nil
+}
+
+// Create starts a session for userID. claims are added to every access
+// token of the session; sub, user_id, sid, jti, iat and exp are set by the
+// manager
+func (m *Manager) Create(ctx context.Context, userID string, claims map[string]interface{}) (*TokenPair, error) {
+	if userID == "" {
+		return nil, errors.New("session: user id is required")
+	}
+
+	now := m.now()
+	s := Session{
+		ID:          uuid.NewString(),
+		UserID:      userID,
+		Claims:      claims,
+		CreatedAt:   now,
+		RefreshedAt: now,
+	}
+	s.ExpiresAt = m.expiry(s, now)
+
+	pair, err := m.issue(&s, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.Create(ctx, s); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Refresh rotates the refresh token and issues a new pair. A refresh token
+// that was already used revokes the session and returns ErrTokenReused, so a
+// stolen token stops working for both the thief and the user
+func (m *Manager) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	id, generation, err := m.parseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	s, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	if !now.Before(s.ExpiresAt) {
+		if _, err := m.store.Delete(ctx, id); err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		return nil, ErrExpired
+	}
+	if generation != s.Generation || !hmac.Equal([]byte(hashToken(refreshToken)), []byte(s.RefreshHash)) {
+		return nil, m.revokeReused(ctx, id)
+	}
+
+	previous := s.Generation
+	s.Generation++
+	s.RefreshedAt = now
+	s.ExpiresAt = m.expiry(*s, now)
+	s.AccessTokens = pruneAccessTokens(s.AccessTokens, now)
+	pair, err := m.issue(s, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.store.Rotate(ctx, *s, previous)
+	if errors.Is(err, ErrTokenReused) {
+		// a concurrent refresh with the same token got there first
+		return nil, m.revokeReused(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Revoke ends a session and denies its unexpired access tokens
+func (m *Manager) Revoke(ctx context.Context, sessionID string) error {
+	s, err := m.store.Delete(ctx, sessionID)
+	if s == nil {
+		return err
+	}
+
+	errs := []error{err}
+	for _, token := range pruneAccessTokens(s.AccessTokens, m.now()) {
+		if err := m.denylist.Deny(ctx, token.JTI, token.ExpiresAt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RevokeAll ends every session of userID, such as after a password change
+func (m *Manager) RevokeAll(ctx context.Context, userID string) error {
+	sessions, err := m.store.UserSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, s := range sessions {
+		if err := m.Revoke(ctx, s.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Sessions lists the active sessions of userID
+func (m *Manager) Sessions(ctx context.Context, userID string) ([]Session, error) {
+	sessions, err := m.store.UserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	active := sessions[:0]
+	for _, s := range sessions {
+		if now.Before(s.ExpiresAt) {
+			active = append(active, s)
+		}
+	}
+	return active, nil
+}
+
+func (m *Manager) revokeReused(ctx context.Context, id string) error {
+	if err := m.Revoke(ctx, id); err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w; revoking session: %v", ErrTokenReused, err)
+	}
+	return ErrTokenReused
+}
+
+// expiry slides the session forward by RefreshTTL, up to MaxLifetime
+func (m *Manager) expiry(s Session, now time.Time) time.Time {
+	expiresAt := now.Add(m.config.RefreshTTL)
+	if m.config.MaxLifetime > 0 {
+		if limit := s.CreatedAt.Add(m.config.MaxLifetime); limit.Before(expiresAt) {
+			return limit
+		}
+	}
+	return expiresAt
+}
+
+// issue signs an access token and a refresh token for the current
+// generation of s, recording both in s
+func (m *Manager) issue(s *Session, now time.Time) (*TokenPair, error) {
+	claims := make(map[string]interface{}, len(s.Claims)+6)
+	for name, value := range s.Claims {
+		claims[name] = value
+	}
+	jti := uuid.NewString()
+	claims["sub"] = s.UserID
+	claims["user_id"] = s.UserID
+	claims["sid"] = s.ID
+	claims["jti"] = jti
+	claims["iat"] = now.Unix()
+
+	accessToken, err := m.jwt.GenerateToken(claims)
+	if err != nil {
+		return nil, fmt.Errorf("session: issuing access token: %w", err)
+	}
+	expiresAt, err := m.accessExpiry(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := m.refreshToken(s.ID, s.Generation)
+	if err != nil {
+		return nil, err
+	}
+	s.RefreshHash = hashToken(refreshToken)
+	s.AccessTokens = append(s.AccessTokens, AccessToken{JTI: jti, ExpiresAt: expiresAt})
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        TokenType,
+		ExpiresAt:        expiresAt,
+		RefreshExpiresAt: s.ExpiresAt,
+		SessionID:        s.ID,
+	}, nil
+}
+
+// accessExpiry reads exp back from a token, since the jwt.JWT config sets it
+func (m *Manager) accessExpiry(token string) (time.Time, error) {
+	parsed, err := m.jwt.ValidateToken(token)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("session: issued access token is invalid: %w", err)
+	}
+	claims, err := m.jwt.GetClaims(parsed)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("session: issued access token is invalid: %w", err)
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, errors.New("session: issued access token has no exp")
+	}
+	return time.Unix(int64(exp), 0), nil
+}
+
+// refreshToken returns <session>.<generation>.<random>.<mac>. The MAC lets
+// forged tokens be rejected without touching the session, so guessing a
+// session ID cannot trigger reuse detection
+func (m *Manager) refreshToken(sessionID string, generation int) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	payload := sessionID + "." + strconv.Itoa(generation) + "." + base64.RawURLEncoding.EncodeToString(random)
+	return payload + "." + m.sign(payload), nil
+}
+
+func (m *Manager) parseRefreshToken(token string) (string, int, error) {
+	dot := strings.LastIndexByte(token, '.')
+	if dot < 0 || !hmac.Equal([]byte(token[dot+1:]), []byte(m.sign(token[:dot]))) {
+		return "", 0, ErrInvalidToken
+	}
+	parts := strings.Split(token[:dot], ".")
+	if len(parts) != 3 {
+		return "", 0, ErrInvalidToken
+	}
+	generation, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, ErrInvalidToken
+	}
+	return parts[0], generation, nil
+}
+
+func (m *Manager) sign(payload string) string {
+	mac := hmac.New(sha256.New, m.config.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,287 @@
+package session
+
+import (
+	"common/pkg/jwt"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestManager(t *testing.T, config Config) (*Manager, *memoryStore, *memoryDenylist, jwt.JWT) {
+	t.Helper()
+	tokens := jwt.New(jwt.JWTConfig{SecretKey: []byte("jwt-secret"), TokenDuration: time.Minute})
+	store := NewMemoryStore()
+	denylist := NewMemoryDenylist()
+	if config.Secret == nil {
+		config.Secret = testSecret
+	}
+	m, err := NewManager(tokens, store, denylist, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, store, denylist, tokens
+}
+
+func accessClaims(t *testing.T, tokens jwt.JWT, token string) map[string]interface{} {
+	t.Helper()
+	parsed, err := tokens.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("access token invalid: %v", err)
+	}
+	claims, err := tokens.GetClaims(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func TestNewManagerValidation(t *testing.T) {
+	tokens := jwt.New(jwt.JWTConfig{SecretKey: []byte("jwt-secret"), TokenDuration: time.Minute})
+	tests := []struct {
+		name     string
+		jwt      jwt.JWT
+		denylist Denylist
+		config   Config
+	}{
+		{"short secret", tokens, NewMemoryDenylist(), Config{Secret: []byte("short")}},
+		{"negative ttl", tokens, NewMemoryDenylist(), Config{Secret: testSecret, RefreshTTL: -time.Second}},
+		{"missing jwt", nil, NewMemoryDenylist(), Config{Secret: testSecret}},
+		{"missing denylist", tokens, nil, Config{Secret: testSecret}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewManager(tt.jwt, NewMemoryStore(), tt.denylist, tt.config); err == nil {
+				t.Error("NewManager accepted an invalid configuration")
+			}
+		})
+	}
+}
+
+func TestCreateAndRefresh(t *testing.T) {
+	ctx := context.Background()
+	m, _, _, tokens := newTestManager(t, Config{})
+
+	pair, err := m.Create(ctx, "u1", map[string]interface{}{"role": "admin", "sub": "spoofed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := accessClaims(t, tokens, pair.AccessToken)
+	if claims["sub"] != "u1" || claims["user_id"] != "u1" || claims["sid"] != pair.SessionID || claims["role"] != "admin" {
+		t.Errorf("access claims = %v", claims)
+	}
+	if jti, _ := claims["jti"].(string); jti == "" {
+		t.Error("access token has no jti")
+	}
+	if pair.TokenType != TokenType || pair.RefreshToken == "" {
+		t.Errorf("pair = %+v", pair)
+	}
+
+	refreshed, err := m.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.SessionID != pair.SessionID || refreshed.RefreshToken == pair.RefreshToken {
+		t.Errorf("refresh did not rotate the token of the same session: %+v", refreshed)
+	}
+	if accessClaims(t, tokens, refreshed.AccessToken)["jti"] == claims["jti"] {
+		t.Error("refresh reused the access token jti")
+	}
+	if _, err := m.Refresh(ctx, refreshed.RefreshToken); err != nil {
+		t.Errorf("second refresh: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+	m, store, denylist, tokens := newTestManager(t, Config{})
+
+	pair, err := m.Create(ctx, "u1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := m.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("reused token err = %v, want ErrTokenReused", err)
+	}
+	if _, err := store.Get(ctx, pair.SessionID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("session still stored after reuse: %v", err)
+	}
+	if _, err := m.Refresh(ctx, refreshed.RefreshToken); !errors.Is(err, ErrNotFound) {
+		t.Errorf("current token after reuse err = %v, want ErrNotFound", err)
+	}
+	for _, access := range []string{pair.AccessToken, refreshed.AccessToken} {
+		jti := accessClaims(t, tokens, access)["jti"].(string)
+		if denied, _ := denylist.Denied(ctx, jti); !denied {
+			t.Errorf("access token %s not denied after reuse", jti)
+		}
+	}
+}
+
+func TestRefreshRejectsInvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	m, store, _, _ := newTestManager(t, Config{})
+
+	pair, err := m.Create(ctx, "u1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(pair.RefreshToken, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"garbage", "not-a-token"},
+		{"bad signature", strings.Join(parts[:3], ".") + ".AAAA"},
+		{"older generation with bad signature", parts[0] + ".-1." + parts[2] + "." + parts[3]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Refresh(ctx, tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("err = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+
+	// forged tokens must not revoke the session
+	if _, err := store.Get(ctx, pair.SessionID); err != nil {
+		t.Errorf("session revoked by forged tokens: %v", err)
+	}
+}
+
+func TestRefreshExpiry(t *testing.T) {
+	ctx := context.Background()
+	m, store, _, _ := newTestManager(t, Config{RefreshTTL: time.Hour, MaxLifetime: 90 * time.Minute})
+	// iat is checked against the real clock, so the fake one runs in the past
+	now := time.Now().Add(-3 * time.Hour)
+	m.now = func() time.Time { return now }
+	store.now = m.now
+
+	pair, err := m.Create(ctx, "u1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pair.RefreshExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("refresh expires at %v, want an hour after login", pair.RefreshExpiresAt)
+	}
+
+	now = now.Add(50 * time.Minute)
+	refreshed, err := m.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(40 * time.Minute); !refreshed.RefreshExpiresAt.Equal(want) {
+		t.Errorf("refresh expires at %v, want %v capped by max lifetime", refreshed.RefreshExpiresAt, want)
+	}
+
+	// a store may still hold the session; the manager checks the expiry itself
+	now = now.Add(41 * time.Minute)
+	store.now = func() time.Time { return now.Add(-time.Hour) }
+	if _, err := m.Refresh(ctx, refreshed.RefreshToken); !errors.Is(err, ErrExpired) {
+		t.Errorf("err = %v, want ErrExpired", err)
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	ctx := context.Background()
+	m, _, denylist, tokens := newTestManager(t, Config{})
+
+	var pairs []*TokenPair
+	for _, user := range []string{"u1", "u1", "u2"} {
+		pair, err := m.Create(ctx, user, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pairs = append(pairs, pair)
+	}
+
+	if sessions, _ := m.Sessions(ctx, "u1"); len(sessions) != 2 {
+		t.Fatalf("u1 has %d sessions, want 2", len(sessions))
+	}
+	if err := m.RevokeAll(ctx, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := m.Sessions(ctx, "u1"); len(sessions) != 0 {
+		t.Errorf("u1 has %d sessions after RevokeAll", len(sessions))
+	}
+	if sessions, _ := m.Sessions(ctx, "u2"); len(sessions) != 1 {
+		t.Errorf("u2 has %d sessions, want 1", len(sessions))
+	}
+
+	for i, pair := range pairs {
+		jti := accessClaims(t, tokens, pair.AccessToken)["jti"].(string)
+		denied, _ := denylist.Denied(ctx, jti)
+		if want := i < 2; denied != want {
+			t.Errorf("session %d denied = %v, want %v", i, denied, want)
+		}
+	}
+
+	if err := m.Revoke(ctx, pairs[0].SessionID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoking twice err = %v, want ErrNotFound", err)
+	}
+}
+
+// indexFailingStore removes sessions but fails to clean up after them, as
+// the Redis store does when SRem fails
+type indexFailingStore struct {
+	Store
+}
+
+func (s indexFailingStore) Delete(ctx context.Context, id string) (*Session, error) {
+	session, err := s.Store.Delete(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return session, errors.New("removing from user index: connection reset")
+}
+
+func TestRevokeDeniesTokensWhenDeleteFailsPartly(t *testing.T) {
+	ctx := context.Background()
+	tokens := jwt.New(jwt.JWTConfig{SecretKey: []byte("jwt-secret"), TokenDuration: time.Minute})
+	denylist := NewMemoryDenylist()
+	m, err := NewManager(tokens, indexFailingStore{NewMemoryStore()}, denylist, Config{Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair, err := m.Create(ctx, "u1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Revoke(ctx, pair.SessionID); err == nil {
+		t.Error("Revoke hid the store error")
+	}
+	jti := accessClaims(t, tokens, pair.AccessToken)["jti"].(string)
+	if denied, _ := denylist.Denied(ctx, jti); !denied {
+		t.Error("access token not denied when the store failed after removing the session")
+	}
+}
+
+func TestMemoryStoreRotateConflict(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	s := Session{ID: "s1", UserID: "u1", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := store.Create(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Generation = 1
+	if err := store.Rotate(ctx, s, 0); err != nil {
+		t.Fatalf("first rotation: %v", err)
+	}
+	if err := store.Rotate(ctx, s, 0); !errors.Is(err, ErrTokenReused) {
+		t.Errorf("stale rotation err = %v, want ErrTokenReused", err)
+	}
+	if err := store.Rotate(ctx, Session{ID: "missing"}, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing session err = %v, want ErrNotFound", err)
+	}
+}
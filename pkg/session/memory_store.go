@@ -0,0 +1,150 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryStore keeps sessions in process, for tests and single-instance
+// setups. Expired sessions are treated as missing
+type memoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+	byUser   map[string]map[string]bool
+	now      func() time.Time
+}
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{
+		sessions: make(map[string]Session),
+		byUser:   make(map[string]map[string]bool),
+		now:      time.Now,
+	}
+}
+
+func (s *memoryStore) Create(ctx context.Context, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = cloneSession(session)
+	if s.byUser[session.UserID] == nil {
+		s.byUser[session.UserID] = make(map[string]bool)
+	}
+	s.byUser[session.UserID][session.ID] = true
+	return nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.get(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	session = cloneSession(session)
+	return &session, nil
+}
+
+func (s *memoryStore) Rotate(ctx context.Context, session Session, generation int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.get(session.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	if current.Generation != generation {
+		return ErrTokenReused
+	}
+	s.sessions[session.ID] = cloneSession(session)
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.get(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	s.remove(session)
+	return &session, nil
+}
+
+func (s *memoryStore) UserSessions(ctx context.Context, userID string) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sessions []Session
+	for id := range s.byUser[userID] {
+		if session, ok := s.get(id); ok {
+			sessions = append(sessions, cloneSession(session))
+		}
+	}
+	return sessions, nil
+}
+
+// get returns an unexpired session, dropping it once it has expired
+func (s *memoryStore) get(id string) (Session, bool) {
+	session, ok := s.sessions[id]
+	if !ok {
+		return Session{}, false
+	}
+	if !s.now().Before(session.ExpiresAt) {
+		s.remove(session)
+		return Session{}, false
+	}
+	return session, true
+}
+
+func (s *memoryStore) remove(session Session) {
+	delete(s.sessions, session.ID)
+	delete(s.byUser[session.UserID], session.ID)
+	if len(s.byUser[session.UserID]) == 0 {
+		delete(s.byUser, session.UserID)
+	}
+}
+
+// cloneSession copies the access token list, which the manager prunes in place
+func cloneSession(session Session) Session {
+	session.AccessTokens = append([]AccessToken(nil), session.AccessTokens...)
+	return session
+}
+
+// memoryDenylist keeps denied token IDs in process until they expire
+type memoryDenylist struct {
+	mu     sync.Mutex
+	denied map[string]time.Time
+	now    func() time.Time
+}
+
+func NewMemoryDenylist() *memoryDenylist {
+	return &memoryDenylist{denied: make(map[string]time.Time), now: time.Now}
+}
+
+func (d *memoryDenylist) Deny(ctx context.Context, jti string, until time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for id, expiresAt := range d.denied {
+		if !now.Before(expiresAt) {
+			delete(d.denied, id)
+		}
+	}
+	if now.Before(until) {
+		d.denied[jti] = until
+	}
+	return nil
+}
+
+func (d *memoryDenylist) Denied(ctx context.Context, jti string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	until, ok := d.denied[jti]
+	return ok && d.now().Before(until), nil
+}
@@ -0,0 +1,193 @@
+package session
+
+import (
+	"common/pkg/redis"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// rotateScript replaces the session while its generation is ARGV[1],
+// returning 1 on success, 0 when it is gone and -1 when it has moved on
+const rotateScript = `
+local current = redis.call("GET", KEYS[1])
+if not current then
+	return 0
+end
+if cjson.decode(current).generation ~= tonumber(ARGV[1]) then
+	return -1
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+return 1
+`
+
+// deleteScript removes the session and returns it, or false when it is gone
+const deleteScript = `
+local current = redis.call("GET", KEYS[1])
+if current then
+	redis.call("DEL", KEYS[1])
+end
+return current
+`
+
+// redisStore keeps each session as JSON under <prefix>:<id>, expiring with
+// the session, and the IDs of a user's sessions in the set
+// <prefix>:user:<user id>. Set members whose session expired are removed
+// when the user's sessions are listed
+type redisStore struct {
+	redis  redis.Redis
+	prefix string
+}
+
+// NewRedisStore creates a store that namespaces its keys with prefix
+func NewRedisStore(r redis.Redis, prefix string) *redisStore {
+	if prefix == "" {
+		prefix = "session"
+	}
+	return &redisStore{redis: r, prefix: prefix}
+}
+
+func (s *redisStore) Create(ctx context.Context, session Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if err := s.redis.Set(s.key(session.ID), data, ttlUntil(session.ExpiresAt)).Err(); err != nil {
+		return err
+	}
+	return s.redis.SAdd(s.userKey(session.UserID), session.ID).Err()
+}
+
+func (s *redisStore) Get(ctx context.Context, id string) (*Session, error) {
+	data, err := s.redis.Get(s.key(id)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeSession(data)
+}
+
+func (s *redisStore) Rotate(ctx context.Context, session Session, generation int) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.redis.Eval(rotateScript, []string{s.key(session.ID)}, generation, data, ttlUntil(session.ExpiresAt).Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case 0:
+		return ErrNotFound
+	case -1:
+		return ErrTokenReused
+	}
+	return nil
+}
+
+func (s *redisStore) Delete(ctx context.Context, id string) (*Session, error) {
+	result, err := s.redis.Eval(deleteScript, []string{s.key(id)}).Text()
+	if errors.Is(err, goredis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := decodeSession([]byte(result))
+	if err != nil {
+		return nil, err
+	}
+	// the session is gone either way, so it is returned for Revoke to deny
+	// its tokens; a stale index entry is dropped by UserSessions later
+	if err := s.redis.SRem(s.userKey(session.UserID), id).Err(); err != nil {
+		return session, fmt.Errorf("session: removing from user index: %w", err)
+	}
+	return session, nil
+}
+
+func (s *redisStore) UserSessions(ctx context.Context, userID string) ([]Session, error) {
+	setKey := s.userKey(userID)
+	ids, err := s.redis.SMembers(setKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	for _, id := range ids {
+		session, err := s.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			// expired by Redis; drop it from the index
+			s.redis.SRem(setKey, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, nil
+}
+
+func (s *redisStore) key(id string) string {
+	return s.prefix + ":" + id
+}
+
+func (s *redisStore) userKey(userID string) string {
+	return s.prefix + ":user:" + userID
+}
+
+// redisDenylist keeps each denied token ID under <prefix>:deny:<jti> until
+// the token expires
+type redisDenylist struct {
+	redis  redis.Redis
+	prefix string
+}
+
+// NewRedisDenylist creates a denylist that namespaces its keys with prefix
+func NewRedisDenylist(r redis.Redis, prefix string) *redisDenylist {
+	if prefix == "" {
+		prefix = "session"
+	}
+	return &redisDenylist{redis: r, prefix: prefix}
+}
+
+func (d *redisDenylist) Deny(ctx context.Context, jti string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	return d.redis.Set(d.prefix+":deny:"+jti, 1, ttl).Err()
+}
+
+func (d *redisDenylist) Denied(ctx context.Context, jti string) (bool, error) {
+	err := d.redis.Get(d.prefix + ":deny:" + jti).Err()
+	if errors.Is(err, goredis.Nil) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func decodeSession(data []byte) (*Session, error) {
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ttlUntil returns the time left until expiresAt, at least a millisecond so
+// Redis does not keep the key forever
+func ttlUntil(expiresAt time.Time) time.Duration {
+	if ttl := time.Until(expiresAt); ttl > time.Millisecond {
+		return ttl
+	}
+	return time.Millisecond
+}
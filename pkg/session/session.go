@@ -0,0 +1,83 @@
+// Package session issues access and refresh token pairs for user logins.
+// Access tokens are short-lived JWTs carrying a jti and the session ID; the
+// refresh token is opaque and is rotated on every use. Presenting a refresh
+// token that was already rotated is treated as theft: the whole session is
+// revoked and its outstanding access tokens are added to a jti denylist
+// that AuthMiddleware and the gRPC auth interceptors consult
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for sessions that were revoked or have expired
+	ErrNotFound     = errors.New("session: not found")
+	ErrInvalidToken = errors.New("session: invalid refresh token")
+	ErrExpired      = errors.New("session: expired")
+	// ErrTokenReused means a rotated refresh token was presented again; the
+	// session has been revoked
+	ErrTokenReused = errors.New("session: refresh token reused")
+)
+
+// Session is one login of a user
+type Session struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	// Generation counts refresh token rotations; only the refresh token of
+	// the current generation is accepted
+	Generation int `json:"generation"`
+	// RefreshHash is the SHA-256 of the current refresh token
+	RefreshHash string `json:"refresh_hash"`
+	// Claims are copied into every access token of the session
+	Claims map[string]interface{} `json:"claims,omitempty"`
+	// AccessTokens are the unexpired access tokens issued for the session,
+	// denied when it is revoked
+	AccessTokens []AccessToken `json:"access_tokens"`
+	CreatedAt    time.Time     `json:"created_at"`
+	RefreshedAt  time.Time     `json:"refreshed_at"`
+	ExpiresAt    time.Time     `json:"expires_at"`
+}
+
+type AccessToken struct {
+	JTI       string    `json:"jti"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store holds sessions. Implementations must be safe for concurrent use
+type Store interface {
+	// Create stores a new session until its ExpiresAt
+	Create(ctx context.Context, s Session) error
+	// Get returns the session, or ErrNotFound
+	Get(ctx context.Context, id string) (*Session, error)
+	// Rotate replaces the session only while its stored generation is still
+	// generation, returning ErrTokenReused when another refresh won the race
+	// and ErrNotFound when it is gone
+	Rotate(ctx context.Context, s Session, generation int) error
+	// Delete removes the session and returns it, or ErrNotFound. The
+	// session is returned along with an error when it was removed but
+	// cleaning up after it failed
+	Delete(ctx context.Context, id string) (*Session, error)
+	// UserSessions lists the sessions of a user
+	UserSessions(ctx context.Context, userID string) ([]Session, error)
+}
+
+// Denylist holds the IDs of access tokens revoked before their expiry
+type Denylist interface {
+	// Deny rejects jti until the token expires at until
+	Deny(ctx context.Context, jti string, until time.Time) error
+	Denied(ctx context.Context, jti string) (bool, error)
+}
+
+// pruneAccessTokens drops the tokens that have expired by now
+func pruneAccessTokens(tokens []AccessToken, now time.Time) []AccessToken {
+	kept := tokens[:0]
+	for _, token := range tokens {
+		if token.ExpiresAt.After(now) {
+			kept = append(kept, token)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,57 @@
+package task
+
+import (
+	"common/pkg/correlation"
+	"context"
+	"encoding/json"
+)
+
+// CorrelationPayloadKey is the payload field that carries the request and
+// correlation IDs of the enqueuing request. asynq tasks have no headers, so
+// the IDs travel inside JSON object payloads. This has two side effects:
+//   - the payload differs per request, so asynq.Unique, which hashes the
+//     payload, no longer deduplicates tasks enqueued from different requests
+//   - handlers that decode with DisallowUnknownFields reject the task
+//
+// Use the methods without Context for such tasks
+const CorrelationPayloadKey = "_correlation"
+
+// withCorrelation adds the IDs in ctx to a JSON object payload. Other
+// payloads are returned unchanged
+func withCorrelation(ctx context.Context, payload []byte) []byte {
+	ids, ok := correlation.FromContext(ctx)
+	if !ok {
+		return payload
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &object); err != nil || object == nil {
+		return payload
+	}
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return payload
+	}
+	object[CorrelationPayloadKey] = encoded
+
+	tagged, err := json.Marshal(object)
+	if err != nil {
+		return payload
+	}
+	return tagged
+}
+
+// ContextFromPayload returns ctx carrying the IDs stored in payload by the
+// *Context enqueue methods, or ctx unchanged when there are none
+func ContextFromPayload(ctx context.Context, payload []byte) context.Context {
+	var envelope struct {
+		IDs *correlation.IDs `json:"_correlation"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil || envelope.IDs == nil {
+		return ctx
+	}
+	if !correlation.Valid(envelope.IDs.RequestID) || !correlation.Valid(envelope.IDs.CorrelationID) {
+		return ctx
+	}
+	return correlation.NewContext(ctx, *envelope.IDs)
+}
@@ -0,0 +1,55 @@
+package task
+
+import (
+	"common/pkg/correlation"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestCorrelationRoundTrip(t *testing.T) {
+	ids := correlation.IDs{RequestID: "req-1", CorrelationID: "corr-1"}
+	ctx := correlation.NewContext(context.Background(), ids)
+
+	payload := withCorrelation(ctx, []byte(`{"user_id":"u1"}`))
+
+	var decoded struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.Unmarshal(payload, &decoded); err != nil || decoded.UserID != "u1" {
+		t.Fatalf("payload = %s, want the original fields kept", payload)
+	}
+	got, ok := correlation.FromContext(ContextFromPayload(context.Background(), payload))
+	if !ok || got != ids {
+		t.Errorf("ids = %+v, %v; want %+v", got, ok, ids)
+	}
+}
+
+func TestWithCorrelationLeavesPayloadUnchanged(t *testing.T) {
+	ctx := correlation.NewContext(context.Background(), correlation.IDs{RequestID: "req-1", CorrelationID: "req-1"})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		payload string
+	}{
+		{"no ids in context", context.Background(), `{"a":1}`},
+		{"array payload", ctx, `[1,2]`},
+		{"string payload", ctx, `"text"`},
+		{"null payload", ctx, `null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withCorrelation(tt.ctx, []byte(tt.payload)); string(got) != tt.payload {
+				t.Errorf("payload = %s, want %s", got, tt.payload)
+			}
+		})
+	}
+}
+
+func TestContextFromPayloadRejectsInvalidIDs(t *testing.T) {
+	payload := []byte(`{"_correlation":{"request_id":"bad id","correlation_id":"x"}}`)
+	if _, ok := correlation.FromContext(ContextFromPayload(context.Background(), payload)); ok {
+		t.Error("invalid ids were restored")
+	}
+}
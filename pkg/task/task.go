@@ -1,6 +1,7 @@
 package task
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
@@ -16,6 +17,14 @@ type TaskEnqueuer interface {
 	Enqueue(taskType string, payload interface{}, queueName string) (*asynq.Task, string, error)
 	EnqueueAt(taskType string, payload interface{}, queueName string, processAt time.Time) (*asynq.Task, string, error)
 	EnqueueIn(taskType string, payload interface{}, queueName string, delay time.Duration) (*asynq.Task, string, error)
+	// EnqueueContext is Enqueue with the request and correlation IDs of ctx
+	// added to the payload. This changes the payload, which matters for
+	// unique tasks and strict decoders; see CorrelationPayloadKey
+	EnqueueContext(ctx context.Context, taskType string, payload interface{}, queueName string) (*asynq.Task, string, error)
+	// EnqueueAtContext and EnqueueInContext are EnqueueAt and EnqueueIn with
+	// the correlation IDs of ctx, as in EnqueueContext
+	EnqueueAtContext(ctx context.Context, taskType string, payload interface{}, queueName string, processAt time.Time) (*asynq.Task, string, error)
+	EnqueueInContext(ctx context.Context, taskType string, payload interface{}, queueName string, delay time.Duration) (*asynq.Task, string, error)
 	Close()
 }
 
@@ -23,6 +32,8 @@ type AsynqTaskEnqueuer struct {
 	client *asynq.Client
 }
 
+var _ TaskEnqueuer = (*AsynqTaskEnqueuer)(nil)
+
 func NewAsynqTaskEnqueuer(redisAddr, redisUsername, redisPassword string) *AsynqTaskEnqueuer {
 	redisConnection := asynq.RedisClientOpt{
 		Addr:     redisAddr,
@@ -41,6 +52,18 @@ func (e *AsynqTaskEnqueuer) Enqueue(taskType string, payload interface{}, queueN
 	return e.enqueue(task, queueName, nil)
 }
 
+// EnqueueContext creates and enqueues a task immediately, tagging its
+// payload with the correlation IDs in ctx. The tagged payload defeats
+// asynq.Unique and strict decoders; see CorrelationPayloadKey
+func (e *AsynqTaskEnqueuer) EnqueueContext(ctx context.Context, taskType string, payload interface{}, queueName string) (*asynq.Task, string, error) {
+	task, err := parseData(taskType, payload)
+	if err != nil {
+		return nil, "", err
+	}
+	task.Payload = withCorrelation(ctx, task.Payload)
+	return e.enqueue(task, queueName, nil)
+}
+
 // EnqueueAt creates and enqueues a task to run at a specific time.
 func (e *AsynqTaskEnqueuer) EnqueueAt(taskType string, payload interface{}, queueName string, processAt time.Time) (*asynq.Task, string, error) {
 	task, err := parseData(taskType, payload)
@@ -61,6 +84,30 @@ func (e *AsynqTaskEnqueuer) EnqueueIn(taskType string, payload interface{}, queu
 	return e.enqueue(task, queueName, opts)
 }
 
+// EnqueueAtContext creates and enqueues a task to run at a specific time,
+// tagging its payload as EnqueueContext does
+func (e *AsynqTaskEnqueuer) EnqueueAtContext(ctx context.Context, taskType string, payload interface{}, queueName string, processAt time.Time) (*asynq.Task, string, error) {
+	task, err := parseData(taskType, payload)
+	if err != nil {
+		return nil, "", err
+	}
+	task.Payload = withCorrelation(ctx, task.Payload)
+	opts := []asynq.Option{asynq.ProcessAt(processAt)}
+	return e.enqueue(task, queueName, opts)
+}
+
+// EnqueueInContext creates and enqueues a task to run after a delay,
+// tagging its payload as EnqueueContext does
+func (e *AsynqTaskEnqueuer) EnqueueInContext(ctx context.Context, taskType string, payload interface{}, queueName string, delay time.Duration) (*asynq.Task, string, error) {
+	task, err := parseData(taskType, payload)
+	if err != nil {
+		return nil, "", err
+	}
+	task.Payload = withCorrelation(ctx, task.Payload)
+	opts := []asynq.Option{asynq.ProcessIn(delay)}
+	return e.enqueue(task, queueName, opts)
+}
+
 // enqueue is a helper function to enqueue a task with optional options.
 func (e *AsynqTaskEnqueuer) enqueue(task *Task, queueName string, opts []asynq.Option) (*asynq.Task, string, error) {
 	if opts == nil {
@@ -2,30 +2,40 @@ package utils
 
 import (
 	"common/dto"
+	"common/pkg/auth"
+	"common/pkg/correlation"
 	"context"
 	"errors"
 
 	"github.com/gin-gonic/gin"
 )
 
+// GetUserIdFromContext returns the user ID set by AuthMiddleware, falling
+// back to the principal in the request context
 func GetUserIdFromContext(ctx *gin.Context) (string, error) {
-	userID, ok := ctx.MustGet("user_id").(string)
-	if !ok {
-		return "", errors.New("user_id is required")
+	if userID, ok := ctx.Get("user_id"); ok {
+		if userID, ok := userID.(string); ok && userID != "" {
+			return userID, nil
+		}
+	}
+	if subject, err := auth.SubjectFromContext(ctx.Request.Context()); err == nil {
+		return subject, nil
 	}
-	return userID, nil
+	return "", errors.New("user_id is required")
 }
 
+// GetRequestIDFromContext returns the request ID from the correlation IDs
+// in ctx, falling back to the "request_id" key of a *gin.Context
 func GetRequestIDFromContext(ctx context.Context) string {
-	userID, ok := ctx.Value("request_id").(string)
-	if !ok {
-		return ""
+	if id := correlation.RequestIDFromContext(ctx); id != "" {
+		return id
 	}
-	return userID
+	requestID, _ := ctx.Value("request_id").(string)
+	return requestID
 }
 
 func GetDecryptedDataFromContext(ctx *gin.Context) (string, error) {
-	decryptedData, ok := ctx.MustGet("decrypted").(string)
+	decryptedData, ok := ctx.Value("decrypted").(string)
 	if !ok {
 		return "", errors.New("request decryption payload missing")
 	}
@@ -33,7 +43,7 @@ func GetDecryptedDataFromContext(ctx *gin.Context) (string, error) {
 }
 
 func GetDeviceInfoFromContext(ctx *gin.Context) (*dto.DeviceInfo, error) {
-	deviceInfo, ok := ctx.MustGet("device_info").(*dto.DeviceInfo)
+	deviceInfo, ok := ctx.Value("device_info").(*dto.DeviceInfo)
 	if !ok {
 		return nil, errors.New("device info is required")
 	}
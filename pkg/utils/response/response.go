@@ -68,6 +68,38 @@ func Forbidden(message string) APIResponse {
 	}
 }
 
+func TooManyRequests(message string) APIResponse {
+	return APIResponse{
+		Data:    nil,
+		Message: message,
+		Status:  http.StatusTooManyRequests,
+	}
+}
+
+func RequestEntityTooLarge(message string) APIResponse {
+	return APIResponse{
+		Data:    nil,
+		Message: message,
+		Status:  http.StatusRequestEntityTooLarge,
+	}
+}
+
+func InternalServerError(message string) APIResponse {
+	return APIResponse{
+		Data:    nil,
+		Message: message,
+		Status:  http.StatusInternalServerError,
+	}
+}
+
+func ServiceUnavailable(message string) APIResponse {
+	return APIResponse{
+		Data:    nil,
+		Message: message,
+		Status:  http.StatusServiceUnavailable,
+	}
+}
+
 func Created(message string, data interface{}) APIResponse {
 	return APIResponse{
 		Data:    data,
@@ -47,8 +47,14 @@ func (v *customValidator) ValidateStruct(s interface{}) error {
 		return nil
 	}
 
+	fieldErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		// s is not a struct or pointer to one
+		return err
+	}
+
 	var validationErrors ValidationErrors
-	for _, err := range err.(validator.ValidationErrors) {
+	for _, err := range fieldErrors {
 		fieldName := getJSONFieldName(s, err.StructField())
 		validationErrors = append(validationErrors, ValidationError{
 			Field:   fieldName,
@@ -1,6 +1,9 @@
 package worker
 
 import (
+	"common/pkg/logger"
+	"common/pkg/logger/adapter"
+	"common/pkg/task"
 	"context"
 
 	"github.com/hibiken/asynq"
@@ -11,23 +14,47 @@ type AsynqServer struct {
 	mux    *asynq.ServeMux
 }
 
-func NewAsynqServer(redisAddr, redisUsername, redisPassword string, concurrency int) *AsynqServer {
+// Option configures the server built by NewAsynqServer
+type Option func(*options)
+
+type options struct {
+	logger logger.Logger
+}
+
+// WithLogger routes asynq's own output through the given logger instead of
+// the standard library logger
+func WithLogger(l logger.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// NewAsynqServer creates a task server
+func NewAsynqServer(redisAddr, redisUsername, redisPassword string, concurrency int, opts ...Option) *AsynqServer {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	redisConnection := asynq.RedisClientOpt{
 		Addr:     redisAddr,
 		Password: redisPassword,
 	}
 
-	server := asynq.NewServer(
-		redisConnection,
-		asynq.Config{
-			Concurrency: concurrency,
-			Queues: map[string]int{
-				string(CriticalQueue): QueuePriorities[CriticalQueue],
-				string(DefaultQueue):  QueuePriorities[DefaultQueue],
-				string(LowQueue):      QueuePriorities[LowQueue],
-			},
+	config := asynq.Config{
+		Concurrency: concurrency,
+		Queues: map[string]int{
+			string(CriticalQueue): QueuePriorities[CriticalQueue],
+			string(DefaultQueue):  QueuePriorities[DefaultQueue],
+			string(LowQueue):      QueuePriorities[LowQueue],
 		},
-	)
+	}
+
+	if o.logger != nil {
+		config.Logger = adapter.NewAsynqLogger(o.logger)
+	}
+
+	server := asynq.NewServer(redisConnection, config)
 
 	mux := asynq.NewServeMux()
 
@@ -37,8 +64,13 @@ func NewAsynqServer(redisAddr, redisUsername, redisPassword string, concurrency
 	}
 }
 
+// HandleFunc registers handler for taskType. The handler context carries
+// the correlation IDs of the request that enqueued the task, when it was
+// enqueued with a *Context method
 func (s *AsynqServer) HandleFunc(taskType string, handler func(ctx context.Context, t *asynq.Task) error) {
-	s.mux.HandleFunc(taskType, handler)
+	s.mux.HandleFunc(taskType, func(ctx context.Context, t *asynq.Task) error {
+		return handler(task.ContextFromPayload(ctx, t.Payload()), t)
+	})
 }
 
 func (s *AsynqServer) Run() error {
@@ -51,3 +83,14 @@ func (s *AsynqServer) Run() error {
 func (s *AsynqServer) Stop() {
 	s.server.Stop()
 }
+
+// Start processes tasks in the background. Unlike Run it neither blocks nor
+// handles signals, so it can be driven by a server lifecycle hook
+func (s *AsynqServer) Start() error {
+	return s.server.Start(s.mux)
+}
+
+// Shutdown stops fetching tasks and waits for active ones to finish
+func (s *AsynqServer) Shutdown() {
+	s.server.Shutdown()
+}